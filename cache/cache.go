@@ -39,3 +39,12 @@ type Cache interface {
 	// Stops the background worker.
 	Stop()
 }
+
+// Observer is optional interface of Cache, that reports about items,
+// which are removed by the cache itself.
+type Observer interface {
+	// Register listener of items, evicted by policy.
+	OnEvict(listener func(key string, value interface{}))
+	// Register listener of removed expired items.
+	OnExpire(listener func(key string, value interface{}))
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/adverax/echo/generic"
+)
+
+// Codec is serializer of cached values.
+// Bundled codecs: GobCodec, JSONCodec and MsgpackCodec.
+// Any other serialization format can be plugged
+// by implementation of this interface.
+type Codec interface {
+	// Encode value into bytes.
+	Marshal(val interface{}) ([]byte, error)
+	// Decode bytes into dst (pointer).
+	Unmarshal(data []byte, dst interface{}) error
+}
+
+type gobCodec struct{}
+
+func (codec gobCodec) Marshal(val interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(val)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec gobCodec) Unmarshal(data []byte, dst interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dst)
+}
+
+type jsonCodec struct{}
+
+func (codec jsonCodec) Marshal(val interface{}) ([]byte, error) {
+	return json.Marshal(val)
+}
+
+func (codec jsonCodec) Unmarshal(data []byte, dst interface{}) error {
+	return json.Unmarshal(data, dst)
+}
+
+var (
+	GobCodec     Codec = gobCodec{}
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+var ErrInvalidDestination = errors.New("destination is not a pointer")
+
+// Value, serialized by codec
+type encoded []byte
+
+// Encode prepares value for storing in cache.
+// Values of plain types (numbers, strings, []byte, time) and all values
+// without codec are stored as is.
+func Encode(codec Codec, val interface{}) (interface{}, error) {
+	if codec == nil || isPlain(val) {
+		return val, nil
+	}
+
+	data, err := codec.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return encoded(data), nil
+}
+
+// Decode extracts value, prepared by Encode, into dst.
+func Decode(codec Codec, stored interface{}, dst interface{}) error {
+	if data, ok := stored.(encoded); ok {
+		return codec.Unmarshal(data, dst)
+	}
+
+	return Assign(dst, stored)
+}
+
+// Assign copies cached value into dst (pointer).
+// Values []byte and string are assigned without copying (zero-copy path),
+// so received []byte must be treated as read only.
+// Values of the same type are assigned directly, other values are
+// converted by generic.ConvertAssign.
+func Assign(dst, src interface{}) error {
+	switch d := dst.(type) {
+	case *[]byte:
+		if s, ok := src.([]byte); ok {
+			*d = s
+			return nil
+		}
+	case *string:
+		if s, ok := src.(string); ok {
+			*d = s
+			return nil
+		}
+	case *interface{}:
+		*d = src
+		return nil
+	}
+
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return ErrInvalidDestination
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.IsValid() && sv.Type() == dv.Elem().Type() {
+		dv.Elem().Set(sv)
+		return nil
+	}
+
+	err := generic.ConvertAssign(dst, src)
+	if err != nil {
+		return fmt.Errorf("cache: can not assign %T to %T: %v", src, dst, err)
+	}
+
+	return nil
+}
+
+func isPlain(val interface{}) bool {
+	switch val.(type) {
+	case nil, bool, string, []byte, time.Time,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type record struct {
+	Id   int
+	Name string
+}
+
+func TestCodec(t *testing.T) {
+	codecs := map[string]Codec{
+		"None":    nil,
+		"Gob":     GobCodec,
+		"JSON":    JSONCodec,
+		"Msgpack": MsgpackCodec,
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			src := record{Id: 1, Name: "Bob"}
+			stored, err := Encode(codec, src)
+			require.NoError(t, err)
+			src.Name = "Alice"
+
+			var dst record
+			require.NoError(t, Decode(codec, stored, &dst))
+			assert.Equal(t, record{Id: 1, Name: "Bob"}, dst)
+
+			// Plain values are never encoded
+			stored, err = Encode(codec, []byte("raw"))
+			require.NoError(t, err)
+			assert.Equal(t, []byte("raw"), stored)
+		})
+	}
+}
+
+func TestAssign(t *testing.T) {
+	raw := []byte("hello")
+	var b []byte
+	require.NoError(t, Assign(&b, raw))
+	assert.True(t, &raw[0] == &b[0], "zero copy")
+
+	var i int64
+	require.NoError(t, Assign(&i, "15"))
+	assert.Equal(t, int64(15), i)
+
+	var s string
+	require.NoError(t, Assign(&s, 15))
+	assert.Equal(t, "15", s)
+
+	var r record
+	assert.Error(t, Assign(&r, "Bob"))
+	assert.Equal(t, ErrInvalidDestination, Assign(r, record{}))
+}
@@ -20,17 +20,32 @@ package memory
 import (
 	"container/list"
 	"fmt"
+	"github.com/adverax/echo/cache"
 	"github.com/adverax/echo/data"
 	"hash/fnv"
-	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
 type Options struct {
-	// Max size of cache
+	// Max count of items.
+	// Default 16000000, if MaxBytes is not defined, otherwise 0 (unlimited)
 	MaxSize int64
+	// Max size of cache in approximate bytes of keys and values.
+	// Default 0 (unlimited)
+	MaxBytes int64
+	// Eviction policy. Default PolicyLRU
+	Policy Policy
+	// Width of frequency sketch for PolicyTinyLFU. Default 65536
+	Counters uint32
+	// Codec for storing values. Values of plain types (numbers, strings,
+	// []byte and time) are stored as is.
+	// Default nil (all values are stored by reference)
+	Codec cache.Codec
+	// Interval of background removing of expired items.
+	// Default 0 (expired items are removed on access or eviction only)
+	ExpireInterval time.Duration
 	// Bucket count (2 ^ n). Default 4
 	Buckets uint8
 	// The number of items to prune when memory is low. Default 500
@@ -47,19 +62,49 @@ type Options struct {
 	GetsPerPromote int32
 }
 
+// Stats of cache usage
+type Stats struct {
+	Hits      int64 `json:"hits"`      // Count of found items
+	Misses    int64 `json:"misses"`    // Count of not found (or expired) items
+	Evictions int64 `json:"evictions"` // Count of items, evicted by policy
+	Expired   int64 `json:"expired"`   // Count of removed expired items
+	Items     int64 `json:"items"`     // Count of items
+	Bytes     int64 `json:"bytes"`     // Approximate size of items
+}
+
+// Ratio of hits to all requests
+func (stats Stats) HitRate() float64 {
+	total := stats.Hits + stats.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(stats.Hits) / float64(total)
+}
+
+// Handler of removed item
+type Listener func(key string, value interface{})
+
 type Cache struct {
 	Options
-	list        *list.List
+	policy      policy
 	size        int64
+	items       int64
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
 	buckets     []*bucket
 	bucketMask  uint32
 	deletables  chan *entry
 	promotables chan *entry
 	donec       chan struct{}
+	mx          sync.RWMutex
+	onEvict     []Listener
+	onExpire    []Listener
 }
 
 func New(options Options) *Cache {
-	if options.MaxSize == 0 {
+	if options.MaxSize == 0 && options.MaxBytes == 0 {
 		options.MaxSize = 16000000
 	}
 
@@ -83,8 +128,12 @@ func New(options Options) *Cache {
 		options.GetsPerPromote = 3
 	}
 
+	if options.Counters == 0 {
+		options.Counters = 65536
+	}
+
 	c := &Cache{
-		list:       list.New(),
+		policy:     newPolicy(&options),
 		Options:    options,
 		bucketMask: uint32(options.Buckets) - 1,
 		buckets:    make([]*bucket, options.Buckets),
@@ -107,25 +156,31 @@ func (c *Cache) Get(key string, dst interface{}) error {
 		return data.ErrNoMatch
 	}
 
-	assign(dst, item.value)
-	return nil
+	return cache.Decode(c.Codec, item.value, dst)
 }
 
 // Get multiple values from cache.
 func (c *Cache) GetMulti(dict map[string]interface{}) (notFound []string, err error) {
 	for key, val := range dict {
 		item := c.get(key)
-		if item != nil {
-			assign(val, item.value)
-		} else {
+		if item == nil {
 			notFound = append(notFound, key)
+			continue
+		}
+		err = cache.Decode(c.Codec, item.value, val)
+		if err != nil {
+			return nil, err
 		}
 	}
-	return nil, nil
+	return notFound, nil
 }
 
 // Set the value in the cache for the specified duration
 func (c *Cache) Set(key string, value interface{}, duration time.Duration) error {
+	value, err := cache.Encode(c.Codec, value)
+	if err != nil {
+		return err
+	}
 	c.set(key, value, duration)
 	return nil
 }
@@ -163,11 +218,40 @@ func (c *Cache) Clear() error {
 	for _, bucket := range c.buckets {
 		bucket.clear()
 	}
-	c.size = 0
-	c.list = list.New()
+	atomic.StoreInt64(&c.size, 0)
+	atomic.StoreInt64(&c.items, 0)
+	c.policy.clear()
 	return nil
 }
 
+// Get statistics of cache usage.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:      atomic.LoadInt64(&c.hits),
+		Misses:    atomic.LoadInt64(&c.misses),
+		Evictions: atomic.LoadInt64(&c.evictions),
+		Expired:   atomic.LoadInt64(&c.expirations),
+		Items:     atomic.LoadInt64(&c.items),
+		Bytes:     atomic.LoadInt64(&c.size),
+	}
+}
+
+// OnEvict registers listener, that called after item is evicted by policy.
+// Listener is called from the background worker and must not call cache.
+func (c *Cache) OnEvict(listener func(key string, value interface{})) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.onEvict = append(c.onEvict, listener)
+}
+
+// OnExpire registers listener, that called after expired item is removed.
+// Listener must not call cache.
+func (c *Cache) OnExpire(listener func(key string, value interface{})) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.onExpire = append(c.onExpire, listener)
+}
+
 // Stops the background worker. Operations performed on the cache after Stop
 // is called are likely to panic
 func (c *Cache) Stop() {
@@ -188,14 +272,22 @@ func (c *Cache) deleteItem(bucket *bucket, item *entry) {
 }
 
 func (c *Cache) get(key string) *entry {
-	item := c.bucket(key).get(key)
+	bucket := c.bucket(key)
+	item := bucket.get(key)
 	if item == nil {
+		atomic.AddInt64(&c.misses, 1)
 		return nil
 	}
 	if item.expires > time.Now().UnixNano() {
+		atomic.AddInt64(&c.hits, 1)
 		c.promote(item)
 		return item
 	}
+	atomic.AddInt64(&c.misses, 1)
+	if bucket.remove(item) {
+		c.deletables <- item
+		c.expired(item)
+	}
 	return nil
 }
 
@@ -222,17 +314,26 @@ func (c *Cache) promote(item *entry) {
 func (c *Cache) worker() {
 	defer close(c.donec)
 
+	var sweep <-chan time.Time
+	if c.ExpireInterval > 0 {
+		ticker := time.NewTicker(c.ExpireInterval)
+		defer ticker.Stop()
+		sweep = ticker.C
+	}
+
 	for {
 		select {
 		case item, ok := <-c.promotables:
 			if ok == false {
 				goto drain
 			}
-			if c.doPromote(item) && c.size > c.MaxSize {
+			if c.doPromote(item) && c.overflow(c.size, c.items) {
 				c.gc()
 			}
 		case item := <-c.deletables:
 			c.doDelete(item)
+		case <-sweep:
+			c.sweep()
 		}
 	}
 
@@ -251,9 +352,11 @@ drain:
 func (c *Cache) doDelete(item *entry) {
 	if item.element == nil {
 		item.promotions = -2
-	} else {
-		c.size -= item.size
-		c.list.Remove(item.element)
+	} else if item.promotions != -2 {
+		atomic.AddInt64(&c.size, -item.size)
+		atomic.AddInt64(&c.items, -1)
+		c.policy.remove(item)
+		item.promotions = -2
 	}
 }
 
@@ -263,31 +366,75 @@ func (c *Cache) doPromote(item *entry) bool {
 		return false
 	}
 	if item.element != nil { //not a new item
-		if item.needPromote(c.GetsPerPromote) {
-			c.list.MoveToFront(item.element)
-			item.promotions = 0
+		c.policy.touch(item)
+		return false
+	}
+
+	if !c.policy.admit(item, c.overflow(c.size+item.size, c.items+1)) {
+		item.promotions = -2
+		if c.bucket(item.key).remove(item) {
+			atomic.AddInt64(&c.evictions, 1)
+			c.notify(c.listeners(true), item)
 		}
 		return false
 	}
 
-	c.size += item.size
-	item.element = c.list.PushFront(item)
+	atomic.AddInt64(&c.size, item.size)
+	atomic.AddInt64(&c.items, 1)
+	c.policy.add(item)
 	return true
 }
 
+// Check, that size or count of items exceeds limits.
+func (c *Cache) overflow(size, items int64) bool {
+	return c.MaxBytes != 0 && size > c.MaxBytes || c.MaxSize != 0 && items > c.MaxSize
+}
+
 func (c *Cache) gc() {
-	element := c.list.Back()
+	listeners := c.listeners(true)
 	for i := 0; uint32(i) < c.ItemsToPrune; i++ {
-		if element == nil {
+		item := c.policy.victim()
+		if item == nil {
 			return
 		}
-		prev := element.Prev()
-		item := element.Value.(*entry)
-		c.bucket(item.key).delete(item.key)
-		c.size -= item.size
-		c.list.Remove(element)
-		item.promotions = -2
-		element = prev
+		c.doDelete(item)
+		if c.bucket(item.key).remove(item) {
+			atomic.AddInt64(&c.evictions, 1)
+			c.notify(listeners, item)
+		}
+	}
+}
+
+// Remove all expired items
+func (c *Cache) sweep() {
+	now := time.Now().UnixNano()
+	for _, bucket := range c.buckets {
+		for _, item := range bucket.expired(now) {
+			if bucket.remove(item) {
+				c.doDelete(item)
+				c.expired(item)
+			}
+		}
+	}
+}
+
+func (c *Cache) expired(item *entry) {
+	atomic.AddInt64(&c.expirations, 1)
+	c.notify(c.listeners(false), item)
+}
+
+func (c *Cache) listeners(evict bool) []Listener {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	if evict {
+		return c.onEvict
+	}
+	return c.onExpire
+}
+
+func (c *Cache) notify(listeners []Listener, item *entry) {
+	for _, listener := range listeners {
+		listener(item.key, item.value)
 	}
 }
 
@@ -343,6 +490,10 @@ func (b *bucket) increase(key string) error {
 		entry.value = val + 1
 	case uint64:
 		entry.value = val + 1
+	case float32:
+		entry.value = val + 1
+	case float64:
+		entry.value = val + 1
 	default:
 		return fmt.Errorf("invalid type of cache value %q", key)
 	}
@@ -380,6 +531,10 @@ func (b *bucket) decrease(key string) error {
 		entry.value = val - 1
 	case uint64:
 		entry.value = val - 1
+	case float32:
+		entry.value = val - 1
+	case float64:
+		entry.value = val - 1
 	default:
 		return fmt.Errorf("invalid type of cache value %q", key)
 	}
@@ -395,16 +550,35 @@ func (b *bucket) delete(key string) *entry {
 	return item
 }
 
+// Remove item, if it is not replaced yet.
+func (b *bucket) remove(item *entry) bool {
+	b.Lock()
+	defer b.Unlock()
+	if existing, ok := b.lookup[item.key]; ok && existing == item {
+		delete(b.lookup, item.key)
+		return true
+	}
+	return false
+}
+
+func (b *bucket) expired(now int64) []*entry {
+	b.RLock()
+	defer b.RUnlock()
+	var res []*entry
+	for _, item := range b.lookup {
+		if atomic.LoadInt64(&item.expires) < now {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
 func (b *bucket) clear() {
 	b.Lock()
 	b.lookup = make(map[string]*entry)
 	b.Unlock()
 }
 
-type Sized interface {
-	Size() int64
-}
-
 type entry struct {
 	key        string
 	group      string
@@ -412,13 +586,14 @@ type entry struct {
 	expires    int64
 	size       int64
 	value      interface{}
-	element    *list.Element
+	element    *list.Element // Element of policy list
+	node       *list.Element // Frequency node (PolicyLFU)
 }
 
 func newItem(key string, value interface{}, expires int64) *entry {
-	size := int64(1)
-	if sized, ok := value.(Sized); ok {
-		size = sized.Size()
+	size := int64(len(key)) + sizeOf(value)
+	if size <= 0 {
+		size = 1
 	}
 
 	return &entry{
@@ -439,9 +614,3 @@ func (i *entry) expired() bool {
 	expires := atomic.LoadInt64(&i.expires)
 	return expires < time.Now().UnixNano()
 }
-
-func assign(dst, src interface{}) {
-	s := reflect.ValueOf(src)
-	d := reflect.ValueOf(dst)
-	d.Elem().Set(s)
-}
@@ -1,8 +1,10 @@
 package memory
 
 import (
+	"github.com/adverax/echo/cache"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -59,3 +61,222 @@ func TestCache(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int(1), vi)
 }
+
+func TestCache_Stats(t *testing.T) {
+	c := New(Options{
+		MaxBytes:     300,
+		ItemsToPrune: 1,
+	})
+
+	var evicted []string
+	c.OnEvict(func(key string, value interface{}) {
+		evicted = append(evicted, key)
+	})
+
+	for _, key := range []string{"k1", "k2", "k3", "k4"} {
+		require.NoError(t, c.Set(key, make([]byte, 60), time.Hour))
+	}
+
+	var val []byte
+	assert.NoError(t, c.Get("k4", &val))
+	assert.Error(t, c.Get("unknown", &val))
+
+	c.Stop()
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(3), stats.Items)
+	assert.Equal(t, int64(3*(2+60+24)), stats.Bytes)
+	assert.Equal(t, 0.5, stats.HitRate())
+	assert.Equal(t, []string{"k1"}, evicted)
+}
+
+func TestCache_MaxSize(t *testing.T) {
+	c := New(Options{
+		MaxSize:      2,
+		ItemsToPrune: 1,
+	})
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		require.NoError(t, c.Set(key, key, time.Hour))
+	}
+
+	c.Stop()
+
+	stats := c.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, int64(2), stats.Items)
+}
+
+func TestCache_Expire(t *testing.T) {
+	c := New(Options{})
+	defer c.Stop()
+
+	var expired []string
+	c.OnExpire(func(key string, value interface{}) {
+		expired = append(expired, key)
+	})
+
+	require.NoError(t, c.Set("city", "London", -time.Second))
+	var val string
+	assert.Error(t, c.Get("city", &val))
+	assert.Equal(t, []string{"city"}, expired)
+	assert.Equal(t, int64(1), c.Stats().Expired)
+}
+
+func TestPolicy(t *testing.T) {
+	type Test struct {
+		policy Policy
+		victim string
+	}
+
+	tests := map[string]Test{
+		"LRU": {
+			policy: PolicyLRU,
+			victim: "b",
+		},
+		"LFU": {
+			policy: PolicyLFU,
+			victim: "c",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := newPolicy(&Options{Policy: test.policy, GetsPerPromote: 1})
+			items := map[string]*entry{}
+			for _, key := range []string{"a", "b", "c"} {
+				items[key] = newItem(key, key, 0)
+				p.add(items[key])
+			}
+
+			// a: 3 hits, b: 2 hits, c: 1 hit (the last)
+			for _, key := range []string{"a", "b", "a", "b", "a", "c"} {
+				p.touch(items[key])
+			}
+
+			assert.Equal(t, test.victim, p.victim().key)
+			p.remove(p.victim())
+			assert.NotEqual(t, test.victim, p.victim().key)
+		})
+	}
+}
+
+func TestPolicy_TinyLFU(t *testing.T) {
+	p := newPolicy(&Options{Policy: PolicyTinyLFU, GetsPerPromote: 1, Counters: 64})
+	hot := newItem("hot", 1, 0)
+	require.True(t, p.admit(hot, false))
+	p.add(hot)
+	for i := 0; i < 5; i++ {
+		p.touch(hot)
+	}
+
+	// Cold item can not displace hot one
+	assert.False(t, p.admit(newItem("cold", 1, 0), true))
+	assert.True(t, p.admit(newItem("cold", 1, 0), false))
+}
+
+func TestSizeOf(t *testing.T) {
+	type Record struct {
+		Id   int64
+		Name string
+		Tags []string
+	}
+
+	assert.Equal(t, int64(8), sizeOf(10))
+	assert.Equal(t, int64(21), sizeOf("hello"))
+	assert.Equal(t, int64(24+8*3), sizeOf([]int64{1, 2, 3}))
+	assert.Equal(t, int64(8+(8+(16+3)+(24+16+1))), sizeOf(&Record{Id: 1, Name: "Bob", Tags: []string{"a"}}))
+}
+
+func TestCache_Codec(t *testing.T) {
+	type Record struct {
+		Id   int
+		Tags []string
+	}
+
+	c := New(Options{Codec: cache.GobCodec})
+	defer c.Stop()
+
+	src := &Record{Id: 1, Tags: []string{"a"}}
+	require.NoError(t, c.Set("record", src, time.Hour))
+	src.Tags[0] = "b"
+
+	var dst Record
+	require.NoError(t, c.Get("record", &dst))
+	assert.Equal(t, Record{Id: 1, Tags: []string{"a"}}, dst)
+
+	var id int
+	require.NoError(t, c.Set("id", "12", time.Hour))
+	require.NoError(t, c.Get("id", &id))
+	assert.Equal(t, 12, id)
+}
+
+type benchRecord struct {
+	Id    int64
+	Name  string
+	Email string
+	Tags  []string
+}
+
+func BenchmarkCache_Get(b *testing.B) {
+	rec := benchRecord{Id: 1, Name: "Bob", Email: "bob@example.com", Tags: []string{"a", "b"}}
+
+	b.Run("Reflect (legacy)", func(b *testing.B) {
+		c := New(Options{})
+		defer c.Stop()
+		_ = c.Set("key", rec, time.Hour)
+		var dst benchRecord
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			item := c.get("key")
+			reflect.ValueOf(&dst).Elem().Set(reflect.ValueOf(item.value))
+		}
+	})
+
+	benchmarks := map[string]struct {
+		codec cache.Codec
+		value interface{}
+		dst   func() interface{}
+	}{
+		"Assign": {
+			value: rec,
+			dst:   func() interface{} { return new(benchRecord) },
+		},
+		"Gob": {
+			codec: cache.GobCodec,
+			value: rec,
+			dst:   func() interface{} { return new(benchRecord) },
+		},
+		"JSON": {
+			codec: cache.JSONCodec,
+			value: rec,
+			dst:   func() interface{} { return new(benchRecord) },
+		},
+		"Bytes (zero copy)": {
+			codec: cache.JSONCodec,
+			value: make([]byte, 4096),
+			dst:   func() interface{} { return new([]byte) },
+		},
+		"String (zero copy)": {
+			codec: cache.JSONCodec,
+			value: "hello, world",
+			dst:   func() interface{} { return new(string) },
+		},
+	}
+
+	for name, bm := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			c := New(Options{Codec: bm.codec})
+			defer c.Stop()
+			_ = c.Set("key", bm.value, time.Hour)
+			dst := bm.dst()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = c.Get("key", dst)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"container/list"
+	"hash/fnv"
+)
+
+// Policy is eviction policy of cache.
+type Policy int
+
+const (
+	// Evict least recently used items.
+	PolicyLRU Policy = iota
+	// Evict least frequently used items.
+	PolicyLFU
+	// Evict least recently used items, but admit new item only if it is
+	// accessed more frequently than the victim (TinyLFU admission).
+	PolicyTinyLFU
+)
+
+// Implementation of eviction policy.
+// All methods are called from the worker goroutine only.
+type policy interface {
+	// Check new item before adding. Full is true, if cache has no room for item.
+	admit(item *entry, full bool) bool
+	// Register new item.
+	add(item *entry)
+	// Register access to the item.
+	touch(item *entry)
+	// Unregister item.
+	remove(item *entry)
+	// Get candidate for eviction.
+	victim() *entry
+	// Unregister all items.
+	clear()
+}
+
+func newPolicy(options *Options) policy {
+	switch options.Policy {
+	case PolicyLFU:
+		return newLfu()
+	case PolicyTinyLFU:
+		return &tinyLfu{
+			lru:    newLru(options.GetsPerPromote),
+			sketch: newSketch(options.Counters),
+		}
+	default:
+		return newLru(options.GetsPerPromote)
+	}
+}
+
+type lru struct {
+	list           *list.List
+	getsPerPromote int32
+}
+
+func newLru(getsPerPromote int32) *lru {
+	return &lru{
+		list:           list.New(),
+		getsPerPromote: getsPerPromote,
+	}
+}
+
+func (p *lru) admit(item *entry, full bool) bool {
+	return true
+}
+
+func (p *lru) add(item *entry) {
+	item.element = p.list.PushFront(item)
+}
+
+func (p *lru) touch(item *entry) {
+	if item.needPromote(p.getsPerPromote) {
+		p.list.MoveToFront(item.element)
+		item.promotions = 0
+	}
+}
+
+func (p *lru) remove(item *entry) {
+	p.list.Remove(item.element)
+}
+
+func (p *lru) victim() *entry {
+	element := p.list.Back()
+	if element == nil {
+		return nil
+	}
+	return element.Value.(*entry)
+}
+
+func (p *lru) clear() {
+	p.list = list.New()
+}
+
+// Group of items with the same access frequency
+type lfuNode struct {
+	count int
+	items *list.List
+}
+
+// Constant time LFU: list of frequency nodes in ascending order,
+// where each node keeps own items in LRU order.
+type lfu struct {
+	nodes *list.List
+}
+
+func newLfu() *lfu {
+	return &lfu{
+		nodes: list.New(),
+	}
+}
+
+func (p *lfu) admit(item *entry, full bool) bool {
+	return true
+}
+
+func (p *lfu) add(item *entry) {
+	front := p.nodes.Front()
+	if front == nil || front.Value.(*lfuNode).count != 1 {
+		front = p.nodes.PushFront(&lfuNode{count: 1, items: list.New()})
+	}
+	p.push(item, front)
+}
+
+func (p *lfu) touch(item *entry) {
+	current := item.node
+	node := current.Value.(*lfuNode)
+	next := current.Next()
+	if next == nil || next.Value.(*lfuNode).count != node.count+1 {
+		next = p.nodes.InsertAfter(&lfuNode{count: node.count + 1, items: list.New()}, current)
+	}
+	p.remove(item)
+	p.push(item, next)
+}
+
+func (p *lfu) remove(item *entry) {
+	node := item.node.Value.(*lfuNode)
+	node.items.Remove(item.element)
+	if node.items.Len() == 0 {
+		p.nodes.Remove(item.node)
+	}
+}
+
+func (p *lfu) victim() *entry {
+	front := p.nodes.Front()
+	if front == nil {
+		return nil
+	}
+	return front.Value.(*lfuNode).items.Back().Value.(*entry)
+}
+
+func (p *lfu) clear() {
+	p.nodes = list.New()
+}
+
+func (p *lfu) push(item *entry, node *list.Element) {
+	item.node = node
+	item.element = node.Value.(*lfuNode).items.PushFront(item)
+}
+
+// LRU with TinyLFU admission.
+type tinyLfu struct {
+	*lru
+	sketch *sketch
+}
+
+func (p *tinyLfu) admit(item *entry, full bool) bool {
+	p.sketch.increment(item.key)
+	if !full {
+		return true
+	}
+
+	victim := p.lru.victim()
+	if victim == nil {
+		return true
+	}
+
+	return p.sketch.estimate(item.key) > p.sketch.estimate(victim.key)
+}
+
+func (p *tinyLfu) touch(item *entry) {
+	p.sketch.increment(item.key)
+	p.lru.touch(item)
+}
+
+const sketchDepth = 4
+
+// Count-Min sketch with 4-bit counters and periodic aging.
+type sketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint32
+	additions uint32
+	limit     uint32 // Sample size, after that all counters are halved
+}
+
+func newSketch(width uint32) *sketch {
+	// Round up to the power of two
+	w := uint32(1)
+	for w < width {
+		w <<= 1
+	}
+
+	s := &sketch{
+		mask:  w - 1,
+		limit: 10 * w,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, w)
+	}
+	return s
+}
+
+func (s *sketch) increment(key string) {
+	h1, h2 := s.hash(key)
+	for i := range s.rows {
+		j := (h1 + uint32(i)*h2) & s.mask
+		if s.rows[i][j] < 15 {
+			s.rows[i][j]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.limit {
+		s.reset()
+	}
+}
+
+func (s *sketch) estimate(key string) uint8 {
+	h1, h2 := s.hash(key)
+	res := uint8(15)
+	for i := range s.rows {
+		j := (h1 + uint32(i)*h2) & s.mask
+		if v := s.rows[i][j]; v < res {
+			res = v
+		}
+	}
+	return res
+}
+
+// Aging of counters
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions = 0
+}
+
+func (s *sketch) hash(key string) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum>>32) | 1
+}
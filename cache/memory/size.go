@@ -0,0 +1,113 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"reflect"
+	"time"
+)
+
+// Sized is interface for values, that know own size.
+type Sized interface {
+	Size() int64
+}
+
+// Max depth of nested values for size estimation
+const maxSizeDepth = 8
+
+// Approximate size of value in bytes.
+func sizeOf(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case Sized:
+		return v.Size()
+	case string:
+		return int64(len(v)) + 16
+	case []byte:
+		return int64(len(v)) + 24
+	case bool, int8, uint8:
+		return 1
+	case int16, uint16:
+		return 2
+	case int32, uint32, float32:
+		return 4
+	case int, int64, uint, uint64, float64:
+		return 8
+	case time.Time:
+		return 24
+	}
+
+	return sizeOfValue(reflect.ValueOf(value), 0)
+}
+
+func sizeOfValue(v reflect.Value, depth int) int64 {
+	if depth > maxSizeDepth {
+		return int64(v.Type().Size())
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 8
+		}
+		return 8 + sizeOfValue(v.Elem(), depth+1)
+	case reflect.String:
+		return int64(v.Len()) + 16
+	case reflect.Slice:
+		return 24 + sizeOfElements(v, depth)
+	case reflect.Array:
+		return sizeOfElements(v, depth)
+	case reflect.Map:
+		size := int64(48)
+		iter := v.MapRange()
+		for iter.Next() {
+			size += sizeOfValue(iter.Key(), depth+1)
+			size += sizeOfValue(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += sizeOfValue(v.Field(i), depth+1)
+		}
+		return size
+	default:
+		return int64(v.Type().Size())
+	}
+}
+
+func sizeOfElements(v reflect.Value, depth int) int64 {
+	n := v.Len()
+	if n == 0 {
+		return 0
+	}
+
+	elem := v.Type().Elem()
+	switch elem.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.String,
+		reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		var size int64
+		for i := 0; i < n; i++ {
+			size += sizeOfValue(v.Index(i), depth+1)
+		}
+		return size
+	default:
+		return int64(n) * int64(elem.Size())
+	}
+}
@@ -0,0 +1,649 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MessagePack (https://msgpack.org) serialization without dependencies.
+// Structures are encoded as maps of exported fields. Name of field can be
+// redefined by tag `msgpack:"name"` (tag "-" excludes field).
+// Values time.Time are encoded by timestamp extension (-1).
+
+var (
+	errMsgpackShort     = errors.New("cache: msgpack: unexpected end of data")
+	errMsgpackTrailing  = errors.New("cache: msgpack: trailing data")
+	timeType            = reflect.TypeOf(time.Time{})
+	msgpackTimestampExt = byte(0xff) // Extension type -1
+)
+
+type msgpackCodec struct{}
+
+func (codec msgpackCodec) Marshal(val interface{}) ([]byte, error) {
+	var e msgpackEncoder
+	if err := e.encode(reflect.ValueOf(val)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (codec msgpackCodec) Unmarshal(data []byte, dst interface{}) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return ErrInvalidDestination
+	}
+
+	d := msgpackDecoder{data: data}
+	val, err := d.decode()
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errMsgpackTrailing
+	}
+	return msgpackAssign(dv.Elem(), val)
+}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = appendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = appendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			e.encodeBytes(data)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		e.encodeHeader(v.Len(), 0x80, 0xde, 0xdf)
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := e.encode(iter.Key()); err != nil {
+				return err
+			}
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := msgpackFields(v.Type())
+		e.encodeHeader(len(fields), 0x80, 0xde, 0xdf)
+		for _, f := range fields {
+			e.encodeString(f.name)
+			if err := e.encode(v.Field(f.index)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	default:
+		return fmt.Errorf("cache: msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeInt(n int64) {
+	switch {
+	case n >= 0:
+		e.encodeUint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = appendUint16(e.buf, uint16(n))
+	case n >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = appendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = appendUint64(e.buf, uint64(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = appendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = appendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = appendUint64(e.buf, n)
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) encodeBytes(data []byte) {
+	n := len(data)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xc5)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xc6)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, data...)
+}
+
+func (e *msgpackEncoder) encodeArray(v reflect.Value) error {
+	e.encodeHeader(v.Len(), 0x90, 0xdc, 0xdd)
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Header of array or map: fix form (up to 15 items), 16 or 32 bit length.
+func (e *msgpackEncoder) encodeHeader(n int, fix, code16, code32 byte) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, code16)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, code32)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+// Timestamp 96: nanoseconds (uint32) and seconds (int64).
+func (e *msgpackEncoder) encodeTime(t time.Time) {
+	e.buf = append(e.buf, 0xc7, 12, msgpackTimestampExt)
+	e.buf = appendUint32(e.buf, uint32(t.Nanosecond()))
+	e.buf = appendUint64(e.buf, uint64(t.Unix()))
+}
+
+func appendUint16(buf []byte, n uint16) []byte {
+	return append(buf, byte(n>>8), byte(n))
+}
+
+func appendUint32(buf []byte, n uint32) []byte {
+	return append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(n>>32)), uint32(n))
+}
+
+// Decoder produces generic values: nil, bool, int64, uint64, float64,
+// string, []byte, time.Time, []interface{} and map[interface{}]interface{}.
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errMsgpackShort
+	}
+	res := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return res, nil
+}
+
+// Read unsigned big endian number of n bytes.
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var res uint64
+	for _, c := range b {
+		res = res<<8 | uint64(c)
+	}
+	return res, nil
+}
+
+func (d *msgpackDecoder) decode() (interface{}, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	code := b[0]
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return d.decodeMap(int(code & 0x0f))
+	case code&0xf0 == 0x90:
+		return d.decodeArray(int(code & 0x0f))
+	case code&0xe0 == 0xa0:
+		return d.decodeString(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.read(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), data...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(int(n))
+	case 0xca:
+		n, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.readUint(1 << (code - 0xcc))
+	case 0xd0:
+		n, err := d.readUint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := d.readUint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := d.readUint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := d.readUint(8)
+		return int64(n), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (code - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+
+	return nil, fmt.Errorf("cache: msgpack: invalid code 0x%x", code)
+}
+
+func (d *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	res := make([]interface{}, n)
+	for i := range res {
+		val, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = val
+	}
+	return res, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	res := make(map[interface{}]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("cache: msgpack: invalid key of map %T", key)
+		}
+		val, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		res[key] = val
+	}
+	return res, nil
+}
+
+// Only timestamp extension is supported.
+func (d *msgpackDecoder) decodeExt(n int) (interface{}, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if b[0] != msgpackTimestampExt {
+		return nil, fmt.Errorf("cache: msgpack: unsupported extension %d", int8(b[0]))
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		n := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(n&0x3ffffffff), int64(n>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		sec := binary.BigEndian.Uint64(data[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	}
+	return nil, fmt.Errorf("cache: msgpack: invalid timestamp of %d bytes", n)
+}
+
+// Assign generic value into destination.
+func msgpackAssign(dst reflect.Value, src interface{}) error {
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return msgpackAssign(dst.Elem(), src)
+	case reflect.Interface:
+		if m, ok := src.(map[interface{}]interface{}); ok {
+			src = msgpackStringMap(m)
+		}
+		sv := reflect.ValueOf(src)
+		if !sv.Type().AssignableTo(dst.Type()) {
+			break
+		}
+		dst.Set(sv)
+		return nil
+	case reflect.Bool:
+		if b, ok := src.(bool); ok {
+			dst.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch n := src.(type) {
+		case int64:
+			if !dst.OverflowInt(n) {
+				dst.SetInt(n)
+				return nil
+			}
+		case uint64:
+			if n <= math.MaxInt64 && !dst.OverflowInt(int64(n)) {
+				dst.SetInt(int64(n))
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch n := src.(type) {
+		case int64:
+			if n >= 0 && !dst.OverflowUint(uint64(n)) {
+				dst.SetUint(uint64(n))
+				return nil
+			}
+		case uint64:
+			if !dst.OverflowUint(n) {
+				dst.SetUint(n)
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := src.(type) {
+		case float64:
+			dst.SetFloat(n)
+			return nil
+		case int64:
+			dst.SetFloat(float64(n))
+			return nil
+		case uint64:
+			dst.SetFloat(float64(n))
+			return nil
+		}
+	case reflect.String:
+		switch s := src.(type) {
+		case string:
+			dst.SetString(s)
+			return nil
+		case []byte:
+			dst.SetString(string(s))
+			return nil
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch s := src.(type) {
+			case []byte:
+				dst.SetBytes(s)
+				return nil
+			case string:
+				dst.SetBytes([]byte(s))
+				return nil
+			}
+		}
+		if list, ok := src.([]interface{}); ok {
+			res := reflect.MakeSlice(dst.Type(), len(list), len(list))
+			for i, item := range list {
+				if err := msgpackAssign(res.Index(i), item); err != nil {
+					return err
+				}
+			}
+			dst.Set(res)
+			return nil
+		}
+	case reflect.Array:
+		if data, ok := src.([]byte); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(dst, reflect.ValueOf(data))
+			return nil
+		}
+		if list, ok := src.([]interface{}); ok {
+			for i := 0; i < dst.Len() && i < len(list); i++ {
+				if err := msgpackAssign(dst.Index(i), list[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if m, ok := src.(map[interface{}]interface{}); ok {
+			res := reflect.MakeMapWithSize(dst.Type(), len(m))
+			for k, v := range m {
+				key := reflect.New(dst.Type().Key()).Elem()
+				if err := msgpackAssign(key, k); err != nil {
+					return err
+				}
+				val := reflect.New(dst.Type().Elem()).Elem()
+				if err := msgpackAssign(val, v); err != nil {
+					return err
+				}
+				res.SetMapIndex(key, val)
+			}
+			dst.Set(res)
+			return nil
+		}
+	case reflect.Struct:
+		if dst.Type() == timeType {
+			if t, ok := src.(time.Time); ok {
+				dst.Set(reflect.ValueOf(t))
+				return nil
+			}
+			break
+		}
+		if m, ok := src.(map[interface{}]interface{}); ok {
+			for _, f := range msgpackFields(dst.Type()) {
+				if v, ok := m[f.name]; ok {
+					if err := msgpackAssign(dst.Field(f.index), v); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cache: msgpack: can not assign %T to %s", src, dst.Type())
+}
+
+// Maps with string keys are decoded into interface{} as map[string]interface{}.
+func msgpackStringMap(m map[interface{}]interface{}) interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		s, ok := k.(string)
+		if !ok {
+			return m
+		}
+		if mm, ok := v.(map[interface{}]interface{}); ok {
+			v = msgpackStringMap(mm)
+		}
+		res[s] = v
+	}
+	return res
+}
+
+type msgpackField struct {
+	name  string
+	index int
+}
+
+// Exported fields of structure.
+func msgpackFields(t reflect.Type) []msgpackField {
+	var res []msgpackField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("msgpack"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tag = strings.Split(tag, ",")[0]; tag != "" {
+				name = tag
+			}
+		}
+		res = append(res, msgpackField{name: name, index: i})
+	}
+	return res
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgpackCodec_Format(t *testing.T) {
+	type tagged struct {
+		Name   string `msgpack:"n"`
+		Hidden int    `msgpack:"-"`
+	}
+
+	tests := map[string]struct {
+		src      interface{}
+		expected []byte
+	}{
+		"Nil":      {src: nil, expected: []byte{0xc0}},
+		"Bool":     {src: true, expected: []byte{0xc3}},
+		"FixInt":   {src: 5, expected: []byte{0x05}},
+		"NegInt":   {src: -3, expected: []byte{0xfd}},
+		"Int16":    {src: -200, expected: []byte{0xd1, 0xff, 0x38}},
+		"Uint16":   {src: 300, expected: []byte{0xcd, 0x01, 0x2c}},
+		"Float64":  {src: 1.5, expected: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		"FixStr":   {src: "ab", expected: []byte{0xa2, 'a', 'b'}},
+		"Str8":     {src: strings.Repeat("x", 40), expected: append([]byte{0xd9, 40}, strings.Repeat("x", 40)...)},
+		"Bin":      {src: [2]byte{1, 2}, expected: []byte{0xc4, 2, 1, 2}},
+		"FixArray": {src: []int{1, 2}, expected: []byte{0x92, 0x01, 0x02}},
+		"FixMap":   {src: map[string]int{"a": 1}, expected: []byte{0x81, 0xa1, 'a', 0x01}},
+		"Struct":   {src: tagged{Name: "b", Hidden: 1}, expected: []byte{0x81, 0xa1, 'n', 0xa1, 'b'}},
+		"Time": {
+			src:      time.Unix(1, 2),
+			expected: []byte{0xc7, 12, 0xff, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := MsgpackCodec.Marshal(test.src)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, data)
+		})
+	}
+}
+
+func TestMsgpackCodec_RoundTrip(t *testing.T) {
+	type item struct {
+		Id    int64
+		Tags  []string
+		Attrs map[string]interface{}
+	}
+
+	type document struct {
+		Title   string
+		Size    uint32
+		Delta   int8
+		Rate    float32
+		Data    []byte
+		Created time.Time
+		Owner   *item
+		Items   []item
+		Index   map[int]string
+		Any     interface{}
+	}
+
+	weight := 2.5
+	src := document{
+		Title:   "report",
+		Size:    70000,
+		Delta:   -100,
+		Rate:    0.25,
+		Data:    []byte{0, 1, 2},
+		Created: time.Date(2019, 5, 1, 10, 0, 0, 123, time.UTC),
+		Owner:   &item{Id: -1 << 40, Tags: []string{"admin"}},
+		Items: []item{
+			{Id: 1, Attrs: map[string]interface{}{"weight": weight, "ok": true}},
+			{Id: 2},
+		},
+		Index: map[int]string{-5: "minus", 1000: "plus"},
+		Any:   "text",
+	}
+
+	data, err := MsgpackCodec.Marshal(src)
+	require.NoError(t, err)
+
+	var dst document
+	require.NoError(t, MsgpackCodec.Unmarshal(data, &dst))
+	assert.True(t, src.Created.Equal(dst.Created))
+	dst.Created = src.Created
+	assert.Equal(t, src, dst)
+
+	// Errors
+	var n int8
+	data, err = MsgpackCodec.Marshal(1000)
+	require.NoError(t, err)
+	assert.Error(t, MsgpackCodec.Unmarshal(data, &n), "overflow")
+	assert.Error(t, MsgpackCodec.Unmarshal(data[:1], &n), "short")
+	assert.Error(t, MsgpackCodec.Unmarshal(append(data, 0), new(int)), "trailing")
+	assert.Equal(t, ErrInvalidDestination, MsgpackCodec.Unmarshal(data, n))
+	_, err = MsgpackCodec.Marshal(make(chan int))
+	assert.Error(t, err)
+}
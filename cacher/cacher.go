@@ -27,8 +27,17 @@ type Storage interface {
 
 	// Assert depended key
 	Assert(key string, dependencies map[string]string) error
+	// Assert tags of key
+	AssertTags(key string, tags []string) error
 	// Invalidate depended data
 	Invalidate(key, val string) error
+	// Invalidate all data, that marked with any of tags
+	InvalidateTags(tags ...string) error
+	// Invalidate all data, that depends on keys with prefix
+	InvalidatePrefix(prefix string) error
+	// Invalidate all data, that depends on matched pairs.
+	// Key and val are patterns (see path.Match).
+	InvalidateMatch(key, val string) error
 }
 
 type Template interface {
@@ -44,6 +53,15 @@ type Cacher interface {
 		lifeTime time.Duration,
 	) error
 
+	FetchTaggedData(
+		class string,
+		dependencies map[string]string,
+		tags []string,
+		dst interface{},
+		builder func() (interface{}, error),
+		lifeTime time.Duration,
+	) error
+
 	FetchHtml(
 		class string,
 		dependencies map[string]string,
@@ -51,8 +69,23 @@ type Cacher interface {
 		lifeTime time.Duration,
 	) (html string, err error)
 
+	FetchTaggedHtml(
+		class string,
+		dependencies map[string]string,
+		tags []string,
+		builder func() (tpl Template, data interface{}, err error),
+		lifeTime time.Duration,
+	) (html string, err error)
+
 	// Invalidate depended data
 	Invalidate(key, val string) error
+	// Invalidate all data, that marked with any of tags
+	InvalidateTags(tags ...string) error
+	// Invalidate all data, that depends on keys with prefix
+	InvalidatePrefix(prefix string) error
+	// Invalidate all data, that depends on matched pairs.
+	// Key and val are patterns (see path.Match).
+	InvalidateMatch(key, val string) error
 }
 
 type cacher struct {
@@ -65,6 +98,17 @@ func (c *cacher) FetchData(
 	dst interface{},
 	builder func() (interface{}, error),
 	lifeTime time.Duration,
+) error {
+	return c.FetchTaggedData(class, dependencies, nil, dst, builder, lifeTime)
+}
+
+func (c *cacher) FetchTaggedData(
+	class string,
+	dependencies map[string]string,
+	tags []string,
+	dst interface{},
+	builder func() (interface{}, error),
+	lifeTime time.Duration,
 ) error {
 	key := c.makeKey(class, dependencies)
 
@@ -88,7 +132,16 @@ func (c *cacher) FetchData(
 		return err
 	}
 
-	return c.Assert(key, dependencies)
+	err = c.Assert(key, dependencies)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return c.AssertTags(key, tags)
 }
 
 func (c *cacher) FetchHtml(
@@ -97,9 +150,20 @@ func (c *cacher) FetchHtml(
 	builder func() (tpl Template, data interface{}, err error),
 	lifeTime time.Duration,
 ) (html string, err error) {
-	err = c.FetchData(
+	return c.FetchTaggedHtml(class, dependencies, nil, builder, lifeTime)
+}
+
+func (c *cacher) FetchTaggedHtml(
+	class string,
+	dependencies map[string]string,
+	tags []string,
+	builder func() (tpl Template, data interface{}, err error),
+	lifeTime time.Duration,
+) (html string, err error) {
+	err = c.FetchTaggedData(
 		class,
 		dependencies,
+		tags,
 		&html,
 		func() (interface{}, error) {
 			tpl, params, err := builder()
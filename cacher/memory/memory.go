@@ -1,10 +1,10 @@
 package memory
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"github.com/adverax/echo/cache"
 	"github.com/adverax/echo/sync/arbiter"
+	"path"
+	"strings"
 	"sync"
 	"time"
 )
@@ -24,15 +24,36 @@ type Manager interface {
 
 	// Assert depended key
 	Assert(key string, dependencies map[string]string) error
+	// Assert tags of key
+	AssertTags(key string, tags []string) error
 	// Invalidate dependencies
 	Invalidate(key, val string) error
+	// Invalidate all data, that marked with any of tags
+	InvalidateTags(tags ...string) error
+	// Invalidate all data, that depends on keys with prefix
+	InvalidatePrefix(prefix string) error
+	// Invalidate all data, that depends on matched pairs.
+	// Key and val are patterns (see path.Match).
+	InvalidateMatch(key, val string) error
+}
+
+// Set of cached keys
+type keys map[string]struct{}
+
+// Reference from cached key to the index entry
+type link struct {
+	tag bool
+	key string // Dependency key (or tag)
+	val string // Dependency value
 }
 
 type engine struct {
 	mx sync.Mutex
 	arbiter.Arbiter
 	cache.Cache
-	deps map[string][]string
+	deps  map[string]map[string]keys // dependency key -> dependency value -> cached keys
+	tags  map[string]keys            // tag -> cached keys
+	links map[string][]link          // cached key -> index entries
 }
 
 func (engine *engine) Assert(
@@ -43,11 +64,29 @@ func (engine *engine) Assert(
 	defer engine.mx.Unlock()
 
 	for k, v := range dependencies {
-		s := makeKey(k, v)
-		if deps, ok := engine.deps[s]; ok {
-			engine.deps[s] = append(deps, key)
-		} else {
-			engine.deps[s] = []string{key}
+		values, ok := engine.deps[k]
+		if !ok {
+			values = make(map[string]keys, 16)
+			engine.deps[k] = values
+		}
+		if assertKey(values, v, key) {
+			engine.links[key] = append(engine.links[key], link{key: k, val: v})
+		}
+	}
+
+	return nil
+}
+
+func (engine *engine) AssertTags(
+	key string,
+	tags []string,
+) error {
+	engine.mx.Lock()
+	defer engine.mx.Unlock()
+
+	for _, tag := range tags {
+		if assertKey(engine.tags, tag, key) {
+			engine.links[key] = append(engine.links[key], link{tag: true, key: tag})
 		}
 	}
 
@@ -58,34 +97,171 @@ func (engine *engine) Invalidate(
 	key, val string,
 ) error {
 	engine.mx.Lock()
-	defer engine.mx.Unlock()
+	var victims []string
+	if values, ok := engine.deps[key]; ok {
+		victims = engine.extract(values[val], victims)
+	}
+	engine.mx.Unlock()
+
+	return engine.delete(victims)
+}
+
+func (engine *engine) InvalidateTags(
+	tags ...string,
+) error {
+	engine.mx.Lock()
+	var victims []string
+	for _, tag := range tags {
+		victims = engine.extract(engine.tags[tag], victims)
+	}
+	engine.mx.Unlock()
+
+	return engine.delete(victims)
+}
+
+func (engine *engine) InvalidatePrefix(
+	prefix string,
+) error {
+	engine.mx.Lock()
+	var victims []string
+	for key, values := range engine.deps {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		for _, ks := range values {
+			victims = engine.extract(ks, victims)
+		}
+	}
+	engine.mx.Unlock()
+
+	return engine.delete(victims)
+}
+
+func (engine *engine) InvalidateMatch(
+	key, val string,
+) error {
+	engine.mx.Lock()
+	var victims []string
+	for k, values := range engine.deps {
+		matched, err := path.Match(key, k)
+		if err != nil {
+			engine.mx.Unlock()
+			return err
+		}
+		if !matched {
+			continue
+		}
 
-	id := makeKey(key, val)
-	if deps, ok := engine.deps[id]; ok {
-		for _, key := range deps {
-			err := engine.Cache.Delete(key)
+		for v, ks := range values {
+			matched, err := path.Match(val, v)
 			if err != nil {
+				engine.mx.Unlock()
 				return err
 			}
+			if matched {
+				victims = engine.extract(ks, victims)
+			}
+		}
+	}
+	engine.mx.Unlock()
+
+	return engine.delete(victims)
+}
+
+// Forget about key, that was removed by cache itself.
+func (engine *engine) forget(key string, value interface{}) {
+	engine.mx.Lock()
+	defer engine.mx.Unlock()
+
+	engine.unlink(key)
+}
+
+// Collect cached keys and remove them from index.
+func (engine *engine) extract(ks keys, victims []string) []string {
+	var list []string
+	for key := range ks {
+		list = append(list, key)
+	}
+
+	for _, key := range list {
+		engine.unlink(key)
+	}
+
+	return append(victims, list...)
+}
+
+// Remove cached key from all index entries.
+func (engine *engine) unlink(key string) {
+	for _, l := range engine.links[key] {
+		if l.tag {
+			removeKey(engine.tags, l.key, key)
+			continue
+		}
+
+		if values, ok := engine.deps[l.key]; ok {
+			removeKey(values, l.val, key)
+			if len(values) == 0 {
+				delete(engine.deps, l.key)
+			}
+		}
+	}
+
+	delete(engine.links, key)
+}
+
+// Delete cached keys.
+// Called without lock, because cache can notify about removed items.
+func (engine *engine) delete(victims []string) error {
+	for _, key := range victims {
+		err := engine.Cache.Delete(key)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func makeKey(key, val string) string {
-	s := key + "=" + val
-	hash := md5.Sum([]byte(s))
-	return hex.EncodeToString(hash[:])
+// Add key into index. Returns true, if key is new for the entry.
+func assertKey(index map[string]keys, id string, key string) bool {
+	ks, ok := index[id]
+	if !ok {
+		ks = make(keys, 4)
+		index[id] = ks
+	}
+	if _, has := ks[key]; has {
+		return false
+	}
+	ks[key] = struct{}{}
+	return true
+}
+
+func removeKey(index map[string]keys, id string, key string) {
+	if ks, ok := index[id]; ok {
+		delete(ks, key)
+		if len(ks) == 0 {
+			delete(index, id)
+		}
+	}
 }
 
 func New(
 	arbiter arbiter.Arbiter,
-	cache cache.Cache,
+	c cache.Cache,
 ) Manager {
-	return &engine{
+	engine := &engine{
 		Arbiter: arbiter,
-		Cache:   cache,
-		deps:    make(map[string][]string, 4096),
+		Cache:   c,
+		deps:    make(map[string]map[string]keys, 256),
+		tags:    make(map[string]keys, 256),
+		links:   make(map[string][]link, 1024),
+	}
+
+	if observer, ok := c.(cache.Observer); ok {
+		observer.OnEvict(engine.forget)
+		observer.OnExpire(engine.forget)
 	}
+
+	return engine
 }
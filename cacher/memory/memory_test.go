@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"github.com/adverax/echo/cache/memory"
+	"github.com/adverax/echo/data"
+	"github.com/adverax/echo/sync/arbiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEngine_Invalidate(t *testing.T) {
+	type Test struct {
+		invalidate func(m Manager) error
+		expected   map[string]bool // key -> exists
+	}
+
+	tests := map[string]Test{
+		"Pair": {
+			invalidate: func(m Manager) error {
+				return m.Invalidate("tenant", "1")
+			},
+			expected: map[string]bool{"a": false, "b": true, "c": true},
+		},
+		"Tags": {
+			invalidate: func(m Manager) error {
+				return m.InvalidateTags("news", "unknown")
+			},
+			expected: map[string]bool{"a": true, "b": false, "c": false},
+		},
+		"Prefix": {
+			invalidate: func(m Manager) error {
+				return m.InvalidatePrefix("user")
+			},
+			expected: map[string]bool{"a": true, "b": false, "c": false},
+		},
+		"Match": {
+			invalidate: func(m Manager) error {
+				return m.InvalidateMatch("user.*", "2")
+			},
+			expected: map[string]bool{"a": true, "b": true, "c": false},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := memory.New(memory.Options{})
+			defer c.Stop()
+			m := New(arbiter.NewLocal(), c)
+
+			for key, deps := range map[string]map[string]string{
+				"a": {"tenant": "1"},
+				"b": {"tenant": "2", "user.id": "1"},
+				"c": {"tenant": "2", "user.role": "2"},
+			} {
+				require.NoError(t, m.Set(key, key, time.Hour))
+				require.NoError(t, m.Assert(key, deps))
+			}
+			require.NoError(t, m.AssertTags("b", []string{"news"}))
+			require.NoError(t, m.AssertTags("c", []string{"news", "users"}))
+
+			require.NoError(t, test.invalidate(m))
+
+			for key, exists := range test.expected {
+				var val string
+				err := m.Get(key, &val)
+				if exists {
+					assert.NoError(t, err, key)
+				} else {
+					assert.Equal(t, data.ErrNoMatch, err, key)
+				}
+			}
+		})
+	}
+}
@@ -165,6 +165,9 @@ type Context interface {
 	// Stream sends a streaming response with status code and content type.
 	Stream(code int, contentType string, r io.Reader) error
 
+	// SSE starts response as stream of server-sent events.
+	SSE() (*SSE, error)
+
 	// Template sends a HTML response with status code,
 	Template(code int, t Template, data interface{}) (err error)
 
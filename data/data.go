@@ -32,6 +32,26 @@ type Sorting bool
 
 type Sort map[string]Sorting
 
+// FilterKind is kind of filter of column.
+type FilterKind int
+
+const (
+	FilterText      FilterKind = iota // Column contains text
+	FilterSelect                      // Column equals to key of item
+	FilterDateRange                   // Column is within range of dates
+)
+
+// Criterion is condition of filter for single column.
+type Criterion struct {
+	Kind  FilterKind
+	Value string    // Text or key of item
+	From  time.Time // Lower bound of range (inclusive, optional)
+	To    time.Time // Upper bound of range (exclusive, optional)
+}
+
+// Filter is set of criteria by keys of columns.
+type Filter map[string]Criterion
+
 type Pagination struct {
 	Offset int64 `json:"offset"`
 	Limit  int64 `json:"limit"`
@@ -49,14 +69,36 @@ type Provider interface {
 	Next(ctx context.Context) error
 }
 
+// Sorter is optional interface of Provider, that applies requested sorting.
+type Sorter interface {
+	SetSort(sort Sort)
+}
+
+// Filterer is optional interface of Provider, that applies requested filter.
+type Filterer interface {
+	SetFilter(filter Filter)
+}
+
 // ArrayProvider is base for CustomArrayProvider
 type ArrayProvider struct {
-	Quantity int // Items count
-	Index    int // Current index (starts from 1)
+	Quantity int    // Items count
+	Index    int    // Current index (starts from 1)
+	Sort     Sort   // Requested sorting (must be applied by Loader)
+	Filter   Filter // Requested filter (must be applied by Loader)
 	Loader   func(ctx context.Context) (int, error)
 	loaded   bool
 }
 
+func (provider *ArrayProvider) SetSort(sort Sort) {
+	provider.Sort = sort
+	provider.loaded = false
+}
+
+func (provider *ArrayProvider) SetFilter(filter Filter) {
+	provider.Filter = filter
+	provider.loaded = false
+}
+
 func (provider *ArrayProvider) Count(ctx context.Context) (int, error) {
 	err := provider.Import(ctx, nil)
 	if err != nil {
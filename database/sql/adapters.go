@@ -20,8 +20,16 @@ package sql
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/adverax/echo/sync/arbiter"
 )
 
 type mySqlAdater struct{}
@@ -34,6 +42,16 @@ func (adapter *mySqlAdater) IsDeadlock(db DB, err error) bool {
 	return mysqlGetErrorCode(err) == "1213"
 }
 
+// Server has gone away (2006), lost connection (2013), server shutdown (1053)
+// and invalid connection of driver.
+func (adapter *mySqlAdater) IsConnectionLost(err error) bool {
+	switch mysqlGetErrorCode(err) {
+	case "2006", "2013", "1053":
+		return true
+	}
+	return err.Error() == "invalid connection"
+}
+
 func (adapter *mySqlAdater) MakeConnectionString(dsn *DSN) string {
 	host := dsn.Host
 	if host == "" {
@@ -140,8 +158,378 @@ func (adapter *mySqlAdater) UnlockGlobal(ctx context.Context, tx Tx, latch strin
 	return nil
 }
 
+func (adapter *mySqlAdater) Placeholder(n int) string {
+	return "?"
+}
+
+func (adapter *mySqlAdater) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '`')
+}
+
+func (adapter *mySqlAdater) ExplainQuery(query string) string {
+	return "EXPLAIN " + query
+}
+
+type postgresAdapter struct {
+	driver string
+	copy   bool // Driver supports COPY FROM STDIN by prepared statement (lib/pq)
+}
+
+func (adapter *postgresAdapter) Driver() string {
+	return adapter.driver
+}
+
+// Deadlock (40P01) and serialization failure (40001) are both
+// resolved by retrying of transaction.
+func (adapter *postgresAdapter) IsDeadlock(db DB, err error) bool {
+	code := postgresGetErrorCode(err)
+	return code == "40P01" || code == "40001"
+}
+
+// Connection exceptions (class 08) and shutdown of server (57P01-57P03).
+func (adapter *postgresAdapter) IsConnectionLost(err error) bool {
+	code := postgresGetErrorCode(err)
+	return strings.HasPrefix(code, "08") || code == "57P01" || code == "57P02" || code == "57P03"
+}
+
+func (adapter *postgresAdapter) MakeConnectionString(dsn *DSN) string {
+	host := dsn.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	port := dsn.Port
+	if dsn.Port == 0 {
+		port = 5432
+	}
+
+	list := []string{
+		"host=" + postgresQuote(host),
+		"port=" + strconv.Itoa(int(port)),
+	}
+	if dsn.Database != "" {
+		list = append(list, "dbname="+postgresQuote(dsn.Database))
+	}
+	if dsn.Username != "" {
+		list = append(list, "user="+postgresQuote(dsn.Username))
+	}
+	if dsn.Password != "" {
+		list = append(list, "password="+postgresQuote(dsn.Password))
+	}
+	for _, key := range sortedKeys(dsn.Params) {
+		list = append(list, key+"="+postgresQuote(dsn.Params[key]))
+	}
+
+	return strings.Join(list, " ")
+}
+
+func (adapter *postgresAdapter) DatabaseName(
+	db DB,
+) (name string, err error) {
+	const query = "SELECT current_database()"
+	err = db.QueryRow(query).Scan(&name)
+	return
+}
+
+// Lock database latch with context.
+// Latch is scoped by current database.
+func (adapter *postgresAdapter) LockLocal(ctx context.Context, tx Tx, latch string, timeout int) error {
+	return adapter.lock(ctx, tx, "hashtext(current_database()), hashtext($1)", latch, timeout)
+}
+
+// Unlock database latch with context
+func (adapter *postgresAdapter) UnlockLocal(ctx context.Context, tx Tx, latch string) error {
+	return adapter.unlock(ctx, tx, "hashtext(current_database()), hashtext($1)", latch)
+}
+
+// Lock database latch with context.
+// PostgreSQL has no advisory locks across databases, so latch is
+// scoped by current database too, but it is separated from local latches.
+func (adapter *postgresAdapter) LockGlobal(ctx context.Context, tx Tx, latch string, timeout int) error {
+	return adapter.lock(ctx, tx, "hashtext($1)::bigint", latch, timeout)
+}
+
+// Unlock database latch with context
+func (adapter *postgresAdapter) UnlockGlobal(ctx context.Context, tx Tx, latch string) error {
+	return adapter.unlock(ctx, tx, "hashtext($1)::bigint", latch)
+}
+
+func (adapter *postgresAdapter) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (adapter *postgresAdapter) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '"')
+}
+
+func (adapter *postgresAdapter) ExplainQuery(query string) string {
+	return "EXPLAIN " + query
+}
+
+// Negative timeout means infinite waiting.
+// Session level lock is used, so it survives commit of transaction,
+// that is used only as holder of connection.
+func (adapter *postgresAdapter) lock(
+	ctx context.Context,
+	tx Tx,
+	key string,
+	latch string,
+	timeout int, // seconds
+) error {
+	if timeout == 0 {
+		var res bool
+		err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_lock("+key+")", latch).Scan(&res)
+		if err != nil {
+			return err
+		}
+		if !res {
+			return ErrCaptureLock
+		}
+		return nil
+	}
+
+	if timeout > 0 {
+		const query = "SELECT set_config('lock_timeout', $1, true)"
+		var res string
+		err := tx.QueryRowContext(ctx, query, strconv.Itoa(timeout*1000)).Scan(&res)
+		if err != nil {
+			return err
+		}
+	}
+
+	var res string
+	err := tx.QueryRowContext(ctx, "SELECT pg_advisory_lock("+key+")::text", latch).Scan(&res)
+	if err != nil {
+		if postgresGetErrorCode(err) == "55P03" {
+			return ErrCaptureLock
+		}
+		return err
+	}
+	return nil
+}
+
+func (adapter *postgresAdapter) unlock(
+	ctx context.Context,
+	tx Tx,
+	key string,
+	latch string,
+) error {
+	var res NullBool
+	err := tx.QueryRowContext(ctx, "SELECT pg_advisory_unlock("+key+")", latch).Scan(&res)
+	if err != nil {
+		return err
+	}
+	if !res.Valid {
+		return ErrReleaseInvalid
+	}
+	if !res.Bool {
+		return ErrReleaseLock
+	}
+	return nil
+}
+
+type sqliteAdapter struct {
+	driver string
+	locks  *processLocks
+}
+
+func (adapter *sqliteAdapter) Driver() string {
+	return adapter.driver
+}
+
+// Busy (5) and locked (6) errors are resolved by retrying of transaction.
+func (adapter *sqliteAdapter) IsDeadlock(db DB, err error) bool {
+	code := sqliteGetErrorCode(err)
+	return code == 5 || code == 6
+}
+
+// DSN.Database is path of database file (in-memory database, if it is empty).
+func (adapter *sqliteAdapter) MakeConnectionString(dsn *DSN) string {
+	name := dsn.Database
+	if name == "" {
+		name = ":memory:"
+	}
+	if len(dsn.Params) == 0 {
+		return name
+	}
+
+	params := make(url.Values, len(dsn.Params))
+	for key, val := range dsn.Params {
+		params.Set(key, val)
+	}
+	return "file:" + name + "?" + params.Encode()
+}
+
+func (adapter *sqliteAdapter) DatabaseName(
+	db DB,
+) (name string, err error) {
+	const query = "SELECT file FROM pragma_database_list WHERE name = 'main'"
+	err = db.QueryRow(query).Scan(&name)
+	return
+}
+
+// SQLite has no named locks, so they are emulated within current process.
+func (adapter *sqliteAdapter) LockLocal(ctx context.Context, tx Tx, latch string, timeout int) error {
+	return adapter.locks.lock(ctx, "local."+latch, timeout)
+}
+
+func (adapter *sqliteAdapter) UnlockLocal(ctx context.Context, tx Tx, latch string) error {
+	return adapter.locks.unlock("local." + latch)
+}
+
+func (adapter *sqliteAdapter) LockGlobal(ctx context.Context, tx Tx, latch string, timeout int) error {
+	return adapter.locks.lock(ctx, "global."+latch, timeout)
+}
+
+func (adapter *sqliteAdapter) UnlockGlobal(ctx context.Context, tx Tx, latch string) error {
+	return adapter.locks.unlock("global." + latch)
+}
+
+func (adapter *sqliteAdapter) Placeholder(n int) string {
+	return "?"
+}
+
+func (adapter *sqliteAdapter) QuoteIdentifier(name string) string {
+	return quoteIdentifier(name, '"')
+}
+
+func (adapter *sqliteAdapter) ExplainQuery(query string) string {
+	return "EXPLAIN QUERY PLAN " + query
+}
+
+// Named locks of current process
+type processLocks struct {
+	sync.Mutex
+	arbiter arbiter.Arbiter
+	held    map[string]bool
+}
+
+// Negative timeout means infinite waiting.
+func (locks *processLocks) lock(ctx context.Context, latch string, timeout int) error {
+	if timeout == 0 {
+		if !locks.arbiter.TryLock(latch) {
+			return ErrCaptureLock
+		}
+	} else {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+			defer cancel()
+		}
+		err := locks.arbiter.LockContext(ctx, latch)
+		if err == context.DeadlineExceeded {
+			return ErrCaptureLock
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	locks.Lock()
+	defer locks.Unlock()
+	locks.held[latch] = true
+	return nil
+}
+
+func (locks *processLocks) unlock(latch string) error {
+	locks.Lock()
+	defer locks.Unlock()
+	if !locks.held[latch] {
+		return ErrReleaseLock
+	}
+	delete(locks.held, latch)
+	locks.arbiter.Unlock(latch)
+	return nil
+}
+
 func init() {
 	Register("mysql", &mySqlAdater{})
+	Register("postgres", &postgresAdapter{driver: "postgres", copy: true})
+	Register("pgx", &postgresAdapter{driver: "pgx"})
+	locks := &processLocks{
+		arbiter: arbiter.NewLocal(),
+		held:    make(map[string]bool, 16),
+	}
+	Register("sqlite3", &sqliteAdapter{driver: "sqlite3", locks: locks})
+	Register("sqlite", &sqliteAdapter{driver: "sqlite", locks: locks})
+}
+
+// Rebind replaces placeholders "?" of query by placeholders of adapter.
+// Question marks inside of quoted strings and identifiers, comments
+// and dollar-quoted bodies are kept. Escape "??" is replaced by literal "?"
+// (for example JSONB operator of PostgreSQL). Operators "?|" and "?&"
+// are kept as is.
+func Rebind(adapter Adapter, query string) string {
+	var buf strings.Builder
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		end := i + 1 // End of copied fragment
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end = skipQuoted(query, i+1, string(c))
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end = skipQuoted(query, i+2, "\n")
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end = skipQuoted(query, i+2, "*/")
+		case c == '$':
+			if tag := dollarTag(query[i:]); tag != "" {
+				end = skipQuoted(query, i+len(tag), tag)
+			}
+		case c == '?':
+			next := byte(0)
+			if i+1 < len(query) {
+				next = query[i+1]
+			}
+			switch {
+			case next == '?':
+				buf.WriteByte('?')
+				i++
+			case next == '|' && !strings.HasPrefix(query[i+1:], "||"),
+				next == '&' && !strings.HasPrefix(query[i+1:], "&&"):
+				buf.WriteByte('?')
+			default:
+				n++
+				buf.WriteString(adapter.Placeholder(n))
+			}
+			continue
+		}
+		buf.WriteString(query[i:end])
+		i = end - 1
+	}
+	return buf.String()
+}
+
+// Get position after terminator, that is searched from position i.
+func skipQuoted(query string, i int, terminator string) int {
+	pos := strings.Index(query[i:], terminator)
+	if pos < 0 {
+		return len(query)
+	}
+	return i + pos + len(terminator)
+}
+
+// Get opening tag of dollar-quoted string ("$$" or "$tag$").
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+// Quote each part of qualified name.
+func quoteIdentifier(name string, quote byte) string {
+	q := string(quote)
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = q + strings.Replace(part, q, q+q, -1) + q
+	}
+	return strings.Join(parts, ".")
 }
 
 func mysqlGetErrorCode(err error) string {
@@ -158,3 +546,76 @@ func mysqlGetErrorCode(err error) string {
 }
 
 var mysqlErrRe = regexp.MustCompile(`Error (\d+):`)
+
+// Extract SQLSTATE from errors of lib/pq (field Code) and pgx (method SQLState).
+func postgresGetErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if e, ok := err.(interface{ SQLState() string }); ok {
+		return e.SQLState()
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() == reflect.Struct {
+		if code := v.FieldByName("Code"); code.IsValid() && code.Kind() == reflect.String {
+			return code.String()
+		}
+	}
+
+	matches := postgresErrRe.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return ""
+	}
+
+	return matches[1]
+}
+
+var postgresErrRe = regexp.MustCompile(`SQLSTATE ([0-9A-Z]{5})`)
+
+// Extract primary result code from errors of sqlite drivers
+// (field Code of mattn/go-sqlite3 or method Code of modernc.org/sqlite).
+func sqliteGetErrorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if e, ok := err.(interface{ Code() int }); ok {
+		return e.Code() & 0xff
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(err))
+	if v.Kind() == reflect.Struct {
+		if code := v.FieldByName("Code"); code.IsValid() && code.Kind() == reflect.Int {
+			return int(code.Int()) & 0xff
+		}
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "database is locked"):
+		return 5
+	case strings.Contains(msg, "database table is locked"):
+		return 6
+	}
+
+	return 0
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Quote value of libpq connection string.
+func postgresQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
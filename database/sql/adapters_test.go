@@ -0,0 +1,217 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdapter_MakeConnectionString(t *testing.T) {
+	type Test struct {
+		driver string
+		dsn    DSN
+		expect string
+	}
+
+	tests := map[string]Test{
+		"Postgres": {
+			driver: "postgres",
+			dsn: DSN{
+				Database: "shop",
+				Username: "admin",
+				Password: "it's secret",
+				Params:   map[string]string{"sslmode": "disable", "application_name": "echo"},
+			},
+			expect: `host=127.0.0.1 port=5432 dbname=shop user=admin password='it\'s secret' application_name=echo sslmode=disable`,
+		},
+		"SQLite memory": {
+			driver: "sqlite3",
+			dsn:    DSN{},
+			expect: ":memory:",
+		},
+		"SQLite file": {
+			driver: "sqlite3",
+			dsn: DSN{
+				Database: "/var/db/shop.db",
+				Params:   map[string]string{"_busy_timeout": "5000", "cache": "shared"},
+			},
+			expect: "file:/var/db/shop.db?_busy_timeout=5000&cache=shared",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			adapter, err := adapters.find(test.driver)
+			require.NoError(t, err)
+			assert.Equal(t, test.expect, adapter.MakeConnectionString(&test.dsn))
+		})
+	}
+}
+
+type pqError struct {
+	Code    string
+	Message string
+}
+
+func (e *pqError) Error() string {
+	return "pq: " + e.Message
+}
+
+type pgxError struct{}
+
+func (e *pgxError) Error() string {
+	return "serialization failure"
+}
+
+func (e *pgxError) SQLState() string {
+	return "40001"
+}
+
+type sqliteError struct {
+	Code int
+}
+
+func (e sqliteError) Error() string {
+	return "sqlite error"
+}
+
+func TestAdapter_IsDeadlock(t *testing.T) {
+	type Test struct {
+		driver   string
+		err      error
+		deadlock bool
+	}
+
+	tests := map[string]Test{
+		"MySQL":                 {driver: "mysql", err: errors.New("Error 1213: Deadlock found"), deadlock: true},
+		"MySQL other":           {driver: "mysql", err: errors.New("Error 1062: Duplicate entry"), deadlock: false},
+		"Postgres field":        {driver: "postgres", err: &pqError{Code: "40P01", Message: "deadlock detected"}, deadlock: true},
+		"Postgres other":        {driver: "postgres", err: &pqError{Code: "23505", Message: "duplicate key"}, deadlock: false},
+		"Postgres method":       {driver: "pgx", err: &pgxError{}, deadlock: true},
+		"Postgres text":         {driver: "pgx", err: errors.New("ERROR: deadlock detected (SQLSTATE 40P01)"), deadlock: true},
+		"SQLite busy":           {driver: "sqlite3", err: sqliteError{Code: 5}, deadlock: true},
+		"SQLite extended code":  {driver: "sqlite3", err: sqliteError{Code: 5 | 1<<8}, deadlock: true},
+		"SQLite locked message": {driver: "sqlite", err: errors.New("database table is locked"), deadlock: true},
+		"SQLite other":          {driver: "sqlite3", err: sqliteError{Code: 19}, deadlock: false},
+		"Nil":                   {driver: "postgres", err: nil, deadlock: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			adapter, err := adapters.find(test.driver)
+			require.NoError(t, err)
+			assert.Equal(t, test.deadlock, adapter.IsDeadlock(nil, test.err))
+		})
+	}
+}
+
+func TestAdapter_IsConnectionLost(t *testing.T) {
+	type Test struct {
+		driver string
+		err    error
+		lost   bool
+	}
+
+	tests := map[string]Test{
+		"MySQL gone away":   {driver: "mysql", err: errors.New("Error 2006: MySQL server has gone away"), lost: true},
+		"MySQL lost":        {driver: "mysql", err: errors.New("Error 2013: Lost connection to MySQL server"), lost: true},
+		"MySQL invalid":     {driver: "mysql", err: errors.New("invalid connection"), lost: true},
+		"MySQL other":       {driver: "mysql", err: errors.New("Error 1062: Duplicate entry"), lost: false},
+		"Postgres class 08": {driver: "postgres", err: &pqError{Code: "08006", Message: "connection failure"}, lost: true},
+		"Postgres shutdown": {driver: "pgx", err: errors.New("FATAL: terminating connection (SQLSTATE 57P01)"), lost: true},
+		"Postgres other":    {driver: "postgres", err: &pqError{Code: "23505", Message: "duplicate key"}, lost: false},
+		"Network":           {driver: "sqlite3", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, lost: true},
+		"Syscall":           {driver: "sqlite3", err: os.NewSyscallError("write", syscall.EPIPE), lost: true},
+		"Unexpected EOF":    {driver: "sqlite3", err: io.ErrUnexpectedEOF, lost: true},
+		"Deadline":          {driver: "mysql", err: context.DeadlineExceeded, lost: false},
+		"Other syscall":     {driver: "sqlite3", err: syscall.ENOENT, lost: false},
+		"Nil":               {driver: "postgres", err: nil, lost: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			adapter, err := adapters.find(test.driver)
+			require.NoError(t, err)
+			assert.Equal(t, test.lost, isConnectionLost(adapter, test.err))
+		})
+	}
+}
+
+func TestAdapter_Quoting(t *testing.T) {
+	mysql, _ := adapters.find("mysql")
+	postgres, _ := adapters.find("postgres")
+	sqlite, _ := adapters.find("sqlite3")
+
+	assert.Equal(t, "`shop`.`order`", mysql.QuoteIdentifier("shop.order"))
+	assert.Equal(t, "\"we\"\"ird\"", postgres.QuoteIdentifier("we\"ird"))
+	assert.Equal(t, "\"user\"", sqlite.QuoteIdentifier("user"))
+
+	const query = "SELECT * FROM t WHERE a = ? AND b = '?' AND \"c?\" IN (?, ?)"
+	assert.Equal(t, query, Rebind(mysql, query))
+	assert.Equal(t, query, Rebind(sqlite, query))
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = '?' AND \"c?\" IN ($2, $3)", Rebind(postgres, query))
+}
+
+func TestRebind(t *testing.T) {
+	type Test struct {
+		query    string
+		postgres string
+		mysql    string
+	}
+
+	tests := map[string]Test{
+		"Comments": {
+			query:    "SELECT ? -- why?\n, ? /* what? */ FROM t",
+			postgres: "SELECT $1 -- why?\n, $2 /* what? */ FROM t",
+		},
+		"Dollar quoting": {
+			query:    "CREATE FUNCTION f(a INT) AS $$ SELECT a = ? $$; SELECT $body$ ? $body$, ?",
+			postgres: "CREATE FUNCTION f(a INT) AS $$ SELECT a = ? $$; SELECT $body$ ? $body$, $1",
+		},
+		"JSONB operators": {
+			query:    "SELECT * FROM t WHERE data ?? ? AND data ?| ? AND data ?& ?",
+			postgres: "SELECT * FROM t WHERE data ? $1 AND data ?| $2 AND data ?& $3",
+			mysql:    "SELECT * FROM t WHERE data ? ? AND data ?| ? AND data ?& ?",
+		},
+		"Concatenation": {
+			query:    "SELECT ?||'x', ?&&?",
+			postgres: "SELECT $1||'x', $2&&$3",
+		},
+		"Unterminated": {
+			query:    "SELECT ? FROM t /* ?",
+			postgres: "SELECT $1 FROM t /* ?",
+		},
+	}
+
+	mysql, _ := adapters.find("mysql")
+	postgres, _ := adapters.find("postgres")
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.postgres, Rebind(postgres, test.query))
+			if test.mysql == "" {
+				test.mysql = test.query
+			}
+			assert.Equal(t, test.mysql, Rebind(mysql, test.query))
+		})
+	}
+}
+
+func TestSqliteAdapter_Lock(t *testing.T) {
+	adapter, _ := adapters.find("sqlite3")
+	ctx := context.Background()
+
+	require.NoError(t, adapter.LockGlobal(ctx, nil, "a", 0))
+	assert.Equal(t, ErrCaptureLock, adapter.LockGlobal(ctx, nil, "a", 0))
+	assert.Equal(t, ErrCaptureLock, adapter.LockGlobal(ctx, nil, "a", 1))
+	require.NoError(t, adapter.LockLocal(ctx, nil, "a", 0))
+	require.NoError(t, adapter.UnlockGlobal(ctx, nil, "a"))
+	assert.Equal(t, ErrReleaseLock, adapter.UnlockGlobal(ctx, nil, "a"))
+	require.NoError(t, adapter.UnlockLocal(ctx, nil, "a"))
+}
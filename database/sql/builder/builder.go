@@ -0,0 +1,182 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package builder composes SQL queries, that are rendered through
+// dialect of sql.Adapter (quoting of identifiers and placeholders).
+//
+//	users := builder.Select("id", "name").
+//		From("users u").
+//		Where(builder.Eq{"u.status": []int{1, 2}}).
+//		Sort(data.Sort{"name": data.SortAsc}).
+//		Paginate(pagination)
+//	err := users.Select(ctx, scope, &list)
+package builder
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+var (
+	ErrNoTable  = errors.New("builder: table is not specified")
+	ErrNoValues = errors.New("builder: values are not specified")
+	ErrNoColumn = errors.New("builder: invalid name of column for sorting")
+)
+
+// Expr is fragment of query.
+// Values of type Expr are rendered inline instead of placeholders.
+type Expr interface {
+	build(w *writer)
+}
+
+// Query is complete statement.
+type Query interface {
+	// Render query and arguments for adapter.
+	ToSQL(adapter sql.Adapter) (string, []interface{}, error)
+}
+
+type writer struct {
+	adapter sql.Adapter
+	buf     strings.Builder
+	args    []interface{}
+	err     error
+}
+
+func (w *writer) write(s string) {
+	w.buf.WriteString(s)
+}
+
+func (w *writer) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Write name of column. Expressions, like "COUNT(*)" or "a + b",
+// and alias "name AS alias" are supported.
+func (w *writer) column(name string) {
+	w.write(quoteName(w.adapter, name))
+}
+
+// Write name of column for sorting. Only (qualified) names are allowed,
+// because keys of sorting often come from request.
+func (w *writer) sortColumn(name string) {
+	if !isIdentifier(name) || name == "*" || strings.HasSuffix(name, ".*") {
+		w.fail(ErrNoColumn)
+		return
+	}
+	w.write(w.adapter.QuoteIdentifier(name))
+}
+
+func (w *writer) columns(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.column(name)
+	}
+}
+
+func (w *writer) value(v interface{}) {
+	if expr, ok := v.(Expr); ok {
+		expr.build(w)
+		return
+	}
+	w.write("?")
+	w.args = append(w.args, v)
+}
+
+func (w *writer) result() (string, []interface{}, error) {
+	if w.err != nil {
+		return "", nil, w.err
+	}
+	return sql.Rebind(w.adapter, w.buf.String()), w.args, nil
+}
+
+func render(adapter sql.Adapter, expr Expr) (string, []interface{}, error) {
+	w := &writer{adapter: adapter}
+	expr.build(w)
+	return w.result()
+}
+
+// Quote name of column or table with optional alias.
+// Names, that contain expressions, are kept as is.
+func quoteName(adapter sql.Adapter, name string) string {
+	parts := strings.Fields(name)
+	switch {
+	case len(parts) == 1 && isIdentifier(parts[0]):
+		return quoteIdentifier(adapter, parts[0])
+	case len(parts) == 2 && isIdentifier(parts[0]) && isIdentifier(parts[1]):
+		return quoteIdentifier(adapter, parts[0]) + " " + adapter.QuoteIdentifier(parts[1])
+	case len(parts) == 3 && isIdentifier(parts[0]) && strings.EqualFold(parts[1], "AS") && isIdentifier(parts[2]):
+		return quoteIdentifier(adapter, parts[0]) + " AS " + adapter.QuoteIdentifier(parts[2])
+	default:
+		return name
+	}
+}
+
+// Quote qualified name, keeping "*" of "table.*".
+func quoteIdentifier(adapter sql.Adapter, name string) string {
+	if name == "*" {
+		return name
+	}
+	if strings.HasSuffix(name, ".*") {
+		return adapter.QuoteIdentifier(strings.TrimSuffix(name, ".*")) + ".*"
+	}
+	return adapter.QuoteIdentifier(name)
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '_' || c == '.' || c == '*' || c >= 'a' && c <= 'z' ||
+			c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c > 127) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check, that value is list of values (not []byte).
+func isList(v interface{}) (reflect.Value, bool) {
+	if _, ok := v.(Expr); ok {
+		return reflect.Value{}, false
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return reflect.Value{}, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return reflect.Value{}, false
+	}
+	return rv, true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
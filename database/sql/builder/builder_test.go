@@ -0,0 +1,177 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/adverax/echo/data"
+	"github.com/adverax/echo/database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func adapter(t *testing.T, driver string) sql.Adapter {
+	res, err := sql.FindAdapter(driver)
+	require.NoError(t, err)
+	return res
+}
+
+func TestBuilder(t *testing.T) {
+	type Test struct {
+		driver string
+		query  Query
+		sql    string
+		args   []interface{}
+	}
+
+	tests := map[string]Test{
+		"Select all": {
+			driver: "mysql",
+			query:  Select().From("users"),
+			sql:    "SELECT * FROM `users`",
+		},
+		"Select with conditions": {
+			driver: "postgres",
+			query: Select("u.id", "u.name AS title", "COUNT(*)").
+				From("users u").
+				LeftJoin("orders o", Raw("o.user_id = u.id")).
+				Where(Eq{"u.status": []int{1, 2}, "u.deleted": nil}).
+				Where(Or(Gt("u.age", 18), Like("u.name", "a%"), nil)).
+				GroupBy("u.id", "u.name").
+				Having(Raw("COUNT(*) > ?", 1)).
+				Sort(data.Sort{"u.name": data.SortAsc, "u.id": data.SortDesc}).
+				Paginate(&data.Pagination{Offset: 20, Limit: 10}),
+			sql: `SELECT "u"."id", "u"."name" AS "title", COUNT(*) FROM "users" "u"` +
+				` LEFT JOIN "orders" "o" ON o.user_id = u.id` +
+				` WHERE ("u"."deleted" IS NULL AND "u"."status" IN ($1, $2))` +
+				` AND (("u"."age" > $3) OR ("u"."name" LIKE $4))` +
+				` GROUP BY "u"."id", "u"."name" HAVING COUNT(*) > $5` +
+				` ORDER BY "u"."id" DESC, "u"."name" LIMIT 10 OFFSET 20`,
+			args: []interface{}{1, 2, 18, "a%", 1},
+		},
+		"Escaped like": {
+			driver: "sqlite3",
+			query:  Select("id").From("users").Where(LikeEscaped("name", `50\%%`)),
+			sql:    `SELECT "id" FROM "users" WHERE "name" LIKE ? ESCAPE '\'`,
+			args:   []interface{}{`50\%%`},
+		},
+		"Escaped like of MySQL": {
+			driver: "mysql",
+			query:  Select("id").From("users").Where(LikeEscaped("name", `50\%%`)),
+			sql:    "SELECT `id` FROM `users` WHERE `name` LIKE ? ESCAPE '\\\\'",
+			args:   []interface{}{`50\%%`},
+		},
+		"Offset without limit": {
+			driver: "mysql",
+			query:  Select("id").From("users").Offset(5),
+			sql:    "SELECT `id` FROM `users` LIMIT 9223372036854775807 OFFSET 5",
+		},
+		"Empty in": {
+			driver: "mysql",
+			query:  Select("id").From("users").Where(And(In("id", []int64{}), Not(NotIn("id", []int64{})))),
+			sql:    "SELECT `id` FROM `users` WHERE (1=0) AND (NOT (1=1))",
+		},
+		"Subquery": {
+			driver: "postgres",
+			query: Select("id").From("users").
+				Where(In("id", Select("user_id").From("orders").Where(Gte("total", 100)))).
+				Where(Between("age", 18, 30)).
+				ForUpdate(),
+			sql:  `SELECT "id" FROM "users" WHERE ("id" IN (SELECT "user_id" FROM "orders" WHERE "total" >= $1)) AND ("age" BETWEEN $2 AND $3) FOR UPDATE`,
+			args: []interface{}{100, 18, 30},
+		},
+		"Count": {
+			driver: "mysql",
+			query:  Select("id").From("users").Where(NotEq{"id": 1}).OrderBy("id", data.SortAsc).Limit(10).Count(),
+			sql:    "SELECT COUNT(*) FROM `users` WHERE `id` <> ?",
+			args:   []interface{}{1},
+		},
+		"Count of groups": {
+			driver: "mysql",
+			query:  Select("city").From("users").GroupBy("city").Limit(10).Count(),
+			sql:    "SELECT COUNT(*) FROM (SELECT `city` FROM `users` GROUP BY `city`) `t`",
+		},
+		"Insert": {
+			driver: "postgres",
+			query:  Insert("users").Columns("id", "name").Values(1, "alice").Values(2, Raw("UPPER(?)", "bob")),
+			sql:    `INSERT INTO "users" ("id", "name") VALUES ($1, $2), ($3, UPPER($4))`,
+			args:   []interface{}{1, "alice", 2, "bob"},
+		},
+		"Upsert MySQL": {
+			driver: "mysql",
+			query:  Insert("users").SetMap(map[string]interface{}{"id": 1, "name": "alice"}).OnConflict([]string{"id"}),
+			sql:    "INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+			args:   []interface{}{1, "alice"},
+		},
+		"Upsert PostgreSQL": {
+			driver: "postgres",
+			query:  Insert("users").SetMap(map[string]interface{}{"id": 1, "name": "alice"}).OnConflict([]string{"id"}),
+			sql:    `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+			args:   []interface{}{1, "alice"},
+		},
+		"Insert ignore": {
+			driver: "mysql",
+			query:  Insert("users").Columns("id").Values(1).OnConflictDoNothing(),
+			sql:    "INSERT IGNORE INTO `users` (`id`) VALUES (?)",
+			args:   []interface{}{1},
+		},
+		"Insert do nothing": {
+			driver: "sqlite3",
+			query:  Insert("users").Columns("id").Values(1).OnConflictDoNothing(),
+			sql:    `INSERT INTO "users" ("id") VALUES (?) ON CONFLICT DO NOTHING`,
+			args:   []interface{}{1},
+		},
+		"Insert from query": {
+			driver: "mysql",
+			query:  Insert("archive").Columns("id").FromQuery(Select("id").From("users").Where(Lt("id", 10))),
+			sql:    "INSERT INTO `archive` (`id`) SELECT `id` FROM `users` WHERE `id` < ?",
+			args:   []interface{}{10},
+		},
+		"Update": {
+			driver: "postgres",
+			query:  Update("users").Set("visits", Raw("visits + ?", 1)).SetMap(map[string]interface{}{"name": "bob"}).Where(Eq{"id": 1}),
+			sql:    `UPDATE "users" SET "visits" = visits + $1, "name" = $2 WHERE "id" = $3`,
+			args:   []interface{}{1, "bob", 1},
+		},
+		"Delete": {
+			driver: "mysql",
+			query:  Delete("users").Where(Lte("id", 10)).Where(IsNotNull("deleted")),
+			sql:    "DELETE FROM `users` WHERE (`id` <= ?) AND (`deleted` IS NOT NULL)",
+			args:   []interface{}{10},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, args, err := test.query.ToSQL(adapter(t, test.driver))
+			require.NoError(t, err)
+			assert.Equal(t, test.sql, query)
+			assert.Equal(t, test.args, args)
+		})
+	}
+}
+
+func TestBuilder_Errors(t *testing.T) {
+	mysql := adapter(t, "mysql")
+	postgres := adapter(t, "postgres")
+
+	_, _, err := Insert("users").ToSQL(mysql)
+	assert.Equal(t, ErrNoValues, err)
+	_, _, err = Update("").Set("a", 1).ToSQL(mysql)
+	assert.Equal(t, ErrNoTable, err)
+	_, _, err = Insert("users").Columns("id", "name").Values(1).ToSQL(mysql)
+	assert.Error(t, err)
+	_, _, err = Insert("users").Columns("id").Values(1).OnConflict(nil).ToSQL(postgres)
+	assert.Error(t, err)
+	_, _, err = Insert("users").Columns("id").Values(1).OnConflict([]string{"id"}).ToSQL(mysql)
+	assert.Error(t, err)
+	_, _, err = Insert("users").Columns("id").Values(1).OnConflict([]string{"id"}).ToSQL(postgres)
+	assert.Error(t, err)
+	_, _, err = Select().From("users").Where(Raw("a = ? AND b = ?", 1)).ToSQL(mysql)
+	assert.Error(t, err)
+	_, _, err = Select().From("users").Sort(data.Sort{"id; DROP TABLE users": data.SortAsc}).ToSQL(mysql)
+	assert.Equal(t, ErrNoColumn, err)
+	_, _, err = Select().From("users").OrderBy("(SELECT password FROM users LIMIT 1)", data.SortDesc).ToSQL(postgres)
+	assert.Equal(t, ErrNoColumn, err)
+	_, _, err = Select().From("users").OrderBy("*", data.SortAsc).ToSQL(postgres)
+	assert.Equal(t, ErrNoColumn, err)
+}
@@ -0,0 +1,341 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type raw struct {
+	sql  string
+	args []interface{}
+}
+
+func (expr *raw) build(w *writer) {
+	if len(expr.args) == 0 {
+		w.write(expr.sql)
+		return
+	}
+
+	// Each "?" is replaced by argument, so arguments can be expressions too.
+	parts := splitPlaceholders(expr.sql)
+	if len(parts)-1 != len(expr.args) {
+		w.fail(fmt.Errorf("raw expression %q expects %d arguments, but got %d", expr.sql, len(parts)-1, len(expr.args)))
+		return
+	}
+	for i, part := range parts {
+		if i > 0 {
+			w.value(expr.args[i-1])
+		}
+		w.write(part)
+	}
+}
+
+// Raw creates expression from fragment of SQL with placeholders "?".
+func Raw(sql string, args ...interface{}) Expr {
+	return &raw{sql: sql, args: args}
+}
+
+// Split text by placeholders outside of quotes.
+func splitPlaceholders(s string) []string {
+	var res []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			res = append(res, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, s[start:])
+}
+
+type compare struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (expr *compare) build(w *writer) {
+	w.column(expr.column)
+	w.write(" " + expr.op + " ")
+	w.value(expr.value)
+}
+
+// Lt creates condition "column < value".
+func Lt(column string, value interface{}) Expr {
+	return &compare{column: column, op: "<", value: value}
+}
+
+// Lte creates condition "column <= value".
+func Lte(column string, value interface{}) Expr {
+	return &compare{column: column, op: "<=", value: value}
+}
+
+// Gt creates condition "column > value".
+func Gt(column string, value interface{}) Expr {
+	return &compare{column: column, op: ">", value: value}
+}
+
+// Gte creates condition "column >= value".
+func Gte(column string, value interface{}) Expr {
+	return &compare{column: column, op: ">=", value: value}
+}
+
+// Like creates condition "column LIKE pattern".
+func Like(column string, pattern interface{}) Expr {
+	return &compare{column: column, op: "LIKE", value: pattern}
+}
+
+// LikeEscaped creates condition "column LIKE pattern ESCAPE '\'".
+// Wildcards of pattern, that match literally, are escaped by backslash.
+func LikeEscaped(column string, pattern interface{}) Expr {
+	return &likeEscaped{column: column, pattern: pattern}
+}
+
+type likeEscaped struct {
+	column  string
+	pattern interface{}
+}
+
+func (expr *likeEscaped) build(w *writer) {
+	w.column(expr.column)
+	w.write(" LIKE ")
+	w.value(expr.pattern)
+	if isMySQL(w.adapter) {
+		// Backslash escapes string literals of MySQL
+		w.write(` ESCAPE '\\'`)
+	} else {
+		w.write(` ESCAPE '\'`)
+	}
+}
+
+type in struct {
+	column string
+	values interface{}
+	not    bool
+}
+
+func (expr *in) build(w *writer) {
+	list, ok := isList(expr.values)
+	if !ok {
+		// Subquery or single value
+		w.column(expr.column)
+		if expr.not {
+			w.write(" NOT IN (")
+		} else {
+			w.write(" IN (")
+		}
+		if q, ok := expr.values.(*SelectBuilder); ok {
+			q.render(w)
+		} else {
+			w.value(expr.values)
+		}
+		w.write(")")
+		return
+	}
+
+	if list.Len() == 0 {
+		// Empty list matches nothing
+		if expr.not {
+			w.write("1=1")
+		} else {
+			w.write("1=0")
+		}
+		return
+	}
+
+	w.column(expr.column)
+	if expr.not {
+		w.write(" NOT IN (")
+	} else {
+		w.write(" IN (")
+	}
+	for i := 0; i < list.Len(); i++ {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.value(list.Index(i).Interface())
+	}
+	w.write(")")
+}
+
+// In creates condition "column IN (values)".
+// Argument values is slice or subquery. Empty slice matches nothing.
+func In(column string, values interface{}) Expr {
+	return &in{column: column, values: values}
+}
+
+// NotIn creates condition "column NOT IN (values)".
+func NotIn(column string, values interface{}) Expr {
+	return &in{column: column, values: values, not: true}
+}
+
+type between struct {
+	column   string
+	from, to interface{}
+}
+
+func (expr *between) build(w *writer) {
+	w.column(expr.column)
+	w.write(" BETWEEN ")
+	w.value(expr.from)
+	w.write(" AND ")
+	w.value(expr.to)
+}
+
+// Between creates condition "column BETWEEN from AND to".
+func Between(column string, from, to interface{}) Expr {
+	return &between{column: column, from: from, to: to}
+}
+
+type isNull struct {
+	column string
+	not    bool
+}
+
+func (expr *isNull) build(w *writer) {
+	w.column(expr.column)
+	if expr.not {
+		w.write(" IS NOT NULL")
+	} else {
+		w.write(" IS NULL")
+	}
+}
+
+// IsNull creates condition "column IS NULL".
+func IsNull(column string) Expr {
+	return &isNull{column: column}
+}
+
+// IsNotNull creates condition "column IS NOT NULL".
+func IsNotNull(column string) Expr {
+	return &isNull{column: column, not: true}
+}
+
+// Eq is conjunction of equalities "column = value".
+// Nil value is rendered as "IS NULL", slice - as "IN (...)".
+type Eq map[string]interface{}
+
+func (expr Eq) build(w *writer) {
+	buildEq(w, expr, false)
+}
+
+// NotEq is conjunction of inequalities "column <> value".
+type NotEq map[string]interface{}
+
+func (expr NotEq) build(w *writer) {
+	buildEq(w, expr, true)
+}
+
+func buildEq(w *writer, m map[string]interface{}, not bool) {
+	if len(m) == 0 {
+		w.write("1=1")
+		return
+	}
+
+	for i, column := range sortedKeys(m) {
+		if i > 0 {
+			w.write(" AND ")
+		}
+
+		value := m[column]
+		if _, ok := isList(value); ok {
+			(&in{column: column, values: value, not: not}).build(w)
+			continue
+		}
+		if value == nil || reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil() {
+			(&isNull{column: column, not: not}).build(w)
+			continue
+		}
+
+		op := "="
+		if not {
+			op = "<>"
+		}
+		(&compare{column: column, op: op, value: value}).build(w)
+	}
+}
+
+type junction struct {
+	op    string
+	exprs []Expr
+}
+
+func (expr *junction) build(w *writer) {
+	var exprs []Expr
+	for _, e := range expr.exprs {
+		if e != nil {
+			exprs = append(exprs, e)
+		}
+	}
+
+	switch len(exprs) {
+	case 0:
+		if expr.op == "AND" {
+			w.write("1=1")
+		} else {
+			w.write("1=0")
+		}
+	case 1:
+		exprs[0].build(w)
+	default:
+		for i, e := range exprs {
+			if i > 0 {
+				w.write(" " + expr.op + " ")
+			}
+			w.write("(")
+			e.build(w)
+			w.write(")")
+		}
+	}
+}
+
+// And creates conjunction of expressions. Nil expressions are skipped.
+func And(exprs ...Expr) Expr {
+	return &junction{op: "AND", exprs: exprs}
+}
+
+// Or creates disjunction of expressions. Nil expressions are skipped.
+func Or(exprs ...Expr) Expr {
+	return &junction{op: "OR", exprs: exprs}
+}
+
+type not struct {
+	expr Expr
+}
+
+func (expr *not) build(w *writer) {
+	w.write("NOT (")
+	expr.expr.build(w)
+	w.write(")")
+}
+
+// Not creates negation of expression.
+func Not(expr Expr) Expr {
+	return &not{expr: expr}
+}
@@ -0,0 +1,357 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+// InsertBuilder builds query INSERT.
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	query     *SelectBuilder
+	upsert    bool
+	conflict  []string // Columns of unique key
+	updates   []string // Columns, updated on conflict
+	doNothing bool
+}
+
+// Insert creates builder of query INSERT.
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns sets inserted columns.
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = columns
+	return b
+}
+
+// Values appends row of values in order of columns.
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// SetMap sets columns and values of single row.
+func (b *InsertBuilder) SetMap(values map[string]interface{}) *InsertBuilder {
+	b.columns = sortedKeys(values)
+	row := make([]interface{}, len(b.columns))
+	for i, column := range b.columns {
+		row[i] = values[column]
+	}
+	b.rows = [][]interface{}{row}
+	return b
+}
+
+// FromQuery inserts rows, selected by query.
+func (b *InsertBuilder) FromQuery(query *SelectBuilder) *InsertBuilder {
+	b.query = query
+	return b
+}
+
+// OnConflict updates columns (all inserted columns besides conflict
+// columns, if list is empty) of existing row, when unique key is violated.
+// Conflict columns are ignored by MySQL, that uses any violated key.
+// Rendering fails, if there are no columns for update (use OnConflictDoNothing).
+func (b *InsertBuilder) OnConflict(conflict []string, update ...string) *InsertBuilder {
+	b.upsert = true
+	b.conflict = conflict
+	b.updates = update
+	b.doNothing = false
+	return b
+}
+
+// OnConflictDoNothing skips rows, that violate unique key.
+func (b *InsertBuilder) OnConflictDoNothing(conflict ...string) *InsertBuilder {
+	b.upsert = true
+	b.conflict = conflict
+	b.updates = nil
+	b.doNothing = true
+	return b
+}
+
+func (b *InsertBuilder) ToSQL(adapter sql.Adapter) (string, []interface{}, error) {
+	if b.table == "" {
+		return "", nil, ErrNoTable
+	}
+	if len(b.rows) == 0 && b.query == nil {
+		return "", nil, ErrNoValues
+	}
+
+	mysql := isMySQL(adapter)
+	w := &writer{adapter: adapter}
+	if mysql && b.doNothing {
+		w.write("INSERT IGNORE INTO ")
+	} else {
+		w.write("INSERT INTO ")
+	}
+	w.column(b.table)
+	if len(b.columns) != 0 {
+		w.write(" (")
+		w.columns(b.columns)
+		w.write(")")
+	}
+
+	if b.query != nil {
+		w.write(" ")
+		b.query.render(w)
+	} else {
+		w.write(" VALUES ")
+		for i, row := range b.rows {
+			if len(b.columns) != 0 && len(row) != len(b.columns) {
+				return "", nil, fmt.Errorf("builder: row %d has %d values, but %d columns are specified", i, len(row), len(b.columns))
+			}
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write("(")
+			for j, value := range row {
+				if j > 0 {
+					w.write(", ")
+				}
+				w.value(value)
+			}
+			w.write(")")
+		}
+	}
+
+	if b.upsert {
+		if mysql {
+			b.renderMySQLUpsert(w)
+		} else {
+			b.renderUpsert(w)
+		}
+	}
+
+	return w.result()
+}
+
+// ON DUPLICATE KEY UPDATE
+func (b *InsertBuilder) renderMySQLUpsert(w *writer) {
+	if b.doNothing {
+		return
+	}
+
+	columns := b.updatedColumns()
+	if len(columns) == 0 {
+		w.fail(fmt.Errorf("builder: no columns for update of %s", b.table))
+		return
+	}
+
+	w.write(" ON DUPLICATE KEY UPDATE ")
+	for i, column := range columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		c := w.adapter.QuoteIdentifier(column)
+		w.write(c + " = VALUES(" + c + ")")
+	}
+}
+
+// ON CONFLICT (PostgreSQL, SQLite)
+func (b *InsertBuilder) renderUpsert(w *writer) {
+	w.write(" ON CONFLICT")
+	if len(b.conflict) != 0 {
+		w.write(" (")
+		w.columns(b.conflict)
+		w.write(")")
+	}
+
+	if b.doNothing {
+		w.write(" DO NOTHING")
+		return
+	}
+
+	if len(b.conflict) == 0 {
+		w.fail(fmt.Errorf("builder: conflict columns are required for update of %s", b.table))
+		return
+	}
+
+	columns := b.updatedColumns()
+	if len(columns) == 0 {
+		w.fail(fmt.Errorf("builder: no columns for update of %s", b.table))
+		return
+	}
+
+	w.write(" DO UPDATE SET ")
+	for i, column := range columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		c := w.adapter.QuoteIdentifier(column)
+		w.write(c + " = EXCLUDED." + c)
+	}
+}
+
+func (b *InsertBuilder) updatedColumns() []string {
+	if len(b.updates) != 0 {
+		return b.updates
+	}
+
+	var res []string
+	for _, column := range b.columns {
+		if !contains(b.conflict, column) {
+			res = append(res, column)
+		}
+	}
+	return res
+}
+
+// Exec executes query within scope.
+func (b *InsertBuilder) Exec(ctx context.Context, scope sql.Scope) (sql.Result, error) {
+	return exec(ctx, scope, b)
+}
+
+type assignment struct {
+	column string
+	value  interface{}
+}
+
+// UpdateBuilder builds query UPDATE.
+type UpdateBuilder struct {
+	table string
+	set   []assignment
+	where []Expr
+}
+
+// Update creates builder of query UPDATE.
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set assigns value (or expression) to column.
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.set = append(b.set, assignment{column: column, value: value})
+	return b
+}
+
+// SetMap assigns values to columns (in alphabetical order of columns).
+func (b *UpdateBuilder) SetMap(values map[string]interface{}) *UpdateBuilder {
+	for _, column := range sortedKeys(values) {
+		b.Set(column, values[column])
+	}
+	return b
+}
+
+// Where appends condition. Conditions are combined by AND.
+func (b *UpdateBuilder) Where(expr Expr) *UpdateBuilder {
+	if expr != nil {
+		b.where = append(b.where, expr)
+	}
+	return b
+}
+
+func (b *UpdateBuilder) ToSQL(adapter sql.Adapter) (string, []interface{}, error) {
+	if b.table == "" {
+		return "", nil, ErrNoTable
+	}
+	if len(b.set) == 0 {
+		return "", nil, ErrNoValues
+	}
+
+	w := &writer{adapter: adapter}
+	w.write("UPDATE ")
+	w.column(b.table)
+	w.write(" SET ")
+	for i, a := range b.set {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(adapter.QuoteIdentifier(a.column) + " = ")
+		w.value(a.value)
+	}
+
+	if len(b.where) != 0 {
+		w.write(" WHERE ")
+		And(b.where...).build(w)
+	}
+
+	return w.result()
+}
+
+// Exec executes query within scope.
+func (b *UpdateBuilder) Exec(ctx context.Context, scope sql.Scope) (sql.Result, error) {
+	return exec(ctx, scope, b)
+}
+
+// DeleteBuilder builds query DELETE.
+type DeleteBuilder struct {
+	table string
+	where []Expr
+}
+
+// Delete creates builder of query DELETE.
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where appends condition. Conditions are combined by AND.
+func (b *DeleteBuilder) Where(expr Expr) *DeleteBuilder {
+	if expr != nil {
+		b.where = append(b.where, expr)
+	}
+	return b
+}
+
+func (b *DeleteBuilder) ToSQL(adapter sql.Adapter) (string, []interface{}, error) {
+	if b.table == "" {
+		return "", nil, ErrNoTable
+	}
+
+	w := &writer{adapter: adapter}
+	w.write("DELETE FROM ")
+	w.column(b.table)
+	if len(b.where) != 0 {
+		w.write(" WHERE ")
+		And(b.where...).build(w)
+	}
+
+	return w.result()
+}
+
+// Exec executes query within scope.
+func (b *DeleteBuilder) Exec(ctx context.Context, scope sql.Scope) (sql.Result, error) {
+	return exec(ctx, scope, b)
+}
+
+func exec(ctx context.Context, scope sql.Scope, q Query) (sql.Result, error) {
+	query, args, err := q.ToSQL(scope.Adapter())
+	if err != nil {
+		return nil, err
+	}
+	return scope.ExecContext(ctx, query, args...)
+}
+
+func isMySQL(adapter sql.Adapter) bool {
+	return adapter.Driver() == "mysql"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/adverax/echo/data"
+	"github.com/adverax/echo/database/sql"
+)
+
+// Mapper converts current row into record.
+type Mapper func(row sql.Fetcher) (interface{}, error)
+
+// Provider is data.Provider, that loads pages of records by base query.
+// Example:
+//   provider := &builder.Provider{
+//       Scope: db,
+//       Query: builder.Select("id", "name").From("users"),
+//       Mapper: func(row sql.Fetcher) (interface{}, error) {
+//           user := new(User)
+//           err := row.Scan(&user.Id, &user.Name)
+//           return user, err
+//       },
+//       Columns: map[string]string{"name": "name"},
+//       Sort: data.Sort{"name": data.SortAsc},
+//   }
+//   table := &widget.Table{Pager: widget.Pager{Provider: provider}, ...}
+//   // Columns of table read provider.Record().(*User)
+type Provider struct {
+	Scope  sql.Scope      // Scope of queries
+	Query  *SelectBuilder // Base query (without sorting and pagination)
+	Mapper Mapper         // Mapper of rows
+
+	// Sortable and filterable columns: key of Sort or Filter -> column of query.
+	// Keys of Sort and Filter, that are absent in this list, are ignored.
+	Columns map[string]string
+	Sort    data.Sort   // Requested sorting
+	Filter  data.Filter // Requested filter
+
+	// Unique columns for keyset pagination (optional).
+	// If After is specified, page starts after the row with these values
+	// of keyset columns and offset of pagination is ignored. Records are
+	// ordered only by keyset columns (direction is taken from Sort).
+	Keyset []string
+	After  []interface{}
+
+	// Total is calculated by window function COUNT(*) OVER () within
+	// query of page, if the page is imported before call of Total.
+	// Otherwise separated query COUNT(*) is used.
+	WindowTotal bool
+
+	records []interface{}
+	index   int // Index of current record (starts from 1)
+	loaded  bool
+	total   int
+	counted bool
+}
+
+// Record returns current record.
+func (provider *Provider) Record() interface{} {
+	if provider.index < 1 || provider.index > len(provider.records) {
+		return nil
+	}
+	return provider.records[provider.index-1]
+}
+
+func (provider *Provider) SetSort(sort data.Sort) {
+	provider.Sort = sort
+	provider.loaded = false
+}
+
+func (provider *Provider) SetFilter(filter data.Filter) {
+	provider.Filter = filter
+	provider.loaded = false
+	provider.counted = false
+}
+
+// Records returns all imported records.
+func (provider *Provider) Records() []interface{} {
+	return provider.records
+}
+
+func (provider *Provider) Count(ctx context.Context) (int, error) {
+	if !provider.loaded {
+		err := provider.Import(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(provider.records), nil
+}
+
+func (provider *Provider) Total(ctx context.Context) (int, error) {
+	if provider.counted {
+		return provider.total, nil
+	}
+
+	var total int
+	err := provider.query().Count().QueryRow(ctx, provider.Scope).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	provider.total = total
+	provider.counted = true
+	return total, nil
+}
+
+func (provider *Provider) Import(ctx context.Context, pagination *data.Pagination) error {
+	query := provider.page(pagination)
+	window := provider.WindowTotal && !provider.counted
+	if window {
+		query.Columns("COUNT(*) OVER () AS " + provider.Scope.Adapter().QuoteIdentifier("_total"))
+	}
+
+	rows, err := query.Query(ctx, provider.Scope)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var row sql.Fetcher = rows
+	var total int
+	if window {
+		row = &windowRow{Fetcher: rows, total: &total}
+	}
+
+	provider.records = provider.records[:0]
+	provider.index = 0
+	for rows.Next() {
+		record, err := provider.Mapper(row)
+		if err != nil {
+			return err
+		}
+		provider.records = append(provider.records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	provider.loaded = true
+	if window && len(provider.records) != 0 {
+		provider.total = total
+		provider.counted = true
+	}
+
+	return nil
+}
+
+func (provider *Provider) Next(ctx context.Context) error {
+	index := provider.index + 1
+	if index > len(provider.records) {
+		return data.ErrRangeCheckError
+	}
+
+	provider.index = index
+	return nil
+}
+
+// Build base query with conditions of filter.
+func (provider *Provider) query() *SelectBuilder {
+	query := provider.Query.clone()
+
+	// Conditions are appended in order of keys
+	keys := make([]string, 0, len(provider.Filter))
+	for key := range provider.Filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		column, ok := provider.Columns[key]
+		if !ok {
+			continue
+		}
+		criterion := provider.Filter[key]
+		switch criterion.Kind {
+		case data.FilterText:
+			if criterion.Value != "" {
+				query.Where(LikeEscaped(column, "%"+escapeLike(criterion.Value)+"%"))
+			}
+		case data.FilterSelect:
+			query.Where(Eq{column: criterion.Value})
+		case data.FilterDateRange:
+			if !criterion.From.IsZero() {
+				query.Where(Gte(column, criterion.From))
+			}
+			if !criterion.To.IsZero() {
+				query.Where(Lt(column, criterion.To))
+			}
+		}
+	}
+
+	return query
+}
+
+// Build query of page.
+func (provider *Provider) page(pagination *data.Pagination) *SelectBuilder {
+	query := provider.query()
+
+	if len(provider.Keyset) != 0 {
+		sorting := make([]data.Sorting, len(provider.Keyset))
+		for i, column := range provider.Keyset {
+			sorting[i] = data.SortAsc
+			for key, s := range provider.Sort {
+				if provider.Columns[key] == column {
+					sorting[i] = s
+				}
+			}
+			query.OrderBy(column, sorting[i])
+		}
+
+		if provider.After != nil {
+			query.Where(keysetCondition(provider.Keyset, sorting, provider.After))
+			if pagination != nil {
+				query.Limit(pagination.Limit)
+			}
+			return query
+		}
+	} else {
+		sort := make(data.Sort, len(provider.Sort))
+		for key, s := range provider.Sort {
+			if column, ok := provider.Columns[key]; ok {
+				sort[column] = s
+			}
+		}
+		query.Sort(sort)
+	}
+
+	return query.Paginate(pagination)
+}
+
+// Condition of rows after values for ordering by columns:
+// (a > x) OR (a = x AND b > y) OR ...
+func keysetCondition(columns []string, sorting []data.Sorting, values []interface{}) Expr {
+	var or []Expr
+	for i := range columns {
+		if i >= len(values) {
+			break
+		}
+
+		and := make([]Expr, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, Eq{columns[j]: values[j]})
+		}
+		if sorting[i] == data.SortDesc {
+			and = append(and, Lt(columns[i], values[i]))
+		} else {
+			and = append(and, Gt(columns[i], values[i]))
+		}
+		or = append(or, And(and...))
+	}
+	return Or(or...)
+}
+
+// Row, that scans total of window function from the last column.
+type windowRow struct {
+	sql.Fetcher
+	total *int
+}
+
+func (row *windowRow) Scan(dest ...interface{}) error {
+	return row.Fetcher.Scan(append(dest, row.total)...)
+}
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Escape wildcards of pattern LIKE.
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
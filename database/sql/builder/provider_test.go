@@ -0,0 +1,142 @@
+package builder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/data"
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ data.Provider = new(Provider)
+
+type user struct {
+	id   int64
+	name string
+}
+
+func newTestProvider(t *testing.T) (*Provider, *sqltest.Mock) {
+	mock := sqltest.New()
+	db, err := sqltest.Open(nil, mock)
+	require.NoError(t, err)
+
+	return &Provider{
+		Scope: db,
+		Query: Select("id", "name").From("users").Where(Gt("id", 0)),
+		Mapper: func(row sql.Fetcher) (interface{}, error) {
+			u := new(user)
+			err := row.Scan(&u.id, &u.name)
+			return u, err
+		},
+		Columns: map[string]string{"name": "users.name", "id": "users.id"},
+	}, mock
+}
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	provider, mock := newTestProvider(t)
+	defer mock.Close()
+	defer provider.Scope.(sql.DB).Close(ctx)
+	provider.Sort = data.Sort{"name": data.SortDesc, "password": data.SortAsc}
+
+	mock.ExpectQuery(`SELECT COUNT(*) FROM "users" WHERE "id" > ?`).
+		WithArgs(0).
+		WillReturnRows(sqltest.NewRows("COUNT(*)").AddRow(5))
+	mock.ExpectQuery(`SELECT "id", "name" FROM "users" WHERE "id" > ? ORDER BY "users"."name" DESC LIMIT 2 OFFSET 2`).
+		WithArgs(0).
+		WillReturnRows(sqltest.NewRows("id", "name").AddRow(3, "carol").AddRow(4, "dave"))
+
+	total, err := provider.Total(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+
+	require.NoError(t, provider.Import(ctx, &data.Pagination{Offset: 2, Limit: 2}))
+	count, err := provider.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Nil(t, provider.Record())
+	require.NoError(t, provider.Next(ctx))
+	assert.Equal(t, &user{id: 3, name: "carol"}, provider.Record())
+	require.NoError(t, provider.Next(ctx))
+	assert.Equal(t, &user{id: 4, name: "dave"}, provider.Record())
+	assert.Equal(t, data.ErrRangeCheckError, provider.Next(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProvider_WindowTotal(t *testing.T) {
+	ctx := context.Background()
+	provider, mock := newTestProvider(t)
+	defer mock.Close()
+	defer provider.Scope.(sql.DB).Close(ctx)
+	provider.WindowTotal = true
+
+	mock.ExpectQuery(`SELECT "id", "name", COUNT(*) OVER () AS "_total" FROM "users" WHERE "id" > ? LIMIT 2`).
+		WithArgs(0).
+		WillReturnRows(sqltest.NewRows("id", "name", "_total").AddRow(1, "alice", 7).AddRow(2, "bob", 7))
+
+	require.NoError(t, provider.Import(ctx, &data.Pagination{Limit: 2}))
+	total, err := provider.Total(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 7, total)
+	assert.Len(t, provider.Records(), 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProvider_Keyset(t *testing.T) {
+	ctx := context.Background()
+	provider, mock := newTestProvider(t)
+	defer mock.Close()
+	defer provider.Scope.(sql.DB).Close(ctx)
+	provider.Keyset = []string{"users.name", "users.id"}
+	provider.Sort = data.Sort{"name": data.SortDesc}
+	provider.After = []interface{}{"bob", int64(2)}
+
+	mock.ExpectQuery(`SELECT "id", "name" FROM "users" WHERE ("id" > ?)`+
+		` AND (("users"."name" < ?) OR (("users"."name" = ?) AND ("users"."id" > ?)))`+
+		` ORDER BY "users"."name" DESC, "users"."id" LIMIT 5`).
+		WithArgs(0, "bob", "bob", 2).
+		WillReturnRows(sqltest.NewRows("id", "name").AddRow(8, "zed"))
+
+	require.NoError(t, provider.Import(ctx, &data.Pagination{Offset: 10, Limit: 5}))
+	assert.Len(t, provider.Records(), 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProvider_Filter(t *testing.T) {
+	ctx := context.Background()
+	provider, mock := newTestProvider(t)
+	defer mock.Close()
+	defer provider.Scope.(sql.DB).Close(ctx)
+	provider.Columns["city"] = "users.city_id"
+	provider.Columns["born"] = "users.born"
+	provider.SetSort(data.Sort{"name": data.SortAsc})
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider.SetFilter(data.Filter{
+		"name":     {Kind: data.FilterText, Value: "50%"},
+		"city":     {Kind: data.FilterSelect, Value: "2"},
+		"born":     {Kind: data.FilterDateRange, From: from, To: to},
+		"password": {Kind: data.FilterText, Value: "secret"},
+	})
+
+	const where = `WHERE ("id" > ?) AND ("users"."born" >= ?) AND ("users"."born" < ?)` +
+		` AND ("users"."city_id" = ?) AND ("users"."name" LIKE ? ESCAPE '\')`
+	mock.ExpectQuery(`SELECT COUNT(*) FROM "users" `+where).
+		WithArgs(0, from, to, "2", `%50\%%`).
+		WillReturnRows(sqltest.NewRows("COUNT(*)").AddRow(1))
+	mock.ExpectQuery(`SELECT "id", "name" FROM "users" `+where+` ORDER BY "users"."name" LIMIT 10`).
+		WithArgs(0, from, to, "2", `%50\%%`).
+		WillReturnRows(sqltest.NewRows("id", "name").AddRow(3, "carol"))
+
+	total, err := provider.Total(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.NoError(t, provider.Import(ctx, &data.Pagination{Limit: 10}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, `50\%`, escapeLike("50%"))
+}
@@ -0,0 +1,342 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/adverax/echo/data"
+	"github.com/adverax/echo/database/sql"
+)
+
+type join struct {
+	kind  string
+	table string
+	on    Expr
+}
+
+type order struct {
+	column  string
+	sorting data.Sorting
+}
+
+// SelectBuilder builds query SELECT.
+// It can be used as subquery in expressions.
+type SelectBuilder struct {
+	distinct  bool
+	columns   []string
+	from      string
+	fromQuery *SelectBuilder
+	alias     string
+	joins     []join
+	where     []Expr
+	groupBy   []string
+	having    []Expr
+	orderBy   []order
+	limit     int64
+	offset    int64
+	forUpdate bool
+}
+
+// Select creates builder of query SELECT.
+// If columns are not specified, all columns are selected.
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// Distinct selects only distinct rows.
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// Columns appends selected columns.
+func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// From sets source table with optional alias ("users u").
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = table
+	b.fromQuery = nil
+	return b
+}
+
+// FromQuery sets subquery as source with alias.
+func (b *SelectBuilder) FromQuery(query *SelectBuilder, alias string) *SelectBuilder {
+	b.fromQuery = query
+	b.alias = alias
+	return b
+}
+
+// Join appends INNER JOIN.
+func (b *SelectBuilder) Join(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "JOIN", table: table, on: on})
+	return b
+}
+
+// LeftJoin appends LEFT JOIN.
+func (b *SelectBuilder) LeftJoin(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "LEFT JOIN", table: table, on: on})
+	return b
+}
+
+// RightJoin appends RIGHT JOIN.
+func (b *SelectBuilder) RightJoin(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "RIGHT JOIN", table: table, on: on})
+	return b
+}
+
+// Where appends condition. Conditions are combined by AND.
+func (b *SelectBuilder) Where(expr Expr) *SelectBuilder {
+	if expr != nil {
+		b.where = append(b.where, expr)
+	}
+	return b
+}
+
+// GroupBy appends grouping columns.
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having appends condition of groups. Conditions are combined by AND.
+func (b *SelectBuilder) Having(expr Expr) *SelectBuilder {
+	if expr != nil {
+		b.having = append(b.having, expr)
+	}
+	return b
+}
+
+// OrderBy appends sorting by column. Column must be plain or qualified
+// name ("name", "u.name"), otherwise rendering fails with ErrNoColumn.
+func (b *SelectBuilder) OrderBy(column string, sorting data.Sorting) *SelectBuilder {
+	b.orderBy = append(b.orderBy, order{column: column, sorting: sorting})
+	return b
+}
+
+// Sort appends sorting by columns of map (in alphabetical order of columns,
+// because order of map is undefined).
+// Keys are used as names of columns, so keys from request must be
+// whitelisted (see Provider.Columns) to avoid sorting by arbitrary columns.
+func (b *SelectBuilder) Sort(s data.Sort) *SelectBuilder {
+	columns := make([]string, 0, len(s))
+	for column := range s {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		b.OrderBy(column, s[column])
+	}
+	return b
+}
+
+// Limit sets maximal count of rows (zero means unlimited).
+func (b *SelectBuilder) Limit(limit int64) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset sets count of skipped rows.
+func (b *SelectBuilder) Offset(offset int64) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// Paginate sets limit and offset from pagination (nil is ignored).
+func (b *SelectBuilder) Paginate(pagination *data.Pagination) *SelectBuilder {
+	if pagination != nil {
+		b.limit = pagination.Limit
+		b.offset = pagination.Offset
+	}
+	return b
+}
+
+// ForUpdate locks selected rows.
+func (b *SelectBuilder) ForUpdate() *SelectBuilder {
+	b.forUpdate = true
+	return b
+}
+
+// Count creates query of count of rows, that are selected by
+// current query without regard to sorting and pagination.
+func (b *SelectBuilder) Count() *SelectBuilder {
+	if b.distinct || len(b.groupBy) != 0 || len(b.having) != 0 {
+		inner := b.clone()
+		inner.orderBy = nil
+		inner.limit = 0
+		inner.offset = 0
+		inner.forUpdate = false
+		return Select("COUNT(*)").FromQuery(inner, "t")
+	}
+
+	res := b.clone()
+	res.columns = []string{"COUNT(*)"}
+	res.orderBy = nil
+	res.limit = 0
+	res.offset = 0
+	res.forUpdate = false
+	return res
+}
+
+func (b *SelectBuilder) clone() *SelectBuilder {
+	res := *b
+	res.columns = append([]string(nil), b.columns...)
+	res.joins = append([]join(nil), b.joins...)
+	res.where = append([]Expr(nil), b.where...)
+	res.groupBy = append([]string(nil), b.groupBy...)
+	res.having = append([]Expr(nil), b.having...)
+	res.orderBy = append([]order(nil), b.orderBy...)
+	return &res
+}
+
+func (b *SelectBuilder) ToSQL(adapter sql.Adapter) (string, []interface{}, error) {
+	w := &writer{adapter: adapter}
+	b.render(w)
+	return w.result()
+}
+
+// Subquery is rendered in parentheses.
+func (b *SelectBuilder) build(w *writer) {
+	w.write("(")
+	b.render(w)
+	w.write(")")
+}
+
+func (b *SelectBuilder) render(w *writer) {
+	w.write("SELECT ")
+	if b.distinct {
+		w.write("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else {
+		w.columns(b.columns)
+	}
+
+	switch {
+	case b.fromQuery != nil:
+		w.write(" FROM ")
+		b.fromQuery.build(w)
+		w.write(" " + w.adapter.QuoteIdentifier(b.alias))
+	case b.from != "":
+		w.write(" FROM ")
+		w.column(b.from)
+	}
+
+	for _, j := range b.joins {
+		w.write(" " + j.kind + " ")
+		w.column(j.table)
+		if j.on != nil {
+			w.write(" ON ")
+			j.on.build(w)
+		}
+	}
+
+	if len(b.where) != 0 {
+		w.write(" WHERE ")
+		And(b.where...).build(w)
+	}
+
+	if len(b.groupBy) != 0 {
+		w.write(" GROUP BY ")
+		w.columns(b.groupBy)
+	}
+
+	if len(b.having) != 0 {
+		w.write(" HAVING ")
+		And(b.having...).build(w)
+	}
+
+	if len(b.orderBy) != 0 {
+		w.write(" ORDER BY ")
+		for i, o := range b.orderBy {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.sortColumn(o.column)
+			if o.sorting == data.SortDesc {
+				w.write(" DESC")
+			}
+		}
+	}
+
+	switch {
+	case b.limit > 0:
+		w.write(" LIMIT " + strconv.FormatInt(b.limit, 10))
+	case b.offset > 0:
+		// Offset without limit is not supported by all databases
+		w.write(" LIMIT 9223372036854775807")
+	}
+	if b.offset > 0 {
+		w.write(" OFFSET " + strconv.FormatInt(b.offset, 10))
+	}
+
+	if b.forUpdate {
+		w.write(" FOR UPDATE")
+	}
+}
+
+// Query executes query within scope.
+func (b *SelectBuilder) Query(ctx context.Context, scope sql.Scope) (sql.Rows, error) {
+	query, args, err := b.ToSQL(scope.Adapter())
+	if err != nil {
+		return nil, err
+	}
+	return scope.QueryContext(ctx, query, args...)
+}
+
+// QueryRow executes query within scope and returns the first row.
+func (b *SelectBuilder) QueryRow(ctx context.Context, scope sql.Scope) sql.Row {
+	query, args, err := b.ToSQL(scope.Adapter())
+	if err != nil {
+		return errRow{err: err}
+	}
+	return scope.QueryRowContext(ctx, query, args...)
+}
+
+// Select executes query and scans all rows into dest (see sql.ScanAll).
+func (b *SelectBuilder) Select(ctx context.Context, scope sql.Scope, dest interface{}) error {
+	query, args, err := b.ToSQL(scope.Adapter())
+	if err != nil {
+		return err
+	}
+	return sql.Select(ctx, scope, dest, query, args...)
+}
+
+// Get executes query and scans the first row into dest (see sql.Get).
+func (b *SelectBuilder) Get(ctx context.Context, scope sql.Scope, dest interface{}) error {
+	query, args, err := b.ToSQL(scope.Adapter())
+	if err != nil {
+		return err
+	}
+	return sql.Get(ctx, scope, dest, query, args...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (row errRow) Scan(dest ...interface{}) error {
+	return row.err
+}
@@ -0,0 +1,410 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adverax/echo/generic"
+)
+
+// BulkLoader is an optional interface of Adapter, that supports bulk operations.
+type BulkLoader interface {
+	// BulkLimits returns limits of single statement:
+	// size in bytes and count of placeholders (zero means unlimited).
+	BulkLimits() (packet int, params int)
+	// BulkLoad inserts rows by fast path of database (LOAD DATA, COPY).
+	// Returns ErrBulkLoadUnsupported, if fast path is not available.
+	BulkLoad(ctx context.Context, tx Tx, table string, columns []string, rows Arrays) (int64, error)
+}
+
+// BulkOptions defines the options of bulk insert.
+type BulkOptions struct {
+	// Maximum size of statement in bytes.
+	// Optional. Default value is limit of adapter or 1MB.
+	MaxPacket int
+	// Maximum count of placeholders in statement.
+	// Optional. Default value is limit of adapter or 999.
+	MaxParams int
+	// Maximum count of rows in statement.
+	// Optional. Default value 1000.
+	MaxRows int
+	// Use fast path of adapter (LOAD DATA LOCAL, COPY), if it is supported.
+	// Optional. Default value false.
+	LoadData bool
+}
+
+// BulkInsert inserts rows into table within single transaction.
+// Rows are chunked into multi-VALUES statements below the limits of adapter.
+// Returns count of inserted rows.
+// Example:
+//   n, err := sql.BulkInsert(ctx, db, "users", []string{"name", "age"}, sql.Arrays{
+//       {"Bob", 30},
+//       {"Alice", 25},
+//   }, sql.BulkOptions{})
+func BulkInsert(
+	ctx context.Context,
+	scope Scope,
+	table string,
+	columns []string,
+	rows Arrays,
+	options BulkOptions,
+) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if len(columns) == 0 {
+		return 0, ErrEmptyList
+	}
+
+	adapter := scope.Adapter()
+	loader, _ := adapter.(BulkLoader)
+	packet, params := 1<<20, 999
+	if loader != nil {
+		packet, params = loader.BulkLimits()
+	}
+	if options.MaxPacket == 0 || packet != 0 && options.MaxPacket > packet {
+		options.MaxPacket = packet
+	}
+	if options.MaxParams == 0 || params != 0 && options.MaxParams > params {
+		options.MaxParams = params
+	}
+	if options.MaxRows == 0 {
+		options.MaxRows = 1000
+	}
+
+	var total int64
+	err := scope.Transact(ctx, nil, func(tx Tx) error {
+		total = 0
+		if options.LoadData && loader != nil {
+			n, err := loader.BulkLoad(ctx, tx, table, columns, rows)
+			if err != ErrBulkLoadUnsupported {
+				total = n
+				return err
+			}
+		}
+
+		// Every attempt of transaction starts from the full input.
+		b := newBulkBuilder(adapter, table, columns, options)
+		rest := rows
+		for len(rest) != 0 {
+			var query string
+			var args []interface{}
+			var err error
+			query, args, rest, err = b.build(rest)
+			if err != nil {
+				return err
+			}
+			res, err := tx.ExecContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Builder of multi-VALUES statements.
+type bulkBuilder struct {
+	adapter Adapter
+	prefix  string
+	width   int // Count of columns
+	options BulkOptions
+	buf     strings.Builder
+}
+
+func newBulkBuilder(adapter Adapter, table string, columns []string, options BulkOptions) *bulkBuilder {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = adapter.QuoteIdentifier(column)
+	}
+
+	return &bulkBuilder{
+		adapter: adapter,
+		prefix:  "INSERT INTO " + adapter.QuoteIdentifier(table) + " (" + strings.Join(names, ", ") + ") VALUES ",
+		width:   len(columns),
+		options: options,
+	}
+}
+
+// Build statement for the head of rows. Returns rest of rows.
+func (b *bulkBuilder) build(rows Arrays) (string, []interface{}, Arrays, error) {
+	b.buf.Reset()
+	b.buf.WriteString(b.prefix)
+	size := b.buf.Len()
+
+	var args []interface{}
+	var count int
+	for count < len(rows) && count < b.options.MaxRows {
+		row := rows[count]
+		if len(row) != b.width {
+			return "", nil, nil, fmt.Errorf("sql: bulk insert: row has %d values instead of %d", len(row), b.width)
+		}
+
+		var tuple strings.Builder
+		if count != 0 {
+			tuple.WriteString(", ")
+		}
+		tuple.WriteByte('(')
+		rowSize := 0
+		for i, value := range row {
+			if i != 0 {
+				tuple.WriteString(", ")
+			}
+			tuple.WriteString(b.adapter.Placeholder(len(args) + i + 1))
+			rowSize += valueSize(value)
+		}
+		tuple.WriteByte(')')
+		rowSize += tuple.Len()
+
+		if count != 0 {
+			if b.options.MaxParams != 0 && len(args)+len(row) > b.options.MaxParams {
+				break
+			}
+			if b.options.MaxPacket != 0 && size+rowSize > b.options.MaxPacket {
+				break
+			}
+		}
+
+		b.buf.WriteString(tuple.String())
+		size += rowSize
+		args = append(args, row...)
+		count++
+	}
+
+	return b.buf.String(), args, rows[count:], nil
+}
+
+// Approximate size of value in the packet.
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v) + 4
+	case []byte:
+		return len(v) + 4
+	default:
+		return 12
+	}
+}
+
+// Fast path of MySQL (LOAD DATA LOCAL INFILE).
+// It requires handler of readers of driver and local_infile=1 on the server.
+var mysqlReaders struct {
+	sync.Mutex
+	register   func(name string, handler func() io.Reader)
+	deregister func(name string)
+	seq        int64
+}
+
+// EnableMySQLLoadData enables fast path of BulkInsert for MySQL.
+// Arguments are functions RegisterReaderHandler and DeregisterReaderHandler
+// of driver github.com/go-sql-driver/mysql.
+func EnableMySQLLoadData(
+	register func(name string, handler func() io.Reader),
+	deregister func(name string),
+) {
+	mysqlReaders.Lock()
+	defer mysqlReaders.Unlock()
+	mysqlReaders.register = register
+	mysqlReaders.deregister = deregister
+}
+
+func (adapter *mySqlAdater) BulkLimits() (packet int, params int) {
+	// Default max_allowed_packet is 4MB
+	return 4<<20 - 1024, 65535
+}
+
+func (adapter *mySqlAdater) BulkLoad(ctx context.Context, tx Tx, table string, columns []string, rows Arrays) (int64, error) {
+	mysqlReaders.Lock()
+	register, deregister := mysqlReaders.register, mysqlReaders.deregister
+	mysqlReaders.Unlock()
+	if register == nil {
+		return 0, ErrBulkLoadUnsupported
+	}
+
+	name := "bulk" + strconv.FormatInt(atomic.AddInt64(&mysqlReaders.seq, 1), 10)
+	var reader *io.PipeReader
+	register(name, func() io.Reader {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeLoadData(pw, rows))
+		}()
+		reader = pr
+		return pr
+	})
+	defer func() {
+		deregister(name)
+		if reader != nil {
+			// Release writer, if driver has not read all data
+			reader.Close()
+		}
+	}()
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = adapter.QuoteIdentifier(column)
+	}
+	query := "LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE " + adapter.QuoteIdentifier(table) +
+		" CHARACTER SET utf8mb4 FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' ESCAPED BY '\\\\'" +
+		" LINES TERMINATED BY '\\n' (" + strings.Join(names, ", ") + ")"
+	res, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Write rows in format of LOAD DATA.
+func writeLoadData(w io.Writer, rows Arrays) error {
+	buf := bufio.NewWriter(w)
+	for _, row := range rows {
+		for i, value := range row {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			switch v := value.(type) {
+			case nil:
+				buf.WriteString(`\N`)
+			case bool:
+				if v {
+					buf.WriteByte('1')
+				} else {
+					buf.WriteByte('0')
+				}
+			case time.Time:
+				buf.WriteString(v.Format("2006-01-02 15:04:05.999999"))
+			case []byte:
+				writeLoadDataString(buf, string(v))
+			case string:
+				writeLoadDataString(buf, v)
+			default:
+				s, err := loadDataValue(value)
+				if err != nil {
+					return err
+				}
+				writeLoadDataString(buf, s)
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Flush()
+}
+
+func loadDataValue(value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		value = v
+	}
+	s, _ := generic.ConvertToString(value)
+	return s, nil
+}
+
+var loadDataEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x00", `\0`,
+)
+
+func writeLoadDataString(buf *bufio.Writer, s string) {
+	buf.WriteByte('"')
+	_, _ = loadDataEscaper.WriteString(buf, s)
+	buf.WriteByte('"')
+}
+
+func (adapter *postgresAdapter) BulkLimits() (packet int, params int) {
+	return 0, 65535
+}
+
+// Fast path of driver github.com/lib/pq (COPY FROM STDIN).
+func (adapter *postgresAdapter) BulkLoad(ctx context.Context, tx Tx, table string, columns []string, rows Arrays) (int64, error) {
+	trans := rawTx(tx)
+	if !adapter.copy || trans == nil {
+		return 0, ErrBulkLoadUnsupported
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = adapter.QuoteIdentifier(column)
+	}
+	query := "COPY " + adapter.QuoteIdentifier(table) + " (" + strings.Join(names, ", ") + ") FROM STDIN"
+	stmt, err := trans.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (adapter *sqliteAdapter) BulkLimits() (packet int, params int) {
+	// Defaults of SQLITE_MAX_SQL_LENGTH and SQLITE_MAX_VARIABLE_NUMBER (before 3.32)
+	return 1000000000, 999
+}
+
+func (adapter *sqliteAdapter) BulkLoad(ctx context.Context, tx Tx, table string, columns []string, rows Arrays) (int64, error) {
+	return 0, ErrBulkLoadUnsupported
+}
+
+// Underlying transaction of driver.
+func rawTx(t Tx) *sql.Tx {
+	switch v := t.(type) {
+	case *tx:
+		return v.trans
+	case *txx:
+		return v.trans
+	case *profilerTx:
+		return rawTx(v.Tx)
+	default:
+		return nil
+	}
+}
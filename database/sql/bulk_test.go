@@ -0,0 +1,174 @@
+package sql_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	if err := sql.RegisterFakeAdapters(sqltest.DriverName); err != nil {
+		panic(err)
+	}
+}
+
+// Open fake database with adapter of driver (empty for adapter of sqltest).
+func openBulk(t *testing.T, driver string) (sql.DB, *sqltest.Mock) {
+	mock := sqltest.New()
+	dsc := sqltest.NewDSC(mock)
+	if driver != "" {
+		dsc.Driver = sqltest.DriverName + "-" + driver
+	}
+	db, err := sql.Open(dsc, nil)
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestBulkInsert(t *testing.T) {
+	db, mock := openBulk(t, "postgres")
+	defer mock.Close()
+	defer db.Close(context.Background())
+
+	const query = `INSERT INTO "users" ("name", "age") VALUES ($1, $2), ($3, $4)`
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("Bob", 30, "Alice", 25).WillReturnResult(0, 2)
+	mock.ExpectExec(query).WithArgs("Tom", 40, "Ann", 20).WillReturnResult(0, 2)
+	mock.ExpectExec(`INSERT INTO "users" ("name", "age") VALUES ($1, $2)`).WithArgs("Sam", 50).WillReturnResult(0, 1)
+	mock.ExpectCommit()
+
+	rows := sql.Arrays{{"Bob", 30}, {"Alice", 25}, {"Tom", 40}, {"Ann", 20}, {"Sam", 50}}
+	n, err := sql.BulkInsert(context.Background(), db, "users", []string{"name", "age"}, rows, sql.BulkOptions{MaxParams: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkInsert_Deadlock(t *testing.T) {
+	db, mock := openBulk(t, "")
+	defer mock.Close()
+	defer db.Close(context.Background())
+
+	// Every attempt starts from the first row
+	const query = `INSERT INTO "users" ("name") VALUES (?)`
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("Bob").WillReturnResult(0, 1)
+	mock.ExpectExec(query).WithArgs("Alice").WillReturnError(sqltest.ErrDeadlock)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("Bob").WillReturnResult(0, 1)
+	mock.ExpectExec(query).WithArgs("Alice").WillReturnResult(0, 1)
+	mock.ExpectExec(query).WithArgs("Tom").WillReturnResult(0, 1)
+	mock.ExpectCommit()
+
+	rows := sql.Arrays{{"Bob"}, {"Alice"}, {"Tom"}}
+	n, err := sql.BulkInsert(context.Background(), db, "users", []string{"name"}, rows, sql.BulkOptions{MaxRows: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkInsert_Limits(t *testing.T) {
+	db, mock := openBulk(t, "")
+	defer mock.Close()
+	defer db.Close(context.Background())
+
+	long := strings.Repeat("x", 100)
+	const query = `INSERT INTO "logs" ("message") VALUES (?), (?)`
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(long, long).WillReturnResult(0, 2)
+	mock.ExpectExec(query).WithArgs(long, "y").WillReturnResult(0, 2)
+	mock.ExpectCommit()
+
+	rows := sql.Arrays{{long}, {long}, {long}, {"y"}}
+	n, err := sql.BulkInsert(context.Background(), db, "logs", []string{"message"}, rows, sql.BulkOptions{MaxPacket: 300, MaxRows: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Row of wrong width
+	mock.Reset()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	_, err = sql.BulkInsert(context.Background(), db, "logs", []string{"message"}, sql.Arrays{{1, 2}}, sql.BulkOptions{})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkInsert_Copy(t *testing.T) {
+	db, mock := openBulk(t, "postgres")
+	defer mock.Close()
+	defer db.Close(context.Background())
+
+	const query = `COPY "users" ("name", "age") FROM STDIN`
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs("Bob", 30)
+	mock.ExpectExec(query).WithArgs("Alice", nil)
+	mock.ExpectExec(query).WithArgs().WillReturnResult(0, 2)
+	mock.ExpectCommit()
+
+	rows := sql.Arrays{{"Bob", 30}, {"Alice", nil}}
+	n, err := sql.BulkInsert(context.Background(), db, "users", []string{"name", "age"}, rows, sql.BulkOptions{LoadData: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Contains(t, mock.Log(), "PREPARE "+query)
+}
+
+func TestBulkInsert_LoadData(t *testing.T) {
+	db, mock := openBulk(t, "mysql")
+	defer mock.Close()
+	defer db.Close(context.Background())
+
+	rows := sql.Arrays{
+		{"Bob \"The\" Builder", 30, nil},
+		{"back\\slash,\nline", 25, time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	// Fast path is disabled without handler of readers
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `users` (`name`, `age`, `born`) VALUES (?, ?, ?), (?, ?, ?)").WillReturnResult(0, 2)
+	mock.ExpectCommit()
+	n, err := sql.BulkInsert(context.Background(), db, "users", []string{"name", "age", "born"}, rows, sql.BulkOptions{LoadData: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Handler is called by driver at execution of statement
+	readers := make(map[string]func() io.Reader)
+	var data []byte
+	sql.EnableMySQLLoadData(
+		func(name string, handler func() io.Reader) {
+			readers[name] = handler
+			var err error
+			data, err = ioutil.ReadAll(handler())
+			require.NoError(t, err)
+			mock.ExpectExec("LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE `users`" +
+				" CHARACTER SET utf8mb4 FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' ESCAPED BY '\\\\'" +
+				" LINES TERMINATED BY '\\n' (`name`, `age`, `born`)").WillReturnResult(0, 2)
+			mock.ExpectCommit()
+		},
+		func(name string) { delete(readers, name) },
+	)
+	defer sql.EnableMySQLLoadData(nil, nil)
+
+	mock.Reset()
+	mock.ExpectBegin()
+	n, err = sql.BulkInsert(context.Background(), db, "users", []string{"name", "age", "born"}, rows, sql.BulkOptions{LoadData: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t,
+		"\"Bob \\\"The\\\" Builder\",\"30\",\\N\n"+
+			"\"back\\\\slash,\\nline\",\"25\",2019-05-01 10:00:00\n",
+		string(data),
+	)
+	assert.Empty(t, readers)
+}
@@ -0,0 +1,272 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"net"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ReplicaPolicy is policy of replica selection for reading queries.
+type ReplicaPolicy int
+
+const (
+	PolicyRoundRobin   ReplicaPolicy = iota // Replicas are used in turn
+	PolicyWeighted                          // Replicas are used proportionally to DSN.Weight
+	PolicyLeastLatency                      // Replica with least latency of ping is used
+)
+
+// Cluster is database with master and replicas.
+type Cluster interface {
+	// Check health of all nodes. Failed replicas are ejected and recovered
+	// replicas are re-admitted. Returns error, if master is not available.
+	Check(ctx context.Context) error
+	// Get status of all nodes (first node is master).
+	Nodes() []NodeStatus
+}
+
+// NodeStatus is status of cluster node.
+type NodeStatus struct {
+	Master  bool
+	Healthy bool
+	Weight  int
+	Latency time.Duration // Average latency of ping
+}
+
+// AsCluster extracts cluster from database (including wrapped databases).
+func AsCluster(db DB) (Cluster, bool) {
+	res, ok := db.Interface(func(v interface{}) interface{} {
+		if c, ok := v.(Cluster); ok {
+			return c
+		}
+		return nil
+	})
+	if !ok {
+		return nil, false
+	}
+	return res.(Cluster), true
+}
+
+// Health of cluster node
+type health struct {
+	healthy int32 // Node is available (1) or ejected (0)
+	latency int64 // Moving average of ping latency (nanoseconds)
+	weight  int
+}
+
+func (h *health) isHealthy() bool {
+	return atomic.LoadInt32(&h.healthy) != 0
+}
+
+func (h *health) eject() {
+	atomic.StoreInt32(&h.healthy, 0)
+}
+
+// Register successful ping and re-admit node.
+func (h *health) observe(latency time.Duration) {
+	old := atomic.LoadInt64(&h.latency)
+	val := int64(latency)
+	if old != 0 {
+		val = (old*7 + val) / 8
+	}
+	atomic.StoreInt64(&h.latency, val)
+	atomic.StoreInt32(&h.healthy, 1)
+}
+
+func newHealth(weight int) *health {
+	if weight <= 0 {
+		weight = 1
+	}
+	return &health{
+		healthy: 1,
+		weight:  weight,
+	}
+}
+
+func (db *database2) Check(ctx context.Context) error {
+	return scatter(
+		len(db.nodes),
+		func(i int) error {
+			started := time.Now()
+			err := db.nodes[i].db.PingContext(ctx)
+			if err != nil {
+				if i == 0 {
+					return err
+				}
+				db.health[i].eject()
+				return nil
+			}
+			db.health[i].observe(time.Since(started))
+			return nil
+		},
+	)
+}
+
+func (db *database2) Nodes() []NodeStatus {
+	res := make([]NodeStatus, len(db.nodes))
+	for i, h := range db.health {
+		res[i] = NodeStatus{
+			Master:  i == 0,
+			Healthy: h.isHealthy(),
+			Weight:  h.weight,
+			Latency: time.Duration(atomic.LoadInt64(&h.latency)),
+		}
+	}
+	return res
+}
+
+// Get index of node for reading query.
+// Master is used, when there are no healthy replicas or context requires
+// reading own writes.
+func (db *database2) slave(ctx context.Context) int {
+	m := len(db.nodes) - 1
+	if m < 1 || isSticky(ctx) {
+		return 0
+	}
+
+	n := atomic.AddUint64(&db.count, 1)
+	switch db.policy {
+	case PolicyWeighted:
+		total := 0
+		for i := 1; i <= m; i++ {
+			if db.health[i].isHealthy() {
+				total += db.health[i].weight
+			}
+		}
+		if total == 0 {
+			return 0
+		}
+		k := int(n % uint64(total))
+		for i := 1; i <= m; i++ {
+			if h := db.health[i]; h.isHealthy() {
+				if k < h.weight {
+					return i
+				}
+				k -= h.weight
+			}
+		}
+		return 0
+	case PolicyLeastLatency:
+		// Replicas with equal latency are used in turn
+		best := 0
+		var latency int64
+		for j := 0; j < m; j++ {
+			i := 1 + int((n+uint64(j))%uint64(m))
+			if h := db.health[i]; h.isHealthy() {
+				l := atomic.LoadInt64(&h.latency)
+				if best == 0 || l < latency {
+					best = i
+					latency = l
+				}
+			}
+		}
+		return best
+	default:
+		for j := 0; j < m; j++ {
+			i := 1 + int((n+uint64(j))%uint64(m))
+			if db.health[i].isHealthy() {
+				return i
+			}
+		}
+		return 0
+	}
+}
+
+// ConnectionChecker is optional interface of Adapter, that recognizes
+// loss of connection by specific codes of database errors.
+type ConnectionChecker interface {
+	IsConnectionLost(err error) bool
+}
+
+// Eject replica after connection failure.
+func (db *database2) failed(i int, err error) {
+	if i != 0 && isConnectionLost(db.adapter, err) {
+		db.health[i].eject()
+	}
+}
+
+// Check, that error is caused by loss of connection to database server.
+func isConnectionLost(adapter Adapter, err error) bool {
+	switch err {
+	case nil, context.Canceled, context.DeadlineExceeded:
+		return false
+	case driver.ErrBadConn, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+
+	// Errno satisfies net.Error, so it is checked first
+	switch e := err.(type) {
+	case syscall.Errno:
+		return isConnectionErrno(e)
+	case *os.SyscallError:
+		return isConnectionErrno(e.Err)
+	case net.Error:
+		return true
+	}
+
+	if checker, ok := adapter.(ConnectionChecker); ok {
+		return checker.IsConnectionLost(err)
+	}
+	return false
+}
+
+func isConnectionErrno(err error) bool {
+	switch err {
+	case syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE:
+		return true
+	default:
+		return false
+	}
+}
+
+type stickiness struct {
+	written int32
+}
+
+type stickinessKey struct{}
+
+// WithReadYourWrites returns context, in which reading queries are routed
+// to master after the first write, so own writes are visible despite of
+// replication lag. Typically used for each request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickinessKey{}, new(stickiness))
+}
+
+// WithMaster returns context, in which all reading queries are routed to master.
+func WithMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickinessKey{}, &stickiness{written: 1})
+}
+
+func markWritten(ctx context.Context) {
+	if s, ok := ctx.Value(stickinessKey{}).(*stickiness); ok {
+		atomic.StoreInt32(&s.written, 1)
+	}
+}
+
+func isSticky(ctx context.Context) bool {
+	if s, ok := ctx.Value(stickinessKey{}).(*stickiness); ok {
+		return atomic.LoadInt32(&s.written) != 0
+	}
+	return false
+}
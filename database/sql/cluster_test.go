@@ -0,0 +1,233 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Open cluster of fake nodes (the first node is master).
+func openCluster(t *testing.T, policy sql.ReplicaPolicy, weights ...int) (sql.DB, []*sqltest.Mock) {
+	nodes := []*sqltest.Mock{sqltest.New()}
+	for range weights {
+		nodes = append(nodes, sqltest.New())
+	}
+
+	dsc := sqltest.NewDSC(nodes...)
+	dsc.Policy = policy
+	for i, w := range weights {
+		dsc.DSN[i+1].Weight = w
+	}
+
+	db, err := sql.Open(dsc, nil)
+	require.NoError(t, err)
+	return db, nodes
+}
+
+func closeCluster(db sql.DB, nodes []*sqltest.Mock) {
+	_ = db.Close(context.Background())
+	for _, node := range nodes {
+		node.Close()
+	}
+}
+
+func TestCluster_Slave(t *testing.T) {
+	type Test struct {
+		policy  sql.ReplicaPolicy
+		weights []int
+		ejected []int
+		expect  map[int]int // Node index -> count of selections
+	}
+
+	tests := map[string]Test{
+		"Round robin": {
+			policy:  sql.PolicyRoundRobin,
+			weights: []int{0, 0},
+			expect:  map[int]int{1: 6, 2: 6},
+		},
+		"Round robin with ejected": {
+			policy:  sql.PolicyRoundRobin,
+			weights: []int{0, 0},
+			ejected: []int{1},
+			expect:  map[int]int{2: 12},
+		},
+		"Weighted": {
+			policy:  sql.PolicyWeighted,
+			weights: []int{1, 3},
+			expect:  map[int]int{1: 3, 2: 9},
+		},
+		"All ejected": {
+			policy:  sql.PolicyWeighted,
+			weights: []int{1, 1},
+			ejected: []int{1, 2},
+			expect:  map[int]int{0: 12},
+		},
+		"Least latency": {
+			policy:  sql.PolicyLeastLatency,
+			weights: []int{0, 0},
+			expect:  map[int]int{2: 12},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, nodes := openCluster(t, test.policy, test.weights...)
+			defer closeCluster(db, nodes)
+
+			sql.ObserveNode(db, 1, 20)
+			sql.ObserveNode(db, 2, 10)
+			for _, i := range test.ejected {
+				sql.EjectNode(db, i)
+			}
+
+			res := make(map[int]int)
+			for i := 0; i < 12; i++ {
+				res[sql.SlaveOf(context.Background(), db)]++
+			}
+			assert.Equal(t, test.expect, res)
+		})
+	}
+}
+
+func TestCluster_Check(t *testing.T) {
+	db, nodes := openCluster(t, sql.PolicyRoundRobin, 0, 0)
+	defer closeCluster(db, nodes)
+
+	assert.Contains(t, []string{nodes[1].Name(), nodes[2].Name()}, db.Slave().DSC().DSN[0].Host)
+	assert.Equal(t, nodes[0].Name(), db.Master().DSC().DSN[0].Host)
+	assert.Equal(t, sql.PrimaryDatabase, db.Master().DbId())
+	assert.NotNil(t, db.Master().Adapter())
+
+	cluster, ok := sql.AsCluster(db)
+	require.True(t, ok)
+
+	nodes[1].SetDown(true)
+	require.NoError(t, cluster.Check(context.Background()))
+	status := cluster.Nodes()
+	assert.True(t, status[0].Master)
+	assert.False(t, status[1].Healthy)
+	assert.True(t, status[2].Healthy)
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, 2, sql.SlaveOf(context.Background(), db))
+	}
+
+	nodes[1].SetDown(false)
+	require.NoError(t, cluster.Check(context.Background()))
+	assert.True(t, cluster.Nodes()[1].Healthy)
+
+	nodes[0].SetDown(true)
+	assert.Equal(t, sqltest.ErrConnLost, cluster.Check(context.Background()))
+}
+
+func TestCluster_Eject(t *testing.T) {
+	type Test struct {
+		err     error
+		ejected bool
+	}
+
+	tests := map[string]Test{
+		"EOF":      {err: io.EOF, ejected: true},
+		"Reset":    {err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, ejected: true},
+		"Refused":  {err: syscall.ECONNREFUSED, ejected: true},
+		"Canceled": {err: context.Canceled, ejected: false},
+		"Other":    {err: errors.New("syntax error"), ejected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, nodes := openCluster(t, sql.PolicyRoundRobin, 0)
+			defer closeCluster(db, nodes)
+
+			nodes[1].ExpectQuery("SELECT 1").WillReturnError(test.err)
+			_, err := db.QueryContext(context.Background(), "SELECT 1")
+			assert.Error(t, err)
+
+			cluster, ok := sql.AsCluster(db)
+			require.True(t, ok)
+			assert.Equal(t, !test.ejected, cluster.Nodes()[1].Healthy)
+		})
+	}
+}
+
+func TestCluster_CloseNode(t *testing.T) {
+	db, nodes := openCluster(t, sql.PolicyRoundRobin, 0)
+	for _, node := range nodes {
+		defer node.Close()
+	}
+
+	ctx := context.Background()
+	require.NoError(t, db.Master().Close(ctx))
+	require.NoError(t, db.Slave().Close(ctx))
+	require.NoError(t, db.Master().Ping())
+	assert.NotPanics(t, func() {
+		assert.NoError(t, db.Close(ctx))
+	})
+}
+
+func TestReadYourWrites(t *testing.T) {
+	db, nodes := openCluster(t, sql.PolicyRoundRobin, 0)
+	defer closeCluster(db, nodes)
+
+	ctx := sql.WithReadYourWrites(context.Background())
+	assert.Equal(t, 1, sql.SlaveOf(ctx, db))
+	sql.MarkWritten(ctx)
+	assert.Equal(t, 0, sql.SlaveOf(ctx, db))
+	assert.Equal(t, 0, sql.SlaveOf(sql.WithMaster(context.Background()), db))
+	assert.Equal(t, 1, sql.SlaveOf(context.Background(), db))
+}
+
+func TestCluster_Prepare(t *testing.T) {
+	db, nodes := openCluster(t, sql.PolicyRoundRobin, 0)
+	defer closeCluster(db, nodes)
+	master, replica := nodes[0], nodes[1]
+
+	const query = "SELECT node"
+	master.ExpectExec(query).WithArgs(1)
+	master.ExpectQuery(query).WillReturnRows(sqltest.NewRows("node").AddRow("master"))
+	replica.ExpectQuery(query).WillReturnRows(sqltest.NewRows("node").AddRow("replica")).Times(2)
+
+	node := func(stmt sql.Stmt) string {
+		var name string
+		require.NoError(t, stmt.QueryRowContext(context.Background(), 1).Scan(&name))
+		return name
+	}
+
+	// Failed replica doesn't prevent writing
+	replica.SetDown(true)
+	stmt, err := db.PrepareContext(context.Background(), query)
+	replica.SetDown(false)
+	require.NoError(t, err)
+	defer stmt.Close()
+	_, err = stmt.ExecContext(context.Background(), 1)
+	require.NoError(t, err)
+
+	// Replica prepares statement at first use
+	assert.NotContains(t, replica.Log(), "PREPARE "+query)
+	assert.Equal(t, "replica", node(stmt))
+	assert.Contains(t, replica.Log(), "PREPARE "+query)
+
+	// Replica, that was ejected at preparing, is used after re-admission
+	sql.EjectNode(db, 1)
+	stmt2, err := db.PrepareContext(context.Background(), query)
+	require.NoError(t, err)
+	defer stmt2.Close()
+	assert.Equal(t, "master", node(stmt2))
+	sql.ObserveNode(db, 1, 10)
+	assert.Equal(t, "replica", node(stmt2))
+
+	assert.NoError(t, master.ExpectationsWereMet())
+	assert.NoError(t, replica.ExpectationsWereMet())
+
+	// Failure of master is reported
+	master.SetDown(true)
+	_, err = db.PrepareContext(context.Background(), "SELECT other")
+	assert.Error(t, err)
+}
@@ -40,6 +40,7 @@ type DSN struct {
 	Username string            `json:"username"` // User name
 	Password string            `json:"password"` // User password
 	Params   map[string]string `json:"params"`   // Other parameters
+	Weight   int               `json:"weight"`   // Weight of replica for PolicyWeighted
 }
 
 func (dsn *DSN) AddParam(key string, value string) {
@@ -81,9 +82,13 @@ func (dsn *DSN) openSQL(driver string) (*sql.DB, Adapter, error) {
 
 // DataSource nodes cluster (first node is master)
 type DSC struct {
-	Driver string `json:"Driver"`
-	DbId   DbId   `json:"-"`
-	DSN    []*DSN `json:"dsn"`
+	Driver string        `json:"Driver"`
+	DbId   DbId          `json:"-"`
+	DSN    []*DSN        `json:"dsn"`
+	Policy ReplicaPolicy `json:"policy"` // Policy of replica selection
+	// Capacity of cache of prepared statements (zero disables cache).
+	// Queries with arguments are executed through the cache.
+	StmtCache int `json:"stmtCache"`
 }
 
 func (dsc *DSC) Primary() DSN {
@@ -131,6 +136,12 @@ type Adapter interface {
 	LockGlobal(ctx context.Context, tx Tx, latch string, timeout int) error
 	// Release local lock
 	UnlockGlobal(ctx context.Context, tx Tx, latch string) error
+	// Get placeholder of n-th (starting from 1) argument of query
+	Placeholder(n int) string
+	// Quote identifier (name of table or column)
+	QuoteIdentifier(name string) string
+	// Make query of execution plan for query
+	ExplainQuery(query string) string
 }
 
 type Activator func(dsc DSC) (DB, error)
@@ -151,6 +162,11 @@ func Register(driver string, adapter Adapter) {
 	adapters[driver] = adapter
 }
 
+// FindAdapter returns adapter, registered for driver.
+func FindAdapter(driver string) (Adapter, error) {
+	return adapters.find(driver)
+}
+
 // IsolationLevel is the transaction isolation level used in TxOptions.
 type IsolationLevel int
 
@@ -179,6 +195,9 @@ type Metrics struct {
 	Query    HalfMetrics `json:"query"`
 	Exec     HalfMetrics `json:"exec"`
 	Transact HalfMetrics `json:"transact"`
+	Retries  int32       `json:"retries"` // Count of retried transactions
+	// Metrics of cached statements
+	Statements []StmtMetrics `json:"statements,omitempty"`
 }
 
 func (metrics *Metrics) beginQuery() int64 {
@@ -208,6 +227,10 @@ func (metrics *Metrics) endTransact(started int64) {
 	atomic.AddInt64(&metrics.Transact.Time, time.Now().UnixNano()-started)
 }
 
+func (metrics *Metrics) retried() {
+	atomic.AddInt32(&metrics.Retries, 1)
+}
+
 func (metrics *Metrics) GetMetrics() Metrics {
 	return Metrics{
 		Query: HalfMetrics{
@@ -222,6 +245,7 @@ func (metrics *Metrics) GetMetrics() Metrics {
 			Count: atomic.LoadInt32(&metrics.Transact.Count),
 			Time:  atomic.LoadInt64(&metrics.Transact.Time),
 		},
+		Retries: atomic.LoadInt32(&metrics.Retries),
 	}
 }
 
@@ -251,6 +275,8 @@ type Scope interface {
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) Row
 	DbId() DbId
 	Adapter() Adapter
+	// Execute action within transaction (see Transact)
+	Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error
 }
 
 // Composer is interface for coordinate threads
@@ -303,6 +329,8 @@ type DB interface {
 	endExec(started int64)
 	beginTransact() int64
 	endTransact(started int64)
+	retried()
+	stmtCache() *stmtCache
 }
 
 // Scanner is an interface used by Scan.
@@ -449,8 +477,10 @@ type database1 struct {
 	dsc     DSC
 	dbId    DbId
 	adapter Adapter
+	stmts   *stmtCache // Cache of statements (optional)
+	node    bool       // Node of cluster, that is closed by cluster only
 	*Metrics
-	composer
+	*composer
 }
 
 func (db *database1) DSC() DSC {
@@ -466,8 +496,15 @@ func (db *database1) Adapter() Adapter {
 }
 
 // Close closes all physical databases concurrently, releasing any open resources.
+// Close of node of cluster (see Master and Slave) does nothing.
 func (db *database1) Close(ctx context.Context) error {
+	if db.node {
+		return nil
+	}
 	db.composer.Close()
+	if db.stmts != nil {
+		db.stmts.close()
+	}
 	return db.db.Close()
 }
 
@@ -485,7 +522,7 @@ func (db *database1) Begin() (Tx, error) {
 		return nil, err
 	}
 
-	return &tx{db: db, trans: t, started: started}, nil
+	return &tx{db: db, trans: t, started: started, stmts: new(txStmts)}, nil
 }
 
 func (db *database1) BeginTx(ctx context.Context, opts *TxOptions) (Tx, error) {
@@ -496,23 +533,21 @@ func (db *database1) BeginTx(ctx context.Context, opts *TxOptions) (Tx, error) {
 		return nil, err
 	}
 
-	return &tx{db: db, trans: t, started: started}, nil
+	return &tx{db: db, trans: t, started: started, stmts: new(txStmts)}, nil
 }
 
 // Exec executes a query without returning any rows.
 // The args are for any named parameters in the query.
 // Exec uses the master as the underlying physical db.
 func (db *database1) Exec(query string, args ...interface{}) (Result, error) {
-	started := db.beginExec()
-	res, err := db.db.Exec(query, args...)
-	db.endExec(started)
-	if err != nil {
-		return nil, err
-	}
-	return &result{db: db, res: res}, nil
+	return db.ExecContext(context.Background(), query, args...)
 }
 
 func (db *database1) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	markWritten(ctx)
+	if db.stmts != nil && len(args) != 0 {
+		return db.stmts.exec(ctx, query, args)
+	}
 	started := db.beginExec()
 	res, err := db.db.ExecContext(ctx, query, args...)
 	db.endExec(started)
@@ -546,15 +581,13 @@ func (db *database1) PrepareContext(ctx context.Context, query string) (Stmt, er
 // The args are for any parameters in the query.
 // Query uses a slave as the physical db.
 func (db *database1) Query(query string, args ...interface{}) (Rows, error) {
-	started := db.beginQuery()
-	rs, err := db.db.Query(query, args...)
-	if err != nil {
-		return nil, recode(err)
-	}
-	return &rows{db: db, rs: rs, started: started}, nil
+	return db.QueryContext(context.Background(), query, args...)
 }
 
 func (db *database1) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	if db.stmts != nil && len(args) != 0 {
+		return db.stmts.query(ctx, query, args)
+	}
 	started := db.beginQuery()
 	rs, err := db.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -568,13 +601,13 @@ func (db *database1) QueryContext(ctx context.Context, query string, args ...int
 // Errors are deferred until Row's Scan method is called.
 // QueryRow uses a slave as the physical db.
 func (db *database1) QueryRow(query string, args ...interface{}) Row {
-	started := db.beginQuery()
-	r := db.db.QueryRow(query, args...)
-	db.endQuery(started)
-	return &row{db: db, r: r}
+	return db.QueryRowContext(context.Background(), query, args...)
 }
 
 func (db *database1) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	if db.stmts != nil && len(args) != 0 {
+		return db.stmts.queryRow(ctx, query, args)
+	}
 	started := db.beginQuery()
 	r := db.db.QueryRowContext(ctx, query, args...)
 	db.endQuery(started)
@@ -627,6 +660,25 @@ func (db *database1) IsCluster() bool {
 	return false
 }
 
+func (db *database1) GetMetrics() Metrics {
+	res := db.Metrics.GetMetrics()
+	if db.stmts != nil {
+		res.Statements = db.stmts.metrics()
+	}
+	return res
+}
+
+func (db *database1) Audit(auditor interface{}) error {
+	if a, ok := auditor.(Auditor); ok {
+		return a.AuditDatabase(db.GetMetrics())
+	}
+	return nil
+}
+
+func (db *database1) stmtCache() *stmtCache {
+	return db.stmts
+}
+
 func (db *database1) Interface(
 	detective func(interface{}) interface{},
 ) (interface{}, bool) {
@@ -639,13 +691,16 @@ func (db *database1) Interface(
 
 // Cluster database
 type database2 struct {
-	pdbs    []*sql.DB // Physical databases
+	nodes   []*database1 // Physical databases (first is master, others are replicas)
+	health  []*health    // Health of nodes
+	policy  ReplicaPolicy
 	dsc     DSC
 	count   uint64 // Monotonically incrementing counter on each query
 	dbId    DbId
 	adapter Adapter
+	stmts   *stmtCache // Cache of statements (optional)
 	*Metrics
-	composer
+	*composer
 }
 
 func (db *database2) DSC() DSC {
@@ -663,10 +718,13 @@ func (db *database2) Adapter() Adapter {
 // Close closes all physical databases concurrently, releasing any open resources.
 func (db *database2) Close(ctx context.Context) error {
 	db.composer.Close()
+	if db.stmts != nil {
+		db.stmts.close()
+	}
 	return scatter(
-		len(db.pdbs),
+		len(db.nodes),
 		func(i int) error {
-			err := db.pdbs[i].Close()
+			err := db.nodes[i].db.Close()
 			return err
 		},
 	)
@@ -674,48 +732,47 @@ func (db *database2) Close(ctx context.Context) error {
 
 // Driver returns the physical database's underlying driver.
 func (db *database2) Driver() driver.Driver {
-	return db.pdbs[0].Driver()
+	return db.nodes[0].Driver()
 }
 
 // Begin starts a transaction on the master. The isolation level is dependent on the driver.
 func (db *database2) Begin() (Tx, error) {
 	started := db.beginTransact()
 
-	t, err := db.pdbs[0].Begin()
+	t, err := db.nodes[0].db.Begin()
 	if err != nil {
 		return nil, err
 	}
 
-	return &tx{db: db, trans: t, started: started}, nil
+	return &tx{db: db, trans: t, started: started, stmts: new(txStmts)}, nil
 }
 
 func (db *database2) BeginTx(ctx context.Context, opts *TxOptions) (Tx, error) {
 	started := db.beginTransact()
 
-	t, err := db.pdbs[0].BeginTx(ctx, opts)
+	t, err := db.nodes[0].db.BeginTx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &tx{db: db, trans: t, started: started}, nil
+	markWritten(ctx)
+	return &tx{db: db, trans: t, started: started, stmts: new(txStmts)}, nil
 }
 
 // Exec executes a query without returning any rows.
 // The args are for any named parameters in the query.
 // Exec uses the master as the underlying physical db.
 func (db *database2) Exec(query string, args ...interface{}) (Result, error) {
-	started := db.beginExec()
-	res, err := db.pdbs[0].Exec(query, args...)
-	db.endExec(started)
-	if err != nil {
-		return nil, err
-	}
-	return &result{db: db, res: res}, nil
+	return db.ExecContext(context.Background(), query, args...)
 }
 
 func (db *database2) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	markWritten(ctx)
+	if db.stmts != nil && len(args) != 0 {
+		return db.stmts.exec(ctx, query, args)
+	}
 	started := db.beginExec()
-	res, err := db.pdbs[0].ExecContext(ctx, query, args...)
+	res, err := db.nodes[0].db.ExecContext(ctx, query, args...)
 	db.endExec(started)
 	if err != nil {
 		return nil, err
@@ -727,68 +784,61 @@ func (db *database2) ExecContext(ctx context.Context, query string, args ...inte
 // establishing a connection if necessary.
 func (db *database2) Ping() error {
 	return scatter(
-		len(db.pdbs),
+		len(db.nodes),
 		func(i int) error {
-			err := db.pdbs[i].Ping()
+			err := db.nodes[i].db.Ping()
 			return err
 		},
 	)
 }
 
 // Prepare creates a prepared statement for later queries or executions
-// on each physical database, concurrently.
+// on master and on each healthy replica, concurrently.
+// Only failure of master is reported, replicas prepare statement at first use.
 func (db *database2) Prepare(query string) (Stmt, error) {
-	stmts := make([]*sql.Stmt, len(db.pdbs))
-
-	err := scatter(
-		len(db.pdbs),
-		func(i int) (err error) {
-			stmts[i], err = db.pdbs[i].Prepare(query)
-			return err
-		},
-	)
+	return db.PrepareContext(context.Background(), query)
+}
 
+func (db *database2) PrepareContext(ctx context.Context, query string) (Stmt, error) {
+	master, err := db.nodes[0].db.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	return &stmt2{db: db, stmts: stmts}, nil
-}
-
-func (db *database2) PrepareContext(ctx context.Context, query string) (Stmt, error) {
-	stmts := make([]*sql.Stmt, len(db.pdbs))
-
-	err := scatter(
-		len(db.pdbs),
-		func(i int) (err error) {
-			stmts[i], err = db.pdbs[i].PrepareContext(ctx, query)
-			return err
+	// Failure of replica doesn't prevent writing. Statements of
+	// replicas, that are not prepared now, are prepared at first use.
+	stmts := make([]*sql.Stmt, len(db.nodes))
+	stmts[0] = master
+	_ = scatter(
+		len(db.nodes)-1,
+		func(i int) error {
+			node := i + 1
+			if db.health[node].isHealthy() {
+				stmts[node], _ = db.nodes[node].db.PrepareContext(ctx, query)
+			}
+			return nil
 		},
 	)
 
-	if err != nil {
-		return nil, err
-	}
-
-	return &stmt2{db: db, stmts: stmts}, nil
+	return &stmt2{db: db, query: query, stmts: stmts}, nil
 }
 
 // Query executes a query that returns rows, typically a SELECT.
 // The args are for any parameters in the query.
 // Query uses a slave as the physical db.
 func (db *database2) Query(query string, args ...interface{}) (Rows, error) {
-	started := db.beginQuery()
-	rs, err := db.pdbs[db.slave(len(db.pdbs))].Query(query, args...)
-	if err != nil {
-		return nil, recode(err)
-	}
-	return &rows{db: db, rs: rs, started: started}, nil
+	return db.QueryContext(context.Background(), query, args...)
 }
 
 func (db *database2) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	if db.stmts != nil && len(args) != 0 {
+		return db.stmts.query(ctx, query, args)
+	}
 	started := db.beginQuery()
-	rs, err := db.pdbs[db.slave(len(db.pdbs))].QueryContext(ctx, query, args...)
+	i := db.slave(ctx)
+	rs, err := db.nodes[i].db.QueryContext(ctx, query, args...)
 	if err != nil {
+		db.failed(i, err)
 		return nil, recode(err)
 	}
 	return &rows{db: db, rs: rs, started: started}, nil
@@ -799,15 +849,15 @@ func (db *database2) QueryContext(ctx context.Context, query string, args ...int
 // Errors are deferred until Row's Scan method is called.
 // QueryRow uses a slave as the physical db.
 func (db *database2) QueryRow(query string, args ...interface{}) Row {
-	started := db.beginQuery()
-	r := db.pdbs[db.slave(len(db.pdbs))].QueryRow(query, args...)
-	db.endQuery(started)
-	return &row{db: db, r: r}
+	return db.QueryRowContext(context.Background(), query, args...)
 }
 
 func (db *database2) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	if db.stmts != nil && len(args) != 0 {
+		return db.stmts.queryRow(ctx, query, args)
+	}
 	started := db.beginQuery()
-	r := db.pdbs[db.slave(len(db.pdbs))].QueryRowContext(ctx, query, args...)
+	r := db.nodes[db.slave(ctx)].db.QueryRowContext(ctx, query, args...)
 	db.endQuery(started)
 	return &row{db: db, r: r}
 }
@@ -818,8 +868,8 @@ func (db *database2) QueryRowContext(ctx context.Context, query string, args ...
 // new MaxIdleConns will be reduced to match the MaxOpenConns limit
 // If n <= 0, no idle connections are retained.
 func (db *database2) SetMaxIdleConns(n int) {
-	for i := range db.pdbs {
-		db.pdbs[i].SetMaxIdleConns(n)
+	for _, node := range db.nodes {
+		node.SetMaxIdleConns(n)
 	}
 }
 
@@ -830,8 +880,8 @@ func (db *database2) SetMaxIdleConns(n int) {
 // the new MaxOpenConns limit. If n <= 0, then there is no limit on the number
 // of open connections. The default is 0 (unlimited).
 func (db *database2) SetMaxOpenConns(n int) {
-	for i := range db.pdbs {
-		db.pdbs[i].SetMaxOpenConns(n)
+	for _, node := range db.nodes {
+		node.SetMaxOpenConns(n)
 	}
 }
 
@@ -839,33 +889,43 @@ func (db *database2) SetMaxOpenConns(n int) {
 // Expired connections may be closed lazily before reuse.
 // If d <= 0, connections are reused forever.
 func (db *database2) SetConnMaxLifetime(d time.Duration) {
-	for i := range db.pdbs {
-		db.pdbs[i].SetConnMaxLifetime(d)
+	for _, node := range db.nodes {
+		node.SetConnMaxLifetime(d)
 	}
 }
 
-// Slave returns one of the physical databases which is a slave
+// Slave returns one of the healthy replicas (or master, if there are no
+// healthy replicas).
 func (db *database2) Slave() DB {
-	return &database1{
-		db:      db.pdbs[db.slave(len(db.pdbs))],
-		Metrics: db.Metrics,
-	}
+	return db.nodes[db.slave(context.Background())]
 }
 
 // Master returns the master physical database
 func (db *database2) Master() DB {
-	return &database1{db: db.pdbs[0]}
+	return db.nodes[0]
+}
+
+func (db *database2) IsCluster() bool {
+	return true
 }
 
-func (db *database2) slave(n int) int {
-	if n <= 1 {
-		return 0
+func (db *database2) GetMetrics() Metrics {
+	res := db.Metrics.GetMetrics()
+	if db.stmts != nil {
+		res.Statements = db.stmts.metrics()
 	}
-	return int(1 + (atomic.AddUint64(&db.count, 1) % uint64(n-1)))
+	return res
 }
 
-func (db *database2) IsCluster() bool {
-	return true
+func (db *database2) Audit(auditor interface{}) error {
+	if a, ok := auditor.(Auditor); ok {
+		return a.AuditDatabase(db.GetMetrics())
+	}
+	return nil
+}
+
+func (db *database2) stmtCache() *stmtCache {
+	return db.stmts
 }
 
 func (db *database2) Interface(
@@ -883,6 +943,7 @@ type tx struct {
 	trans   *sql.Tx
 	level   int16
 	started int64
+	stmts   *txStmts // Cached statements, bound to transaction
 }
 
 func (t *tx) DbId() DbId {
@@ -898,7 +959,7 @@ func (t *tx) Level() int16 {
 }
 
 func (t *tx) Begin() (Tx, error) {
-	res := &txx{tx{db: t.db, trans: t.trans, level: t.level + 1}, true}
+	res := &txx{tx{db: t.db, trans: t.trans, level: t.level + 1, stmts: t.stmts}, true}
 	query := "SAVEPOINT " + res.getSavePoint()
 	_, err := t.Exec(query)
 	if err != nil {
@@ -908,7 +969,7 @@ func (t *tx) Begin() (Tx, error) {
 }
 
 func (t *tx) BeginTx(ctx context.Context, opts *TxOptions) (Tx, error) {
-	res := &txx{tx{db: t.db, trans: t.trans, level: t.level + 1}, true}
+	res := &txx{tx{db: t.db, trans: t.trans, level: t.level + 1, stmts: t.stmts}, true}
 	query := "SAVEPOINT " + res.getSavePoint()
 	_, err := t.ExecContext(ctx, query)
 	if err != nil {
@@ -935,18 +996,23 @@ func (t *tx) Rollback() error {
 }
 
 func (t *tx) Exec(query string, args ...interface{}) (Result, error) {
-	started := t.db.beginExec()
-	res, err := t.trans.Exec(query, args...)
-	t.db.endExec(started)
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *tx) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	s, entry, err := t.prepared(ctx, query, args)
 	if err != nil {
 		return nil, err
 	}
-	return &result{db: t.db, res: res}, nil
-}
 
-func (t *tx) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
 	started := t.db.beginExec()
-	res, err := t.trans.ExecContext(ctx, query, args...)
+	var res sql.Result
+	if s != nil {
+		res, err = s.ExecContext(ctx, args...)
+		entry.end(started, err)
+	} else {
+		res, err = t.trans.ExecContext(ctx, query, args...)
+	}
 	t.db.endExec(started)
 	if err != nil {
 		return nil, err
@@ -955,17 +1021,23 @@ func (t *tx) ExecContext(ctx context.Context, query string, args ...interface{})
 }
 
 func (t *tx) Query(query string, args ...interface{}) (Rows, error) {
-	started := t.db.beginQuery()
-	rs, err := t.trans.Query(query, args...)
-	if err != nil {
-		return nil, recode(err)
-	}
-	return &rows{db: t.db, rs: rs, started: started}, nil
+	return t.QueryContext(context.Background(), query, args...)
 }
 
 func (t *tx) QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	s, entry, err := t.prepared(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
 	started := t.db.beginQuery()
-	rs, err := t.trans.QueryContext(ctx, query, args...)
+	var rs *sql.Rows
+	if s != nil {
+		rs, err = s.QueryContext(ctx, args...)
+		entry.end(started, err)
+	} else {
+		rs, err = t.trans.QueryContext(ctx, query, args...)
+	}
 	if err != nil {
 		return nil, recode(err)
 	}
@@ -973,19 +1045,41 @@ func (t *tx) QueryContext(ctx context.Context, query string, args ...interface{}
 }
 
 func (t *tx) QueryRow(query string, args ...interface{}) Row {
-	started := t.db.beginQuery()
-	r := t.trans.QueryRow(query, args...)
-	t.db.endQuery(started)
-	return &row{db: t.db, r: r}
+	return t.QueryRowContext(context.Background(), query, args...)
 }
 
 func (t *tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	s, entry, err := t.prepared(ctx, query, args)
+	if err != nil {
+		return errRow{err: err}
+	}
+
 	started := t.db.beginQuery()
-	r := t.trans.QueryRowContext(ctx, query, args...)
+	var r *sql.Row
+	if s != nil {
+		r = s.QueryRowContext(ctx, args...)
+		entry.end(started, nil)
+	} else {
+		r = t.trans.QueryRowContext(ctx, query, args...)
+	}
 	t.db.endQuery(started)
 	return &row{db: t.db, r: r}
 }
 
+// Get cached statement, bound to transaction.
+// Returns nil, if cache is disabled or query has no arguments.
+func (t *tx) prepared(
+	ctx context.Context,
+	query string,
+	args []interface{},
+) (*sql.Stmt, *stmtEntry, error) {
+	cache := t.db.stmtCache()
+	if cache == nil || len(args) == 0 {
+		return nil, nil, nil
+	}
+	return cache.txStmt(ctx, t, query)
+}
+
 func (t *tx) getSavePoint() string {
 	return fmt.Sprintf("trans%d", t.level)
 }
@@ -1030,12 +1124,15 @@ func (t *txx) Rollback() error {
 }
 
 var (
-	ErrNoRows         = data.ErrNoMatch
-	ErrTxDone         = sql.ErrTxDone
-	ErrUnknownDriver  = errors.New("unknown driver")
-	ErrCaptureLock    = errors.New("timeout of latch")
-	ErrReleaseLock    = errors.New("can not release lock")
-	ErrReleaseInvalid = errors.New("unknown latch or invalid thread")
+	ErrNoRows              = data.ErrNoMatch
+	ErrTxDone              = sql.ErrTxDone
+	ErrUnknownDriver       = errors.New("unknown driver")
+	ErrCaptureLock         = errors.New("timeout of latch")
+	ErrReleaseLock         = errors.New("can not release lock")
+	ErrReleaseInvalid      = errors.New("unknown latch or invalid thread")
+	ErrEmptyList           = errors.New("empty list of values")
+	ErrInvalidDest         = errors.New("destination must be non-nil pointer")
+	ErrBulkLoadUnsupported = errors.New("bulk load is not supported")
 )
 
 type Repository interface {
@@ -1131,5 +1228,3 @@ func recode(err error) error {
 
 	return err
 }
-
-// todo: Handle failovers on slaves
@@ -0,0 +1,384 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+)
+
+// Decimal is a fixed point number with exact arithmetic.
+// It is used for money amounts and other DECIMAL (NUMERIC) columns.
+// Zero value is 0. Values are immutable.
+type Decimal struct {
+	coef  *big.Int // Unscaled value (nil means zero)
+	scale int32    // Count of digits after decimal point
+}
+
+// NewDecimal returns decimal value * 10^(-scale).
+func NewDecimal(value int64, scale int32) Decimal {
+	return Decimal{coef: big.NewInt(value), scale: scale}
+}
+
+// MaxDecimalScale limits exponent and scale of parsed decimals,
+// so untrusted input can't require huge amount of memory and time.
+const MaxDecimalScale = 1000
+
+// ParseDecimal parses decimal in plain ("-12.50") or exponential ("1.25e1") notation.
+// Exponent and resulting scale must be within ±MaxDecimalScale.
+func ParseDecimal(s string) (Decimal, error) {
+	src := strings.TrimSpace(s)
+	var exp int64
+	if i := strings.IndexAny(src, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.ParseInt(src[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("sql: invalid decimal %q", s)
+		}
+		if exp > MaxDecimalScale || exp < -MaxDecimalScale {
+			return Decimal{}, fmt.Errorf("sql: decimal %q is out of range", s)
+		}
+		src = src[:i]
+	}
+
+	sign := ""
+	if src != "" && (src[0] == '-' || src[0] == '+') {
+		if src[0] == '-' {
+			sign = "-"
+		}
+		src = src[1:]
+	}
+
+	digits := src
+	var frac string
+	if i := strings.IndexByte(src, '.'); i >= 0 {
+		digits, frac = src[:i], src[i+1:]
+	}
+	if digits == "" && frac == "" || !isDigits(digits) || !isDigits(frac) {
+		return Decimal{}, fmt.Errorf("sql: invalid decimal %q", s)
+	}
+
+	scale := int64(len(frac)) - exp
+	if scale > MaxDecimalScale || scale < -MaxDecimalScale {
+		return Decimal{}, fmt.Errorf("sql: decimal %q is out of range", s)
+	}
+
+	coef, _ := new(big.Int).SetString(sign+digits+frac, 10)
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// Scale returns count of digits after decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares values and returns -1, 0 or +1.
+func (d Decimal) Cmp(x Decimal) int {
+	a, b := align(d, x)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+func (d Decimal) Add(x Decimal) Decimal {
+	a, b := align(d, x)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: maxScale(d, x)}
+}
+
+func (d Decimal) Sub(x Decimal) Decimal {
+	a, b := align(d, x)
+	return Decimal{coef: new(big.Int).Sub(a, b), scale: maxScale(d, x)}
+}
+
+func (d Decimal) Mul(x Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), x.int()), scale: d.scale + x.scale}
+}
+
+// Div returns quotient, rounded half away from zero to the required scale.
+// Panics, if divisor is zero.
+func (d Decimal) Div(x Decimal, scale int32) Decimal {
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(x.int())
+	if k := int64(scale) - int64(d.scale) + int64(x.scale); k >= 0 {
+		num.Mul(num, pow10(k))
+	} else {
+		den.Mul(den, pow10(-k))
+	}
+	return Decimal{coef: quo(num, den), scale: scale}
+}
+
+// Round returns value, rounded half away from zero to the required scale.
+func (d Decimal) Round(scale int32) Decimal {
+	if scale >= d.scale {
+		return Decimal{coef: new(big.Int).Mul(d.int(), pow10(int64(scale-d.scale))), scale: scale}
+	}
+	return Decimal{coef: quo(d.int(), pow10(int64(d.scale-scale))), scale: scale}
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns value in plain notation with all digits of scale.
+func (d Decimal) String() string {
+	coef := d.int()
+	digits := new(big.Int).Abs(coef).String()
+	sign := ""
+	if coef.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale <= 0 {
+		return sign + digits + strings.Repeat("0", int(-d.scale))
+	}
+
+	scale := int(d.scale)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	point := len(digits) - scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (d *Decimal) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case Decimal:
+		*d = v
+		return nil
+	case NullDecimal:
+		if !v.Valid {
+			return errors.New("sql: converting NULL to Decimal is unsupported")
+		}
+		*d = v.Decimal
+		return nil
+	case int64:
+		*d = NewDecimal(v, 0)
+		return nil
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return errors.New("sql: converting NULL to Decimal is unsupported")
+	}
+
+	var s string
+	if err := generic.ConvertAssign(&s, value); err != nil {
+		return err
+	}
+	res, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = res
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON encodes decimal as JSON number without loss of precision.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes decimal from JSON number or string.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	res, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = res
+	return nil
+}
+
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// NullDecimal represents a decimal that may be null.
+// NullDecimal implements the Scanner interface so
+// it can be used as a scan destination, similar to NullString.
+// NullDecimal implements the echo.Codec interface, so it can be used
+// as codec of form fields:
+//   &widget.FormText{Codec: new(sql.NullDecimal)}
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool // Valid is true if Decimal is not NULL
+}
+
+func (n *NullDecimal) Scan(value interface{}) error {
+	if value == nil {
+		n.Decimal, n.Valid = Decimal{}, false
+		return nil
+	}
+	if v, ok := value.(NullDecimal); ok {
+		*n = v
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.Scan(value)
+}
+
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.String(), nil
+}
+
+func (n NullDecimal) Internal() driver.Value {
+	if !n.Valid {
+		return nil
+	}
+	return n.Decimal.String()
+}
+
+func (n NullDecimal) External() interface{} {
+	if !n.Valid {
+		return Decimal{}
+	}
+	return n.Decimal
+}
+
+func (n *NullDecimal) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return n.Decimal.MarshalJSON()
+}
+
+func (n *NullDecimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		n.Decimal, n.Valid = Decimal{}, false
+		return nil
+	}
+	n.Valid = true
+	return n.Decimal.UnmarshalJSON(data)
+}
+
+func (n *NullDecimal) Empty(ctx echo.Context) (interface{}, error) {
+	return NullDecimal{}, nil
+}
+
+func (n *NullDecimal) Encode(ctx echo.Context, value string) (interface{}, error) {
+	if value == "" {
+		return NullDecimal{}, nil
+	}
+	d, err := ParseDecimal(value)
+	if err != nil {
+		return nil, echo.ValidationErrors{
+			echo.ValidationErrorInvalidValue,
+		}
+	}
+	return NullDecimal{Decimal: d, Valid: true}, nil
+}
+
+func (n *NullDecimal) Decode(ctx echo.Context, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case NullDecimal:
+		if !v.Valid {
+			return "", nil
+		}
+		return v.Decimal.String(), nil
+	case Decimal:
+		return v.String(), nil
+	}
+	val, _ := generic.ConvertToString(value)
+	return val, nil
+}
+
+// Align coefficients of values to the same scale.
+func align(a, b Decimal) (*big.Int, *big.Int) {
+	switch {
+	case a.scale < b.scale:
+		return new(big.Int).Mul(a.int(), pow10(int64(b.scale-a.scale))), b.int()
+	case a.scale > b.scale:
+		return a.int(), new(big.Int).Mul(b.int(), pow10(int64(a.scale-b.scale)))
+	default:
+		return a.int(), b.int()
+	}
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// Quotient, rounded half away from zero.
+func quo(num, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	r.Abs(r).Lsh(r, 1)
+	if r.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if num.Sign() == den.Sign() {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
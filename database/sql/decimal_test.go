@@ -0,0 +1,166 @@
+package sql
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]string{
+		"0":         "0",
+		"12.50":     "12.50",
+		"-0.05":     "-0.05",
+		"+.5":       "0.5",
+		"7.":        "7",
+		"1.25e1":    "12.5",
+		"1.25E+3":   "1250",
+		"-15e-3":    "-0.015",
+		" 100.001 ": "100.001",
+		"123456789012345678901234567890.123456789": "123456789012345678901234567890.123456789",
+	}
+
+	for src, dst := range tests {
+		d, err := ParseDecimal(src)
+		require.NoError(t, err, src)
+		assert.Equal(t, dst, d.String(), src)
+	}
+
+	for _, src := range []string{"", "-", ".", "1.2.3", "abc", "1e", "0x10", "1,5"} {
+		_, err := ParseDecimal(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestParseDecimal_Range(t *testing.T) {
+	d, err := ParseDecimal("1e1000")
+	require.NoError(t, err)
+	assert.Equal(t, "1"+strings.Repeat("0", 1000), d.String())
+
+	d, err = ParseDecimal("1e-1000")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1000), d.Scale())
+
+	tests := []string{
+		"1e10000000",
+		"1.5e-2147483647",
+		"1e1001",
+		"1e-1001",
+		"0.5e-1000",
+		"0." + strings.Repeat("1", 1001),
+	}
+	for _, src := range tests {
+		_, err := ParseDecimal(src)
+		assert.Error(t, err, src)
+	}
+
+	var n NullDecimal
+	assert.Error(t, json.Unmarshal([]byte(`"1e10000000"`), &n))
+	_, err = new(NullDecimal).Encode(nil, "1.5e-2147483647")
+	assert.Error(t, err)
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	parse := func(s string) Decimal {
+		d, err := ParseDecimal(s)
+		require.NoError(t, err)
+		return d
+	}
+
+	a, b := parse("0.1"), parse("0.2")
+	assert.Equal(t, "0.3", a.Add(b).String())
+	assert.Equal(t, 0, a.Add(b).Cmp(parse("0.30")))
+	assert.Equal(t, "-0.1", a.Sub(b).String())
+	assert.Equal(t, "0.02", a.Mul(b).String())
+	assert.Equal(t, "0.50", a.Div(b, 2).String())
+	assert.Equal(t, "0.3333", parse("1").Div(parse("3"), 4).String())
+	assert.Equal(t, "0.6667", parse("2").Div(parse("3"), 4).String())
+	assert.Equal(t, "-0.6667", parse("-2").Div(parse("3"), 4).String())
+	assert.Equal(t, "1000", parse("10").Div(parse("0.01"), 0).String())
+	assert.Equal(t, "2.35", parse("2.345").Round(2).String())
+	assert.Equal(t, "-2.35", parse("-2.345").Round(2).String())
+	assert.Equal(t, "2.3400", parse("2.34").Round(4).String())
+	assert.Equal(t, "12.00", NewDecimal(1200, 2).String())
+	assert.Equal(t, "0", Decimal{}.String())
+	assert.Equal(t, "5", Decimal{}.Add(NewDecimal(5, 0)).String())
+	assert.Equal(t, -1, parse("-3").Sign())
+	assert.Equal(t, "3", parse("-3").Abs().String())
+	assert.Equal(t, "3", parse("-3").Neg().String())
+	assert.True(t, parse("0.00").IsZero())
+	assert.Equal(t, 1.5, parse("1.50").Float64())
+	assert.Equal(t, 1, parse("1.01").Cmp(parse("1.009")))
+
+	// Values are immutable
+	c := a.Add(b)
+	_ = c.Mul(c)
+	assert.Equal(t, "0.3", c.String())
+	assert.Panics(t, func() { a.Div(Decimal{}, 2) })
+}
+
+func TestNullDecimal(t *testing.T) {
+	var n NullDecimal
+	require.NoError(t, n.Scan([]byte("19.99")))
+	assert.True(t, n.Valid)
+	assert.Equal(t, "19.99", n.Decimal.String())
+
+	value, err := n.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "19.99", value)
+
+	require.NoError(t, n.Scan(int64(7)))
+	assert.Equal(t, "7", n.Decimal.String())
+	require.NoError(t, n.Scan(2.5))
+	assert.Equal(t, "2.5", n.Decimal.String())
+	assert.Error(t, n.Scan("money"))
+
+	require.NoError(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+	value, err = n.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	data, err := json.Marshal(&struct {
+		Price  *NullDecimal
+		Amount *NullDecimal
+	}{
+		Price:  &NullDecimal{Decimal: NewDecimal(100, 2), Valid: true},
+		Amount: &NullDecimal{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Price":1.00,"Amount":null}`, string(data))
+
+	require.NoError(t, json.Unmarshal([]byte(`"0.10"`), &n))
+	assert.True(t, n.Valid)
+	assert.Equal(t, "0.10", n.Decimal.String())
+	require.NoError(t, json.Unmarshal([]byte(`null`), &n))
+	assert.False(t, n.Valid)
+}
+
+func TestNullDecimal_Codec(t *testing.T) {
+	var codec echo.Codec = new(NullDecimal)
+
+	val, err := codec.Encode(nil, "12.30")
+	require.NoError(t, err)
+	assert.Equal(t, NullDecimal{Decimal: NewDecimal(1230, 2), Valid: true}, val)
+
+	s, err := codec.Decode(nil, val)
+	require.NoError(t, err)
+	assert.Equal(t, "12.30", s)
+
+	_, err = codec.Encode(nil, "12,30")
+	assert.Equal(t, echo.ValidationErrors{echo.ValidationErrorInvalidValue}, err)
+
+	empty, err := codec.Empty(nil)
+	require.NoError(t, err)
+	assert.Equal(t, NullDecimal{}, empty)
+
+	// Export of model assigns value of field to the destination
+	var dst NullDecimal
+	require.NoError(t, generic.ConvertAssign(&dst, val))
+	assert.Equal(t, val, dst)
+}
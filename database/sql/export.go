@@ -0,0 +1,182 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+)
+
+const (
+	MIMETextCSV              = "text/csv"
+	MIMETextCSVCharsetUTF8   = MIMETextCSV + "; charset=UTF-8"
+	MIMEApplicationJSONLines = "application/x-ndjson"
+)
+
+// WriteCSV writes rows into w as CSV with header of column names.
+// NULL values are written as empty strings.
+func WriteCSV(w io.Writer, rows Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	values, dest := exportBuffers(len(columns))
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, value := range values {
+			record[i] = exportString(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSONLines writes rows into w as JSON objects (one object per line).
+// Keys of objects are names of columns in order of the query.
+func WriteJSONLines(w io.Writer, rows Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]byte, len(columns))
+	for i, column := range columns {
+		keys[i], err = json.Marshal(column)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	values, dest := exportBuffers(len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i, value := range values {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+		}
+		buf.WriteString("}\n")
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+// StreamCSV sends rows to the client as CSV through Context.Stream.
+// Rows are read as the client consumes response, so memory usage is constant.
+// Rows are closed after all.
+// Example:
+//   rows, err := db.QueryContext(ctx, "SELECT id, name FROM users")
+//   if err != nil {
+//       return err
+//   }
+//   return sql.StreamCSV(ctx, http.StatusOK, rows)
+func StreamCSV(ctx echo.Context, code int, rows Rows) error {
+	return stream(ctx, code, MIMETextCSVCharsetUTF8, rows, WriteCSV)
+}
+
+// StreamJSONLines sends rows to the client as JSON lines through Context.Stream.
+// See StreamCSV.
+func StreamJSONLines(ctx echo.Context, code int, rows Rows) error {
+	return stream(ctx, code, MIMEApplicationJSONLines, rows, WriteJSONLines)
+}
+
+func stream(
+	ctx echo.Context,
+	code int,
+	contentType string,
+	rows Rows,
+	write func(w io.Writer, rows Rows) error,
+) error {
+	defer rows.Close()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(write(pw, rows))
+	}()
+
+	err := ctx.Stream(code, contentType, pr)
+	// Release writer, if client has gone away
+	pr.CloseWithError(err)
+	<-done
+	return err
+}
+
+// Buffers for scanning of arbitrary row.
+func exportBuffers(n int) ([]interface{}, []interface{}) {
+	values := make([]interface{}, n)
+	dest := make([]interface{}, n)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	return values, dest
+}
+
+func exportString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		s, _ := generic.ConvertToString(v)
+		return s
+	}
+}
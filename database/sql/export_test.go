@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExportRows() *arrayRows {
+	return &arrayRows{
+		columns: []string{"id", "name", "created"},
+		data: Arrays{
+			{int64(1), []byte("Bob, \"Jr\""), time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)},
+			{int64(2), nil, nil},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, newExportRows()))
+	assert.Equal(t,
+		"id,name,created\n"+
+			"1,\"Bob, \"\"Jr\"\"\",2019-05-01T10:00:00Z\n"+
+			"2,,\n",
+		buf.String(),
+	)
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONLines(&buf, newExportRows()))
+	assert.Equal(t,
+		`{"id":1,"name":"Bob, \"Jr\"","created":"2019-05-01T10:00:00Z"}`+"\n"+
+			`{"id":2,"name":null,"created":null}`+"\n",
+		buf.String(),
+	)
+}
+
+type failedRows struct {
+	arrayRows
+}
+
+func (rows *failedRows) Err() error {
+	return errors.New("connection lost")
+}
+
+func TestStream(t *testing.T) {
+	e := echo.New()
+	e.Router().Get("/csv", func(ctx echo.Context) error {
+		return StreamCSV(ctx, http.StatusOK, newExportRows())
+	})
+	e.Router().Get("/jsonl", func(ctx echo.Context) error {
+		return StreamJSONLines(ctx, http.StatusOK, newExportRows())
+	})
+	e.Router().Get("/failed", func(ctx echo.Context) error {
+		err := StreamCSV(ctx, http.StatusOK, &failedRows{arrayRows: *newExportRows()})
+		assert.EqualError(t, err, "connection lost")
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/csv", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMETextCSVCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), "2,,\n")
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jsonl", nil))
+	assert.Equal(t, MIMEApplicationJSONLines, rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `{"id":2,"name":null,"created":null}`)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/failed", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
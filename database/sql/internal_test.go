@@ -0,0 +1,78 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Internals of package for external tests (package sql_test),
+// which use fake database of package sqltest.
+
+var Backoff = RetryPolicy.backoff
+
+var MarkWritten = markWritten
+
+// SlaveOf returns index of node of cluster, selected for reading query.
+func SlaveOf(ctx context.Context, db DB) int {
+	return db.(*database2).slave(ctx)
+}
+
+// EjectNode ejects replica of cluster.
+func EjectNode(db DB, i int) {
+	db.(*database2).health[i].eject()
+}
+
+// ObserveNode admits replica of cluster with latency of ping.
+func ObserveNode(db DB, i int, latency time.Duration) {
+	db.(*database2).health[i].observe(latency)
+}
+
+// CloseCachedStmt closes cached statement, as concurrent eviction does.
+func CloseCachedStmt(ctx context.Context, db DB, query string) error {
+	entry, err := db.stmtCache().get(ctx, query)
+	if err != nil {
+		return err
+	}
+	return entry.stmt.Close()
+}
+
+var Percentile = percentile
+
+// ObserveQuery registers query, that started at moment, within slow log.
+func ObserveQuery(log *SlowLog, query string, started time.Time) {
+	profiler := &slowLogProfiler{log: log}
+	profiler.finished(query, nil, started)
+}
+
+// Adapters of MySQL and PostgreSQL (with COPY) over fake database of package sqltest.
+type fakeMySQL struct{ *mySqlAdater }
+
+func (adapter fakeMySQL) MakeConnectionString(dsn *DSN) string {
+	return dsn.Host
+}
+
+type fakePostgres struct{ *postgresAdapter }
+
+func (adapter fakePostgres) MakeConnectionString(dsn *DSN) string {
+	return dsn.Host
+}
+
+// RegisterFakeAdapters registers driver of package sqltest under names
+// driver+"-mysql" and driver+"-postgres" with adapters of real databases.
+func RegisterFakeAdapters(driver string) error {
+	db, err := sql.Open(driver, "")
+	if err != nil {
+		return err
+	}
+	drv := db.Driver()
+	if err := db.Close(); err != nil {
+		return err
+	}
+
+	sql.Register(driver+"-mysql", drv)
+	Register(driver+"-mysql", fakeMySQL{new(mySqlAdater)})
+	sql.Register(driver+"-postgres", drv)
+	Register(driver+"-postgres", fakePostgres{&postgresAdapter{driver: driver + "-postgres", copy: true}})
+	return nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+)
+
+var errInvalidJSON = errors.New("sql: invalid JSON document")
+
+// NullJSON represents a raw JSON document that may be null.
+// NullJSON implements the Scanner interface so
+// it can be used as a scan destination, similar to NullString.
+// NullJSON implements the echo.Codec interface, so it can be used
+// as codec of form fields (document is validated):
+//   &widget.FormText{Codec: new(sql.NullJSON)}
+type NullJSON struct {
+	JSON  json.RawMessage
+	Valid bool // Valid is true if JSON is not NULL
+}
+
+func (n *NullJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		n.JSON, n.Valid = nil, false
+		return nil
+	case NullJSON:
+		*n = v
+		return nil
+	case []byte:
+		n.JSON, n.Valid = append(json.RawMessage(nil), v...), true
+		return nil
+	case string:
+		n.JSON, n.Valid = json.RawMessage(v), true
+		return nil
+	default:
+		return fmt.Errorf("sql: converting %T to NullJSON is unsupported", value)
+	}
+}
+
+func (n NullJSON) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if !json.Valid(n.JSON) {
+		return nil, errInvalidJSON
+	}
+	return string(n.JSON), nil
+}
+
+func (n NullJSON) Internal() driver.Value {
+	if !n.Valid {
+		return nil
+	}
+	return string(n.JSON)
+}
+
+func (n NullJSON) External() interface{} {
+	if !n.Valid {
+		return ""
+	}
+	return string(n.JSON)
+}
+
+func (n *NullJSON) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	if !json.Valid(n.JSON) {
+		return nil, errInvalidJSON
+	}
+	return n.JSON, nil
+}
+
+func (n *NullJSON) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		n.JSON, n.Valid = nil, false
+		return nil
+	}
+	n.JSON, n.Valid = append(json.RawMessage(nil), data...), true
+	return nil
+}
+
+// Unmarshal decodes document into v. Null document leaves v untouched.
+func (n NullJSON) Unmarshal(v interface{}) error {
+	if !n.Valid {
+		return nil
+	}
+	return json.Unmarshal(n.JSON, v)
+}
+
+func (n *NullJSON) Empty(ctx echo.Context) (interface{}, error) {
+	return NullJSON{}, nil
+}
+
+func (n *NullJSON) Encode(ctx echo.Context, value string) (interface{}, error) {
+	if value == "" {
+		return NullJSON{}, nil
+	}
+	if !json.Valid([]byte(value)) {
+		return nil, echo.ValidationErrors{
+			echo.ValidationErrorInvalidValue,
+		}
+	}
+	return NullJSON{JSON: json.RawMessage(value), Valid: true}, nil
+}
+
+func (n *NullJSON) Decode(ctx echo.Context, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case NullJSON:
+		return string(v.JSON), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+	val, _ := generic.ConvertToString(value)
+	return val, nil
+}
+
+// NullJSONValue represents a typed JSON document that may be null.
+// Data is pointer to the value of document.
+// Example:
+//   settings := &Settings{}
+//   err := db.QueryRow("SELECT settings FROM users WHERE id=?", id).Scan(&sql.NullJSONValue{Data: settings})
+// NullJSONValue implements the echo.Codec interface, so it can be used
+// as codec of form fields (Data of codec defines type of document):
+//   &widget.FormText{Codec: &sql.NullJSONValue{Data: new(Settings)}}
+type NullJSONValue struct {
+	Data  interface{}
+	Valid bool // Valid is true if document is not NULL
+}
+
+func (n *NullJSONValue) Scan(value interface{}) error {
+	var raw NullJSON
+	if err := raw.Scan(value); err != nil {
+		return err
+	}
+	n.Valid = raw.Valid
+	if !raw.Valid {
+		return nil
+	}
+	if n.Data == nil {
+		n.Data = new(interface{})
+	}
+	return json.Unmarshal(raw.JSON, n.Data)
+}
+
+func (n NullJSONValue) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (n NullJSONValue) Internal() driver.Value {
+	value, _ := n.Value()
+	return value
+}
+
+func (n NullJSONValue) External() interface{} {
+	if !n.Valid {
+		return nil
+	}
+	return n.Data
+}
+
+func (n *NullJSONValue) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(n.Data)
+}
+
+func (n *NullJSONValue) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		n.Valid = false
+		return nil
+	}
+	return n.Scan(data)
+}
+
+func (n *NullJSONValue) Empty(ctx echo.Context) (interface{}, error) {
+	return NullJSONValue{Data: n.newData()}, nil
+}
+
+func (n *NullJSONValue) Encode(ctx echo.Context, value string) (interface{}, error) {
+	res := NullJSONValue{Data: n.newData()}
+	if value == "" {
+		return res, nil
+	}
+	if err := res.Scan(value); err != nil {
+		return nil, echo.ValidationErrors{
+			echo.ValidationErrorInvalidValue,
+		}
+	}
+	return res, nil
+}
+
+func (n *NullJSONValue) Decode(ctx echo.Context, value interface{}) (string, error) {
+	if v, ok := value.(NullJSONValue); ok {
+		data, err := v.Value()
+		if data == nil || err != nil {
+			return "", err
+		}
+		return data.(string), nil
+	}
+	val, _ := generic.ConvertToString(value)
+	return val, nil
+}
+
+// Create new instance of data with the same type.
+func (n *NullJSONValue) newData() interface{} {
+	if n.Data == nil {
+		return new(interface{})
+	}
+	t := reflect.TypeOf(n.Data)
+	if t.Kind() != reflect.Ptr {
+		return new(interface{})
+	}
+	return reflect.New(t.Elem()).Interface()
+}
@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonSettings struct {
+	Theme string   `json:"theme"`
+	Tags  []string `json:"tags"`
+}
+
+func TestNullJSON(t *testing.T) {
+	var n NullJSON
+	require.NoError(t, n.Scan([]byte(`{"theme":"dark"}`)))
+	assert.True(t, n.Valid)
+
+	var settings jsonSettings
+	require.NoError(t, n.Unmarshal(&settings))
+	assert.Equal(t, "dark", settings.Theme)
+
+	value, err := n.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"theme":"dark"}`, value)
+
+	data, err := json.Marshal(&struct{ Doc *NullJSON }{Doc: &n})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Doc":{"theme":"dark"}}`, string(data))
+
+	_, err = NullJSON{JSON: json.RawMessage("{"), Valid: true}.Value()
+	assert.Error(t, err)
+
+	require.NoError(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+	data, err = json.Marshal(&struct{ Doc *NullJSON }{Doc: &n})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Doc":null}`, string(data))
+
+	var codec echo.Codec = new(NullJSON)
+	_, err = codec.Encode(nil, "{")
+	assert.Equal(t, echo.ValidationErrors{echo.ValidationErrorInvalidValue}, err)
+	val, err := codec.Encode(nil, `[1,2]`)
+	require.NoError(t, err)
+	s, err := codec.Decode(nil, val)
+	require.NoError(t, err)
+	assert.Equal(t, `[1,2]`, s)
+}
+
+func TestNullJSONValue(t *testing.T) {
+	settings := new(jsonSettings)
+	n := NullJSONValue{Data: settings}
+	require.NoError(t, n.Scan(`{"theme":"light","tags":["a","b"]}`))
+	assert.True(t, n.Valid)
+	assert.Equal(t, &jsonSettings{Theme: "light", Tags: []string{"a", "b"}}, settings)
+
+	value, err := n.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `{"theme":"light","tags":["a","b"]}`, value)
+
+	require.NoError(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+	value, err = n.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &n))
+	assert.False(t, n.Valid)
+
+	var codec echo.Codec = &NullJSONValue{Data: new(jsonSettings)}
+	val, err := codec.Encode(nil, `{"theme":"blue"}`)
+	require.NoError(t, err)
+	assert.Equal(t, &jsonSettings{Theme: "blue"}, val.(NullJSONValue).Data)
+	s, err := codec.Decode(nil, val)
+	require.NoError(t, err)
+	assert.Equal(t, `{"theme":"blue","tags":null}`, s)
+
+	_, err = codec.Encode(nil, `{"theme":1}`)
+	assert.Equal(t, echo.ValidationErrors{echo.ValidationErrorInvalidValue}, err)
+}
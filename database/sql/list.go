@@ -0,0 +1,418 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+)
+
+// ListFormat defines representation of list in the database.
+type ListFormat int
+
+const (
+	ListComma ListFormat = iota // Comma separated values ("1,2,3")
+	ListJSON                    // JSON array ("[1,2,3]")
+)
+
+// NullList represents a list of values that may be null.
+// List is pointer to slice of any scalar type (default *[]string).
+// Comma separated lists can not contain values with commas, so use
+// ListJSON for arbitrary strings.
+// NullList implements the echo.Codec interface, so it can be used
+// as codec of form fields (values are separated by comma in the form):
+//   &widget.FormText{Codec: &sql.NullList{List: new([]int), Format: sql.ListJSON}}
+type NullList struct {
+	List   interface{}
+	Valid  bool       // Valid is true if List is not NULL
+	Format ListFormat // Format of list in the database
+}
+
+func (n *NullList) Scan(value interface{}) error {
+	if value == nil {
+		n.Valid = false
+		if n.List != nil {
+			reflect.ValueOf(n.List).Elem().Set(reflect.Zero(reflect.TypeOf(n.List).Elem()))
+		}
+		return nil
+	}
+	if v, ok := value.(NullList); ok {
+		*n = v
+		return nil
+	}
+	if n.List == nil {
+		n.List = new([]string)
+	}
+	n.Valid = true
+	return scanList(n.List, n.Format, value)
+}
+
+func (n NullList) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return listValue(n.List, n.Format)
+}
+
+func (n NullList) Internal() driver.Value {
+	value, _ := n.Value()
+	return value
+}
+
+func (n NullList) External() interface{} {
+	if !n.Valid || n.List == nil {
+		return nil
+	}
+	return reflect.ValueOf(n.List).Elem().Interface()
+}
+
+func (n *NullList) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(n.List)
+}
+
+func (n *NullList) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return n.Scan(nil)
+	}
+	if n.List == nil {
+		n.List = new([]string)
+	}
+	n.Valid = true
+	return json.Unmarshal(data, n.List)
+}
+
+func (n *NullList) Empty(ctx echo.Context) (interface{}, error) {
+	return NullList{List: n.newList(), Format: n.Format}, nil
+}
+
+func (n *NullList) Encode(ctx echo.Context, value string) (interface{}, error) {
+	res := NullList{List: n.newList(), Format: n.Format}
+	if strings.TrimSpace(value) == "" {
+		return res, nil
+	}
+	if err := scanList(res.List, ListComma, value); err != nil {
+		return nil, echo.ValidationErrors{
+			echo.ValidationErrorInvalidValue,
+		}
+	}
+	res.Valid = true
+	return res, nil
+}
+
+func (n *NullList) Decode(ctx echo.Context, value interface{}) (string, error) {
+	if v, ok := value.(NullList); ok {
+		if !v.Valid || v.List == nil {
+			return "", nil
+		}
+		return formatList(v.List, ", "), nil
+	}
+	val, _ := generic.ConvertToString(value)
+	return val, nil
+}
+
+// Create new list with the same type.
+func (n *NullList) newList() interface{} {
+	if n.List == nil {
+		return new([]string)
+	}
+	return reflect.New(reflect.TypeOf(n.List).Elem()).Interface()
+}
+
+// NullStrings represents a list of strings that may be null.
+// NullStrings implements the Scanner interface so
+// it can be used as a scan destination, similar to NullString.
+// NullStrings implements the echo.Codec interface, so it can be used
+// as codec of form fields (values are separated by comma in the form).
+type NullStrings struct {
+	Strings []string
+	Valid   bool       // Valid is true if Strings is not NULL
+	Format  ListFormat // Format of list in the database
+}
+
+func (n *NullStrings) Scan(value interface{}) error {
+	if v, ok := value.(NullStrings); ok {
+		*n = v
+		return nil
+	}
+	list := NullList{List: &n.Strings, Format: n.Format}
+	err := list.Scan(value)
+	n.Valid = list.Valid
+	return err
+}
+
+func (n NullStrings) Value() (driver.Value, error) {
+	return NullList{List: &n.Strings, Valid: n.Valid, Format: n.Format}.Value()
+}
+
+func (n NullStrings) Internal() driver.Value {
+	value, _ := n.Value()
+	return value
+}
+
+func (n NullStrings) External() interface{} {
+	if !n.Valid {
+		return nil
+	}
+	return n.Strings
+}
+
+func (n *NullStrings) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(n.Strings)
+}
+
+func (n *NullStrings) UnmarshalJSON(data []byte) error {
+	list := NullList{List: &n.Strings}
+	err := list.UnmarshalJSON(data)
+	n.Valid = list.Valid
+	return err
+}
+
+func (n *NullStrings) Empty(ctx echo.Context) (interface{}, error) {
+	return NullStrings{Format: n.Format}, nil
+}
+
+func (n *NullStrings) Encode(ctx echo.Context, value string) (interface{}, error) {
+	list := NullList{List: new([]string), Format: n.Format}
+	val, err := list.Encode(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	res := val.(NullList)
+	return NullStrings{Strings: *res.List.(*[]string), Valid: res.Valid, Format: n.Format}, nil
+}
+
+func (n *NullStrings) Decode(ctx echo.Context, value interface{}) (string, error) {
+	if v, ok := value.(NullStrings); ok {
+		value = NullList{List: &v.Strings, Valid: v.Valid, Format: v.Format}
+	}
+	return new(NullList).Decode(ctx, value)
+}
+
+// NullInts represents a list of integers that may be null.
+// NullInts implements the Scanner interface so
+// it can be used as a scan destination, similar to NullString.
+// NullInts implements the echo.Codec interface, so it can be used
+// as codec of form fields (values are separated by comma in the form).
+type NullInts struct {
+	Ints   []int
+	Valid  bool       // Valid is true if Ints is not NULL
+	Format ListFormat // Format of list in the database
+}
+
+func (n *NullInts) Scan(value interface{}) error {
+	if v, ok := value.(NullInts); ok {
+		*n = v
+		return nil
+	}
+	list := NullList{List: &n.Ints, Format: n.Format}
+	err := list.Scan(value)
+	n.Valid = list.Valid
+	return err
+}
+
+func (n NullInts) Value() (driver.Value, error) {
+	return NullList{List: &n.Ints, Valid: n.Valid, Format: n.Format}.Value()
+}
+
+func (n NullInts) Internal() driver.Value {
+	value, _ := n.Value()
+	return value
+}
+
+func (n NullInts) External() interface{} {
+	if !n.Valid {
+		return nil
+	}
+	return n.Ints
+}
+
+func (n *NullInts) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(n.Ints)
+}
+
+func (n *NullInts) UnmarshalJSON(data []byte) error {
+	list := NullList{List: &n.Ints}
+	err := list.UnmarshalJSON(data)
+	n.Valid = list.Valid
+	return err
+}
+
+func (n *NullInts) Empty(ctx echo.Context) (interface{}, error) {
+	return NullInts{Format: n.Format}, nil
+}
+
+func (n *NullInts) Encode(ctx echo.Context, value string) (interface{}, error) {
+	list := NullList{List: new([]int), Format: n.Format}
+	val, err := list.Encode(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	res := val.(NullList)
+	return NullInts{Ints: *res.List.(*[]int), Valid: res.Valid, Format: n.Format}, nil
+}
+
+func (n *NullInts) Decode(ctx echo.Context, value interface{}) (string, error) {
+	if v, ok := value.(NullInts); ok {
+		value = NullList{List: &v.Ints, Valid: v.Valid, Format: v.Format}
+	}
+	return new(NullList).Decode(ctx, value)
+}
+
+// NullInt64s represents a list of int64 that may be null.
+// NullInt64s implements the Scanner interface so
+// it can be used as a scan destination, similar to NullString.
+// NullInt64s implements the echo.Codec interface, so it can be used
+// as codec of form fields (values are separated by comma in the form).
+type NullInt64s struct {
+	Int64s []int64
+	Valid  bool       // Valid is true if Int64s is not NULL
+	Format ListFormat // Format of list in the database
+}
+
+func (n *NullInt64s) Scan(value interface{}) error {
+	if v, ok := value.(NullInt64s); ok {
+		*n = v
+		return nil
+	}
+	list := NullList{List: &n.Int64s, Format: n.Format}
+	err := list.Scan(value)
+	n.Valid = list.Valid
+	return err
+}
+
+func (n NullInt64s) Value() (driver.Value, error) {
+	return NullList{List: &n.Int64s, Valid: n.Valid, Format: n.Format}.Value()
+}
+
+func (n NullInt64s) Internal() driver.Value {
+	value, _ := n.Value()
+	return value
+}
+
+func (n NullInt64s) External() interface{} {
+	if !n.Valid {
+		return nil
+	}
+	return n.Int64s
+}
+
+func (n *NullInt64s) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(n.Int64s)
+}
+
+func (n *NullInt64s) UnmarshalJSON(data []byte) error {
+	list := NullList{List: &n.Int64s}
+	err := list.UnmarshalJSON(data)
+	n.Valid = list.Valid
+	return err
+}
+
+func (n *NullInt64s) Empty(ctx echo.Context) (interface{}, error) {
+	return NullInt64s{Format: n.Format}, nil
+}
+
+func (n *NullInt64s) Encode(ctx echo.Context, value string) (interface{}, error) {
+	list := NullList{List: new([]int64), Format: n.Format}
+	val, err := list.Encode(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	res := val.(NullList)
+	return NullInt64s{Int64s: *res.List.(*[]int64), Valid: res.Valid, Format: n.Format}, nil
+}
+
+func (n *NullInt64s) Decode(ctx echo.Context, value interface{}) (string, error) {
+	if v, ok := value.(NullInt64s); ok {
+		value = NullList{List: &v.Int64s, Valid: v.Valid, Format: v.Format}
+	}
+	return new(NullList).Decode(ctx, value)
+}
+
+// Decode list from the database representation into pointer to slice.
+func scanList(list interface{}, format ListFormat, value interface{}) error {
+	var src string
+	if err := generic.ConvertAssign(&src, value); err != nil {
+		return err
+	}
+
+	if format == ListJSON {
+		return json.Unmarshal([]byte(src), list)
+	}
+
+	slice := reflect.ValueOf(list).Elem()
+	if strings.TrimSpace(src) == "" {
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+		return nil
+	}
+
+	items := strings.Split(src, ",")
+	res := reflect.MakeSlice(slice.Type(), len(items), len(items))
+	for i, item := range items {
+		item = strings.TrimSpace(item)
+		if err := generic.ConvertAssign(res.Index(i).Addr().Interface(), item); err != nil {
+			return fmt.Errorf("sql: invalid item %q of list: %s", item, err)
+		}
+	}
+	slice.Set(res)
+	return nil
+}
+
+// Encode list (pointer to slice or slice) into the database representation.
+func listValue(list interface{}, format ListFormat) (driver.Value, error) {
+	if list == nil {
+		list = []string{}
+	}
+	if format == ListJSON {
+		data, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		if string(data) == "null" {
+			return "[]", nil
+		}
+		return string(data), nil
+	}
+	return formatList(list, ","), nil
+}
+
+func formatList(list interface{}, separator string) string {
+	slice := reflect.Indirect(reflect.ValueOf(list))
+	items := make([]string, slice.Len())
+	for i := range items {
+		items[i], _ = generic.ConvertToString(slice.Index(i).Interface())
+	}
+	return strings.Join(items, separator)
+}
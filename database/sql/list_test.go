@@ -0,0 +1,151 @@
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/widget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullList(t *testing.T) {
+	type Test struct {
+		list   interface{}
+		format ListFormat
+		src    string
+		result interface{}
+		value  string
+	}
+
+	tests := map[string]Test{
+		"Comma strings": {
+			src:    "a, b,c",
+			result: &[]string{"a", "b", "c"},
+			value:  "a,b,c",
+		},
+		"Comma ints": {
+			list:   new([]int),
+			src:    "1,2,3",
+			result: &[]int{1, 2, 3},
+			value:  "1,2,3",
+		},
+		"Comma empty": {
+			list:   new([]int),
+			src:    "",
+			result: &[]int{},
+			value:  "",
+		},
+		"JSON strings": {
+			format: ListJSON,
+			src:    `["a,b","c"]`,
+			result: &[]string{"a,b", "c"},
+			value:  `["a,b","c"]`,
+		},
+		"JSON floats": {
+			list:   new([]float64),
+			format: ListJSON,
+			src:    `[1.5,2]`,
+			result: &[]float64{1.5, 2},
+			value:  `[1.5,2]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := NullList{List: test.list, Format: test.format}
+			require.NoError(t, n.Scan([]byte(test.src)))
+			assert.True(t, n.Valid)
+			assert.Equal(t, test.result, n.List)
+
+			value, err := n.Value()
+			require.NoError(t, err)
+			assert.Equal(t, test.value, value)
+		})
+	}
+
+	n := NullList{List: new([]int)}
+	assert.Error(t, n.Scan("1,x"))
+	require.NoError(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+	value, err := n.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestNullList_Typed(t *testing.T) {
+	ints := NullInts{Format: ListJSON}
+	require.NoError(t, ints.Scan("[3,1]"))
+	assert.Equal(t, NullInts{Ints: []int{3, 1}, Valid: true, Format: ListJSON}, ints)
+	value, err := ints.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "[3,1]", value)
+
+	var ids NullInt64s
+	require.NoError(t, ids.Scan("10,20"))
+	assert.Equal(t, []int64{10, 20}, ids.Int64s)
+
+	var tags NullStrings
+	require.NoError(t, tags.Scan(nil))
+	assert.False(t, tags.Valid)
+	require.NoError(t, json.Unmarshal([]byte(`["x","y"]`), &tags))
+	assert.Equal(t, NullStrings{Strings: []string{"x", "y"}, Valid: true}, tags)
+	value, err = tags.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "x,y", value)
+
+	data, err := json.Marshal(&struct{ Tags *NullStrings }{Tags: &tags})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Tags":["x","y"]}`, string(data))
+}
+
+func TestNullList_Codec(t *testing.T) {
+	var codec echo.Codec = &NullList{List: new([]int), Format: ListJSON}
+
+	val, err := codec.Encode(nil, "1, 2, 3")
+	require.NoError(t, err)
+	assert.Equal(t, NullList{List: &[]int{1, 2, 3}, Valid: true, Format: ListJSON}, val)
+
+	value, err := val.(NullList).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "[1,2,3]", value)
+
+	s, err := codec.Decode(nil, val)
+	require.NoError(t, err)
+	assert.Equal(t, "1, 2, 3", s)
+
+	_, err = codec.Encode(nil, "1, two")
+	assert.Equal(t, echo.ValidationErrors{echo.ValidationErrorInvalidValue}, err)
+
+	empty, err := codec.Empty(nil)
+	require.NoError(t, err)
+	assert.False(t, empty.(NullList).Valid)
+}
+
+func TestNullList_FormText(t *testing.T) {
+	ctx := echo.New().NewContext(nil, nil)
+
+	ints := &widget.FormText{Name: "ids", Codec: new(NullInts), Required: true}
+	require.NoError(t, ints.SetValue(ctx, []string{"3, 1"}))
+	assert.Empty(t, ints.GetErrors())
+	assert.Equal(t, NullInts{Ints: []int{3, 1}, Valid: true}, ints.GetVal())
+	ints.SetVal(ctx, NullInts{Ints: []int{5, 7}, Valid: true})
+	assert.Equal(t, []string{"5, 7"}, ints.GetValue())
+
+	invalid := &widget.FormText{Name: "ids", Codec: new(NullInt64s)}
+	require.NoError(t, invalid.SetValue(ctx, []string{"1, two"}))
+	assert.Equal(t, echo.ValidationErrors{echo.ValidationErrorInvalidValue}, invalid.GetErrors())
+
+	tags := &widget.FormText{Name: "tags", Codec: &NullStrings{Format: ListJSON}}
+	require.NoError(t, tags.SetValue(ctx, []string{""}))
+	assert.Equal(t, NullStrings{Format: ListJSON}, tags.GetVal())
+	require.NoError(t, tags.SetValue(ctx, []string{"a, b"}))
+	tagsVal := tags.GetVal().(NullStrings)
+	assert.Equal(t, []string{"a", "b"}, tagsVal.External())
+	assert.Equal(t, `["a","b"]`, tagsVal.Internal())
+
+	assert.Nil(t, NullInt64s{}.External())
+	assert.Nil(t, NullInt64s{}.Internal())
+	assert.Equal(t, "10,20", NullInt64s{Int64s: []int64{10, 20}, Valid: true}.Internal())
+}
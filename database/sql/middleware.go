@@ -410,14 +410,18 @@ func open(
 		if err != nil {
 			return nil, err
 		}
-		return &database1{
+		res := &database1{
 			db:       db,
 			dsc:      dsc,
 			adapter:  adapter,
 			dbId:     dbId,
-			composer: composer{stop: make(chan struct{})},
+			composer: &composer{stop: make(chan struct{})},
 			Metrics:  new(Metrics),
-		}, nil
+		}
+		if dsc.StmtCache > 0 {
+			res.stmts = newStmtCache(dsc.StmtCache, res.PrepareContext)
+		}
+		return res, nil
 	}
 
 	adapter, err := adapters.find(dsc.Driver)
@@ -426,25 +430,53 @@ func open(
 	}
 
 	db := &database2{
-		pdbs:     make([]*sql.DB, len(dsc.DSN)),
+		nodes:    make([]*database1, len(dsc.DSN)),
+		health:   make([]*health, len(dsc.DSN)),
+		policy:   dsc.Policy,
 		dsc:      dsc,
 		dbId:     dbId,
 		adapter:  adapter,
-		composer: composer{stop: make(chan struct{})},
+		composer: &composer{stop: make(chan struct{})},
 		Metrics:  new(Metrics),
 	}
 
 	err = scatter(
-		len(db.pdbs),
-		func(i int) (err error) {
-			db.pdbs[i], _, err = dsc.DSN[0].openSQL(dsc.Driver)
-			return err
+		len(db.nodes),
+		func(i int) error {
+			pdb, _, err := dsc.DSN[i].openSQL(dsc.Driver)
+			if err != nil {
+				return err
+			}
+			db.nodes[i] = &database1{
+				db: pdb,
+				dsc: DSC{
+					Driver: dsc.Driver,
+					DbId:   dsc.DbId,
+					DSN:    []*DSN{dsc.DSN[i]},
+				},
+				adapter:  adapter,
+				dbId:     dbId,
+				node:     true,
+				composer: db.composer,
+				Metrics:  db.Metrics,
+			}
+			db.health[i] = newHealth(dsc.DSN[i].Weight)
+			return nil
 		},
 	)
 	if err != nil {
+		for _, node := range db.nodes {
+			if node != nil {
+				_ = node.db.Close()
+			}
+		}
 		return nil, err
 	}
 
+	if dsc.StmtCache > 0 {
+		db.stmts = newStmtCache(dsc.StmtCache, db.PrepareContext)
+	}
+
 	return db, nil
 }
 
@@ -0,0 +1,126 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tracer []string
+
+func (t *tracer) Trace(msg interface{}) {
+	*t = append(*t, msg.(string))
+}
+
+func TestProfiler(t *testing.T) {
+	mock := sqltest.New()
+	defer mock.Close()
+	mock.ExpectExec("UPDATE users SET name = ?").WithArgs("Bob")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT 1")
+	mock.ExpectCommit()
+
+	var trace tracer
+	db, err := sqltest.Open(sql.OpenWithProfiler(&trace, "  ", nil), mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	_, err = db.Exec("UPDATE users SET name = ?", "Bob")
+	require.NoError(t, err)
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	rows, err := tx.Query("SELECT 1")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, trace, 4)
+	assert.True(t, strings.HasPrefix(trace[0], "SQL: Elapsed time "))
+	assert.True(t, strings.HasSuffix(trace[0], "\n  UPDATE users SET name = ?\n  Args: [Bob]"), trace[0])
+	assert.True(t, strings.HasSuffix(trace[2], "\n  SELECT 1"), trace[2])
+}
+
+func TestTransact_Deadlock(t *testing.T) {
+	policy := sql.TransactRetryPolicy
+	defer func() { sql.TransactRetryPolicy = policy }()
+	sql.TransactRetryPolicy = sql.RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	mock := sqltest.New()
+	defer mock.Close()
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE counters SET value = value + 1")
+		mock.ExpectCommit().WillReturnError(sqltest.ErrDeadlock)
+	}
+
+	db, err := sqltest.Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	attempts := 0
+	err = db.Transact(context.Background(), nil, func(tx sql.Tx) error {
+		attempts++
+		_, err := tx.Exec("UPDATE counters SET value = value + 1")
+		return err
+	})
+	assert.Equal(t, sqltest.ErrDeadlock, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, int32(1), db.GetMetrics().Retries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Errors except deadlocks are not retried.
+	errAction := errors.New("action")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = db.Transact(context.Background(), nil, func(tx sql.Tx) error {
+		return errAction
+	})
+	assert.Equal(t, errAction, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCluster_Failover(t *testing.T) {
+	master := sqltest.New()
+	defer master.Close()
+	replica := sqltest.New()
+	defer replica.Close()
+
+	db, err := sqltest.Open(nil, master, replica)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	cluster, ok := sql.AsCluster(db)
+	require.True(t, ok)
+
+	replica.ExpectQuery("SELECT COUNT(*) FROM users").WillReturnRows(sqltest.NewRows("count").AddRow(1))
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	replica.SetDown(true)
+	require.NoError(t, cluster.Check(context.Background()))
+	assert.False(t, cluster.Nodes()[1].Healthy)
+
+	master.ExpectQuery("SELECT COUNT(*) FROM users").WillReturnRows(sqltest.NewRows("count").AddRow(2))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	replica.SetDown(false)
+	require.NoError(t, cluster.Check(context.Background()))
+	assert.True(t, cluster.Nodes()[1].Healthy)
+
+	master.SetDown(true)
+	assert.Equal(t, sqltest.ErrConnLost, cluster.Check(context.Background()))
+
+	assert.NoError(t, master.ExpectationsWereMet())
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
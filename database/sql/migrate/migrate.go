@@ -0,0 +1,304 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrate applies versioned schema migrations to database.
+//
+// SQL migrations are pairs of files "<version>_<name>.up.sql" and
+// "<version>_<name>.down.sql" (down file is optional). Go migrations
+// are registered by Func. Applied versions are recorded in table
+// together with checksum of the up script, so modified files are detected.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+var (
+	ErrIrreversible     = errors.New("migrate: migration is irreversible")
+	ErrDuplicateVersion = errors.New("migrate: duplicate version of migration")
+	ErrNoMigration      = errors.New("migrate: no migration to redo")
+)
+
+// ChecksumError is returned, when script of applied migration was modified.
+type ChecksumError struct {
+	Version int64
+	Name    string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("migrate: checksum mismatch of applied migration %d_%s", e.Version, e.Name)
+}
+
+// Action of Go migration.
+type Action func(ctx context.Context, tx sql.Tx) error
+
+// Migration is single step of schema evolution.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       []string // Statements of up script
+	Down     []string // Statements of down script
+	UpFunc   Action   // Go alternative of Up
+	DownFunc Action   // Go alternative of Down
+	Checksum string   // Checksum of up script (empty for Go migrations)
+}
+
+func (m *Migration) reversible() bool {
+	return m.Down != nil || m.DownFunc != nil
+}
+
+// Func creates Go migration. Argument down can be nil.
+func Func(version int64, name string, up, down Action) *Migration {
+	return &Migration{
+		Version:  version,
+		Name:     name,
+		UpFunc:   up,
+		DownFunc: down,
+	}
+}
+
+// Script creates SQL migration from texts of up and down scripts.
+// Empty down means irreversible migration.
+func Script(version int64, name string, up, down string) *Migration {
+	m := &Migration{
+		Version:  version,
+		Name:     name,
+		Up:       Split(up),
+		Checksum: checksum(up),
+	}
+	if strings.TrimSpace(down) != "" {
+		m.Down = Split(down)
+	}
+	return m
+}
+
+// LoadDir loads SQL migrations from directory.
+func LoadDir(dir string) ([]*Migration, error) {
+	return Load(http.Dir(dir), "/")
+}
+
+// Load loads SQL migrations from directory of file system.
+// Files with other names are ignored.
+func Load(fs http.FileSystem, dir string) ([]*Migration, error) {
+	d, err := fs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	infos, err := d.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	type pair struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+
+	pairs := make(map[int64]*pair, len(infos))
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		version, name, direction, ok := parseName(info.Name())
+		if !ok {
+			continue
+		}
+
+		text, err := readFile(fs, path.Join(dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		p, ok := pairs[version]
+		if !ok {
+			p = &pair{name: name}
+			pairs[version] = p
+		} else if p.name != name {
+			return nil, fmt.Errorf("%s: %d", ErrDuplicateVersion, version)
+		}
+
+		if direction == "up" {
+			p.up = text
+			p.hasUp = true
+		} else {
+			p.down = text
+		}
+	}
+
+	res := make([]*Migration, 0, len(pairs))
+	for version, p := range pairs {
+		if !p.hasUp {
+			return nil, fmt.Errorf("migrate: up script of migration %d_%s is not found", version, p.name)
+		}
+		res = append(res, Script(version, p.name, p.up, p.down))
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+
+	return res, nil
+}
+
+func readFile(fs http.FileSystem, name string) (string, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Parse name of file "<version>_<name>.<up|down>.sql".
+func parseName(filename string) (version int64, name, direction string, ok bool) {
+	if !strings.HasSuffix(filename, ".sql") {
+		return
+	}
+	base := strings.TrimSuffix(filename, ".sql")
+
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	pos := strings.IndexByte(base, '_')
+	if pos <= 0 {
+		return
+	}
+
+	version, err := strconv.ParseInt(base[:pos], 10, 64)
+	if err != nil || version <= 0 {
+		return
+	}
+
+	return version, base[pos+1:], direction, true
+}
+
+func checksum(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Split script into statements, separated by semicolon.
+// Semicolons inside of quotes, comments and dollar-quoted
+// bodies (PostgreSQL) are kept.
+func Split(script string) []string {
+	var res []string
+	start := 0
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(script, i, c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			i = skipUntil(script, i, "\n")
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			i = skipUntil(script, i+2, "*/")
+		case c == '$':
+			if tag := dollarTag(script[i:]); tag != "" {
+				i = skipUntil(script, i+len(tag), tag)
+			}
+		case c == ';':
+			res = appendStatement(res, script[start:i])
+			start = i + 1
+		}
+	}
+	return appendStatement(res, script[start:])
+}
+
+func appendStatement(list []string, stmt string) []string {
+	if isBlank(stmt) {
+		return list
+	}
+	return append(list, strings.TrimSpace(stmt))
+}
+
+// Check, that statement has no code besides comments.
+func isBlank(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns index of closing quote.
+func skipQuoted(s string, i int, quote byte) int {
+	for i++; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+		if s[i] == '\\' && quote != '`' {
+			i++
+		}
+	}
+	return len(s)
+}
+
+// Returns index of the last byte of terminator.
+func skipUntil(s string, i int, terminator string) int {
+	pos := strings.Index(s[i:], terminator)
+	if pos < 0 {
+		return len(s)
+	}
+	return i + pos + len(terminator) - 1
+}
+
+// Returns tag "$...$", if s starts with it.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,314 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tracer struct {
+	messages []string
+}
+
+func (t *tracer) Trace(msg interface{}) {
+	t.messages = append(t.messages, msg.(string))
+}
+
+const (
+	createTable = `CREATE TABLE IF NOT EXISTS "schema_migrations" (` +
+		"version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, " +
+		"checksum VARCHAR(64) NOT NULL, applied_at BIGINT NOT NULL)"
+	selectApplied = `SELECT version, name, checksum, applied_at FROM "schema_migrations"`
+	insertVersion = `INSERT INTO "schema_migrations" (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`
+	deleteVersion = `DELETE FROM "schema_migrations" WHERE version = ?`
+)
+
+// Open fake database with single connection, that is enough for migrator.
+func openTest(t *testing.T) (sql.DB, *sqltest.Mock) {
+	mock := sqltest.New()
+	db, err := sqltest.Open(nil, mock)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	return db, mock
+}
+
+// Rows of table of applied migrations.
+func appliedRows(migrations ...*Migration) *sqltest.Rows {
+	rows := sqltest.NewRows("version", "name", "checksum", "applied_at")
+	for _, m := range migrations {
+		rows.AddRow(m.Version, m.Name, m.Checksum, 1500000000)
+	}
+	return rows
+}
+
+// Expect capture of latch and verification of applied migrations.
+func expectLatch(mock *sqltest.Mock, applied *sqltest.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL migrations")
+	mock.ExpectExec(createTable)
+	mock.ExpectQuery(selectApplied).WillReturnRows(applied)
+}
+
+// Expect release of latch and completion of transaction.
+func expectUnlatch(mock *sqltest.Mock, commit bool) {
+	mock.ExpectExec("UNLOCK GLOBAL migrations")
+	if commit {
+		mock.ExpectCommit()
+	} else {
+		mock.ExpectRollback()
+	}
+}
+
+func TestSplit(t *testing.T) {
+	type Test struct {
+		src string
+		dst []string
+	}
+
+	tests := map[string]Test{
+		"Empty": {
+			src: " \n-- comment\n",
+		},
+		"Simple": {
+			src: "CREATE TABLE a (id INT);\nDROP TABLE b;",
+			dst: []string{"CREATE TABLE a (id INT)", "DROP TABLE b"},
+		},
+		"Quotes": {
+			src: "INSERT INTO a VALUES ('x;y', \"z;\", `w;`, 'it''s;')",
+			dst: []string{"INSERT INTO a VALUES ('x;y', \"z;\", `w;`, 'it''s;')"},
+		},
+		"Comments": {
+			src: "-- first;\nSELECT 1; /* second; */ SELECT 2",
+			dst: []string{"-- first;\nSELECT 1", "/* second; */ SELECT 2"},
+		},
+		"Dollar quoting": {
+			src: "CREATE FUNCTION f() AS $body$ BEGIN; END; $body$ LANGUAGE plpgsql; SELECT $1",
+			dst: []string{"CREATE FUNCTION f() AS $body$ BEGIN; END; $body$ LANGUAGE plpgsql", "SELECT $1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.dst, Split(test.src))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"002_users.up.sql":     "CREATE TABLE users (id INT);",
+		"002_users.down.sql":   "DROP TABLE users;",
+		"001_init.up.sql":      "CREATE TABLE a (id INT); CREATE TABLE b (id INT);",
+		"003_seed.up.sql":      "INSERT INTO users VALUES (1);",
+		"README.md":            "ignored",
+		"x_invalid.up.sql":     "ignored",
+		"004_orphan.down.txt":  "ignored",
+		"005_without_up.sql":   "ignored",
+		"006_another.down.sq1": "ignored",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	migrations, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+	assert.Equal(t, []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}, migrations[0].Up)
+	assert.False(t, migrations[0].reversible())
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, []string{"DROP TABLE users"}, migrations[1].Down)
+	assert.Equal(t, checksum(files["002_users.up.sql"]), migrations[1].Checksum)
+	assert.Equal(t, "seed", migrations[2].Name)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "007_lost.down.sql"), []byte("x"), 0644))
+	_, err = LoadDir(dir)
+	assert.Error(t, err)
+}
+
+func TestMigrator(t *testing.T) {
+	db, mock := openTest(t)
+	defer mock.Close()
+	defer db.Close(context.Background())
+	ctx := context.Background()
+
+	var called []string
+	m := New(db, Options{})
+	require.NoError(t, m.Add(
+		Script(1, "init", "CREATE TABLE a (id INT)", "DROP TABLE a"),
+		Func(
+			2, "seed",
+			func(ctx context.Context, tx sql.Tx) error {
+				called = append(called, "up")
+				return nil
+			},
+			func(ctx context.Context, tx sql.Tx) error {
+				called = append(called, "down")
+				return nil
+			},
+		),
+		Script(3, "users", "CREATE TABLE users (id INT); CREATE INDEX i ON users (id)", ""),
+	))
+	assert.Error(t, m.Add(Script(3, "duplicate", "", "")))
+	first, seed, users := m.migrations[0], m.migrations[1], m.migrations[2]
+
+	// Statements and versions are written by holder of latch
+	expectLatch(mock, appliedRows())
+	mock.ExpectExec("CREATE TABLE a (id INT)")
+	mock.ExpectExec(insertVersion).WithArgs(1, "init", first.Checksum, sqltest.AnyArg)
+	mock.ExpectExec(insertVersion).WithArgs(2, "seed", "", sqltest.AnyArg)
+	expectUnlatch(mock, true)
+	require.NoError(t, m.Up(ctx, 2))
+	assert.Equal(t, []string{"up"}, called)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	expectLatch(mock, appliedRows(first, seed))
+	mock.ExpectExec("CREATE TABLE users (id INT)")
+	mock.ExpectExec("CREATE INDEX i ON users (id)")
+	mock.ExpectExec(insertVersion).WithArgs(3, "users", users.Checksum, sqltest.AnyArg)
+	expectUnlatch(mock, true)
+	require.NoError(t, m.Up(ctx, 0))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectExec(createTable)
+	mock.ExpectQuery(selectApplied).WillReturnRows(appliedRows(first, seed, users))
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	for _, s := range status {
+		assert.True(t, s.Applied, s.Version)
+		assert.False(t, s.Modified, s.Version)
+	}
+
+	// Migration 3 is irreversible
+	expectLatch(mock, appliedRows(first, seed, users))
+	expectUnlatch(mock, false)
+	assert.Equal(t, ErrIrreversible.Error()+": 3_users", m.Down(ctx, 1).Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	users.Down = []string{"DROP TABLE users"}
+	expectLatch(mock, appliedRows(first, seed, users))
+	mock.ExpectExec("DROP TABLE users")
+	mock.ExpectExec(deleteVersion).WithArgs(3)
+	mock.ExpectExec("CREATE TABLE users (id INT)")
+	mock.ExpectExec("CREATE INDEX i ON users (id)")
+	mock.ExpectExec(insertVersion).WithArgs(3, "users", users.Checksum, sqltest.AnyArg)
+	expectUnlatch(mock, true)
+	require.NoError(t, m.Redo(ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	expectLatch(mock, appliedRows(first, seed, users))
+	mock.ExpectExec("DROP TABLE users")
+	mock.ExpectExec(deleteVersion).WithArgs(3)
+	mock.ExpectExec(deleteVersion).WithArgs(2)
+	expectUnlatch(mock, true)
+	require.NoError(t, m.Down(ctx, 2))
+	assert.Equal(t, []string{"up", "down"}, called)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Checksum(t *testing.T) {
+	db, mock := openTest(t)
+	defer mock.Close()
+	defer db.Close(context.Background())
+	ctx := context.Background()
+
+	m := New(db, Options{})
+	require.NoError(t, m.Add(Script(1, "init", "CREATE TABLE a (id INT)", "")))
+	modified := New(db, Options{})
+	require.NoError(t, modified.Add(Script(1, "init", "CREATE TABLE a (id BIGINT)", "")))
+	applied := func() *sqltest.Rows {
+		return appliedRows(m.migrations[0])
+	}
+
+	mock.ExpectExec(createTable)
+	mock.ExpectQuery(selectApplied).WillReturnRows(applied())
+	status, err := modified.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Modified)
+
+	expectLatch(mock, applied())
+	expectUnlatch(mock, false)
+	err = modified.Up(ctx, 0)
+	assert.Equal(t, &ChecksumError{Version: 1, Name: "init"}, err)
+
+	expectLatch(mock, applied())
+	expectUnlatch(mock, false)
+	assert.Equal(t, err, modified.Verify(ctx))
+
+	missing := New(db, Options{})
+	mock.ExpectExec(createTable)
+	mock.ExpectQuery(selectApplied).WillReturnRows(applied())
+	status, err = missing.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Missing)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Rollback(t *testing.T) {
+	db, mock := openTest(t)
+	defer mock.Close()
+	defer db.Close(context.Background())
+	ctx := context.Background()
+
+	m := New(db, Options{})
+	require.NoError(t, m.Add(Script(1, "broken", "CREATE TABLE a (id INT); FAIL", "")))
+
+	expectLatch(mock, appliedRows())
+	mock.ExpectExec("CREATE TABLE a (id INT)")
+	mock.ExpectExec("FAIL").WillReturnError(errors.New("failed"))
+	expectUnlatch(mock, false)
+	assert.EqualError(t, m.Up(ctx, 0), "failed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	db, mock := openTest(t)
+	defer mock.Close()
+	defer db.Close(context.Background())
+	ctx := context.Background()
+
+	tr := new(tracer)
+	m := New(db, Options{DryRun: true, Tracer: tr})
+	require.NoError(t, m.Add(
+		Script(1, "init", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);", ""),
+		Func(2, "seed", func(ctx context.Context, tx sql.Tx) error {
+			t.Fatal("function is called in dry run")
+			return nil
+		}, nil),
+	))
+
+	// Absent table means empty database
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL migrations")
+	mock.ExpectSavepoint("trans1")
+	mock.ExpectQuery(selectApplied).WillReturnError(errors.New("no such table"))
+	mock.ExpectRollbackTo("trans1")
+	expectUnlatch(mock, true)
+	require.NoError(t, m.Up(ctx, 0))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []string{
+		"-- up 1_init",
+		"CREATE TABLE a (id INT);",
+		"CREATE TABLE b (id INT);",
+		"-- up 2_seed",
+		"-- go function",
+	}, tr.messages)
+}
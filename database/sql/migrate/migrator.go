@@ -0,0 +1,410 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+type Options struct {
+	Table   string     // Table of applied versions (default "schema_migrations")
+	Latch   string     // Name of global latch (default "migrations")
+	Timeout int        // Timeout of latch capture in seconds (default 60, negative means infinite)
+	DryRun  bool       // Print statements through Tracer instead of execution
+	Tracer  sql.Tracer // Receiver of executed statements (optional)
+}
+
+// Status of migration.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // Migration is applied, but it is not registered
+	Modified  bool // Script is modified after applying
+}
+
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt int64
+}
+
+// Migrator applies migrations to database.
+// Only one instance of migrator works at same time, because
+// all operations capture global latch of database.
+// Migrations are applied on connection, that holds the latch, within
+// single transaction, so one connection of pool is enough. Databases
+// with transactional DDL revert all migrations of call on failure.
+type Migrator struct {
+	db         sql.DB
+	options    Options
+	migrations []*Migration // Sorted by version
+}
+
+// Add migrations.
+func (m *Migrator) Add(migrations ...*Migration) error {
+	for _, migration := range migrations {
+		if m.find(migration.Version) != nil {
+			return fmt.Errorf("%s: %d", ErrDuplicateVersion, migration.Version)
+		}
+		m.migrations = append(m.migrations, migration)
+	}
+
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+
+	return nil
+}
+
+// Status returns states of all known and applied migrations ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	ctx = sql.WithMaster(ctx)
+	if !m.options.DryRun {
+		if err := m.createTable(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Status, 0, len(m.migrations)+len(applied))
+	for _, migration := range m.migrations {
+		status := Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if rec, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = time.Unix(rec.appliedAt, 0)
+			status.Modified = migration.Checksum != "" && migration.Checksum != rec.checksum
+		}
+		res = append(res, status)
+	}
+
+	for _, rec := range applied {
+		if m.find(rec.version) == nil {
+			res = append(res, Status{
+				Version:   rec.version,
+				Name:      rec.name,
+				Applied:   true,
+				AppliedAt: time.Unix(rec.appliedAt, 0),
+				Missing:   true,
+			})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+
+	return res, nil
+}
+
+// Verify checksums of applied migrations.
+func (m *Migrator) Verify(ctx context.Context) error {
+	return m.exclusive(ctx, func(ctx context.Context, tx sql.Tx, applied map[int64]*record) error {
+		return nil
+	})
+}
+
+// Up applies pending migrations with versions up to target
+// (all pending migrations, if target is zero).
+func (m *Migrator) Up(ctx context.Context, target int64) error {
+	return m.exclusive(ctx, func(ctx context.Context, tx sql.Tx, applied map[int64]*record) error {
+		for _, migration := range m.migrations {
+			if target > 0 && migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, tx, migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts steps of the last applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.exclusive(ctx, func(ctx context.Context, tx sql.Tx, applied map[int64]*record) error {
+		for _, migration := range m.lastApplied(applied, steps) {
+			if err := m.apply(ctx, tx, migration, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Redo reverts and applies again the last applied migration.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.exclusive(ctx, func(ctx context.Context, tx sql.Tx, applied map[int64]*record) error {
+		list := m.lastApplied(applied, 1)
+		if len(list) == 0 {
+			return ErrNoMigration
+		}
+
+		if err := m.apply(ctx, tx, list[0], false); err != nil {
+			return err
+		}
+		return m.apply(ctx, tx, list[0], true)
+	})
+}
+
+// Get the last steps applied migrations in reverse order.
+// Applied migrations, that are not registered, are ignored.
+func (m *Migrator) lastApplied(applied map[int64]*record, steps int) []*Migration {
+	var res []*Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(res) < steps; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			res = append(res, m.migrations[i])
+		}
+	}
+	return res
+}
+
+// Execute action under global latch after verification of checksums.
+// Latch is bound to connection, so action works within transaction,
+// that holds the latch. Transaction is committed after release of latch.
+func (m *Migrator) exclusive(
+	ctx context.Context,
+	action func(ctx context.Context, tx sql.Tx, applied map[int64]*record) error,
+) error {
+	ctx = sql.WithMaster(ctx)
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	adapter := m.db.Adapter()
+	err = adapter.LockGlobal(ctx, tx, m.options.Latch, m.options.Timeout)
+	if err != nil {
+		return err
+	}
+
+	err = m.verify(ctx, tx, action)
+	if e := adapter.UnlockGlobal(ctx, tx, m.options.Latch); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Verify checksums of applied migrations and execute action.
+func (m *Migrator) verify(
+	ctx context.Context,
+	tx sql.Tx,
+	action func(ctx context.Context, tx sql.Tx, applied map[int64]*record) error,
+) error {
+	if !m.options.DryRun {
+		if err := m.createTable(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range applied {
+		migration := m.find(rec.version)
+		if migration != nil && migration.Checksum != "" && migration.Checksum != rec.checksum {
+			return &ChecksumError{Version: rec.version, Name: rec.name}
+		}
+	}
+
+	return action(ctx, tx, applied)
+}
+
+func (m *Migrator) apply(ctx context.Context, tx sql.Tx, migration *Migration, up bool) error {
+	direction := "up"
+	stmts, fn := migration.Up, migration.UpFunc
+	if !up {
+		if !migration.reversible() {
+			return fmt.Errorf("%s: %d_%s", ErrIrreversible, migration.Version, migration.Name)
+		}
+		direction = "down"
+		stmts, fn = migration.Down, migration.DownFunc
+	}
+
+	m.trace(fmt.Sprintf("-- %s %d_%s", direction, migration.Version, migration.Name))
+	if m.options.DryRun {
+		for _, stmt := range stmts {
+			m.trace(stmt + ";")
+		}
+		if fn != nil {
+			m.trace("-- go function")
+		}
+		return nil
+	}
+
+	for _, stmt := range stmts {
+		m.trace(stmt + ";")
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if fn != nil {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if up {
+		return m.insert(ctx, tx, migration)
+	}
+	return m.delete(ctx, tx, migration)
+}
+
+func (m *Migrator) createTable(ctx context.Context, scope sql.Scope) error {
+	_, err := scope.ExecContext(
+		ctx,
+		"CREATE TABLE IF NOT EXISTS "+m.table()+" ("+
+			"version BIGINT NOT NULL PRIMARY KEY, "+
+			"name VARCHAR(255) NOT NULL, "+
+			"checksum VARCHAR(64) NOT NULL, "+
+			"applied_at BIGINT NOT NULL)",
+	)
+	return err
+}
+
+// Fetch applied migrations.
+// In dry run mode table can be absent, that means empty database.
+// Query is isolated by nested transaction, because failed query
+// aborts the whole transaction in some databases.
+func (m *Migrator) applied(ctx context.Context, scope sql.Scope) (map[int64]*record, error) {
+	if !m.options.DryRun {
+		return m.fetch(ctx, scope)
+	}
+
+	var res map[int64]*record
+	err := scope.Transact(ctx, nil, func(tx sql.Tx) error {
+		var err error
+		res, err = m.fetch(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return map[int64]*record{}, nil
+	}
+	return res, nil
+}
+
+func (m *Migrator) fetch(ctx context.Context, scope sql.Scope) (map[int64]*record, error) {
+	rows, err := scope.QueryContext(
+		ctx,
+		"SELECT version, name, checksum, applied_at FROM "+m.table(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[int64]*record, 64)
+	for rows.Next() {
+		rec := new(record)
+		err := rows.Scan(&rec.version, &rec.name, &rec.checksum, &rec.appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		res[rec.version] = rec
+	}
+
+	return res, rows.Err()
+}
+
+func (m *Migrator) insert(ctx context.Context, scope sql.Scope, migration *Migration) error {
+	_, err := scope.ExecContext(
+		ctx,
+		sql.Rebind(
+			scope.Adapter(),
+			"INSERT INTO "+m.table()+" (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		),
+		migration.Version,
+		migration.Name,
+		migration.Checksum,
+		time.Now().Unix(),
+	)
+	return err
+}
+
+func (m *Migrator) delete(ctx context.Context, scope sql.Scope, migration *Migration) error {
+	_, err := scope.ExecContext(
+		ctx,
+		sql.Rebind(scope.Adapter(), "DELETE FROM "+m.table()+" WHERE version = ?"),
+		migration.Version,
+	)
+	return err
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) table() string {
+	return m.db.Adapter().QuoteIdentifier(m.options.Table)
+}
+
+func (m *Migrator) trace(msg string) {
+	if m.options.Tracer != nil {
+		m.options.Tracer.Trace(strings.TrimSpace(msg))
+	}
+}
+
+// New creates migrator of database.
+func New(db sql.DB, options Options) *Migrator {
+	if options.Table == "" {
+		options.Table = "schema_migrations"
+	}
+	if options.Latch == "" {
+		options.Latch = "migrations"
+	}
+	if options.Timeout == 0 {
+		options.Timeout = 60
+	}
+
+	return &Migrator{
+		db:      db,
+		options: options,
+	}
+}
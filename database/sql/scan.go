@@ -0,0 +1,416 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Select executes query and scans all rows into dest (see ScanAll).
+// If the single argument has type Args, named parameters of query
+// are bound (see Named).
+func Select(
+	ctx context.Context,
+	scope Scope,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	query, args, err := bindArgs(scope.Adapter(), query, args)
+	if err != nil {
+		return err
+	}
+
+	rows, err := scope.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return ScanAll(rows, dest)
+}
+
+// Get executes query and scans the first row into dest, that is
+// pointer to struct or to scalar. Returns ErrNoRows, if result is empty.
+func Get(
+	ctx context.Context,
+	scope Scope,
+	dest interface{},
+	query string,
+	args ...interface{},
+) error {
+	query, args, err := bindArgs(scope.Adapter(), query, args)
+	if err != nil {
+		return err
+	}
+
+	rows, err := scope.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrNoRows
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrInvalidDest
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	err = scanRow(rows, columns, v.Elem())
+	if err != nil {
+		return err
+	}
+
+	return rows.Err()
+}
+
+// NamedExec executes query with named parameters (see Named).
+func NamedExec(
+	ctx context.Context,
+	scope Scope,
+	query string,
+	arg interface{},
+) (Result, error) {
+	query, args, err := Named(scope.Adapter(), query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return scope.ExecContext(ctx, query, args...)
+}
+
+// ScanAll scans all rows into dest, that is pointer to slice.
+// Elements of slice are structs, pointers to structs or scalars.
+// Columns are mapped to fields of struct by tag "db" (or by name
+// of field in snake case). Fields of embedded structs are included.
+func ScanAll(rows Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrInvalidDest
+	}
+
+	slice := v.Elem()
+	itemType := slice.Type().Elem()
+	isPtr := itemType.Kind() == reflect.Ptr
+	if isPtr {
+		itemType = itemType.Elem()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	slice.SetLen(0)
+	for rows.Next() {
+		item := reflect.New(itemType)
+		err := scanRow(rows, columns, item.Elem())
+		if err != nil {
+			return err
+		}
+
+		if isPtr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanRow(rows Rows, columns []string, v reflect.Value) error {
+	if isScalar(v.Type()) {
+		if len(columns) != 1 {
+			return fmt.Errorf("scalar destination requires single column, but got %d", len(columns))
+		}
+		return rows.Scan(v.Addr().Interface())
+	}
+
+	fields := fieldsOf(v.Type())
+	dest := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := fields[column]
+		if !ok {
+			return fmt.Errorf("missing destination field for column %q", column)
+		}
+		dest[i] = fieldByIndex(v, index).Addr().Interface()
+	}
+
+	return rows.Scan(dest...)
+}
+
+// Get field, allocating nil pointers to embedded structs.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+var (
+	scannerType = reflect.TypeOf((*Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Check, that value of type is scanned as single column.
+func isScalar(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct ||
+		t == timeType ||
+		reflect.PtrTo(t).Implements(scannerType)
+}
+
+// Cache of mapping columns to indexes of fields for each struct type.
+var structFields sync.Map
+
+func fieldsOf(t reflect.Type) map[string][]int {
+	if res, ok := structFields.Load(t); ok {
+		return res.(map[string][]int)
+	}
+
+	res := make(map[string][]int, t.NumField())
+	collectFields(t, nil, res)
+	structFields.Store(t, res)
+	return res
+}
+
+// Outer fields take precedence over fields of embedded structs.
+func collectFields(t reflect.Type, parent []int, res map[string][]int) {
+	var embedded [][]int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && tag == "" && ft.Kind() == reflect.Struct && !isScalar(ft) {
+			if f.PkgPath != "" && f.Type.Kind() == reflect.Ptr {
+				continue // Pointer to unexported struct can not be allocated
+			}
+			embedded = append(embedded, index)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue // Unexported
+		}
+
+		name := tag
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		if _, ok := res[name]; !ok {
+			res[name] = index
+		}
+	}
+
+	for _, index := range embedded {
+		ft := t.Field(index[len(index)-1]).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		collectFields(ft, index, res)
+	}
+}
+
+// Convert "UserID" into "user_id".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var buf strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// Bind named arguments, if args contains single value of type Args.
+func bindArgs(adapter Adapter, query string, args []interface{}) (string, []interface{}, error) {
+	if len(args) == 1 {
+		switch arg := args[0].(type) {
+		case Args, map[string]interface{}:
+			return Named(adapter, query, arg)
+		}
+	}
+	return query, args, nil
+}
+
+// Named replaces named parameters ":name" of query by positional
+// placeholders of adapter and returns list of arguments.
+// Source of values is Args, map[string]interface{} or struct (fields are
+// mapped as in ScanAll). Slices (besides []byte) are expanded into
+// list of placeholders, so "IN (:ids)" is supported.
+// Casts "::type" and parameters inside of quotes are kept.
+func Named(adapter Adapter, query string, arg interface{}) (string, []interface{}, error) {
+	lookup, err := namedSource(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf strings.Builder
+	var args []interface{}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				end = len(query) - i - 2 // Unterminated quote
+			}
+			buf.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			buf.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && isNameChar(query[i+1]):
+			j := i + 1
+			for j < len(query) && isNameChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("missing named argument %q", name)
+			}
+
+			n, err := expandArg(&args, value)
+			if err != nil {
+				return "", nil, fmt.Errorf("argument %q: %s", name, err)
+			}
+			buf.WriteString(strings.TrimSuffix(strings.Repeat("?, ", n), ", "))
+			i = j - 1
+			continue
+		}
+		buf.WriteByte(c)
+	}
+
+	return Rebind(adapter, buf.String()), args, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// Append value (or items of slice) to args and return count of appended values.
+func expandArg(args *[]interface{}, value interface{}) (int, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array ||
+		v.Type().Elem().Kind() == reflect.Uint8 ||
+		v.Type().Implements(valuerType) {
+		*args = append(*args, value)
+		return 1, nil
+	}
+
+	if v.Len() == 0 {
+		return 0, ErrEmptyList
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		*args = append(*args, v.Index(i).Interface())
+	}
+	return v.Len(), nil
+}
+
+func namedSource(arg interface{}) (func(name string) (interface{}, bool), error) {
+	switch a := arg.(type) {
+	case Args:
+		return func(name string) (interface{}, bool) {
+			value, ok := a[name]
+			return value, ok
+		}, nil
+	case map[string]interface{}:
+		return func(name string) (interface{}, bool) {
+			value, ok := a[name]
+			return value, ok
+		}, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(arg))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported source of named arguments %T", arg)
+	}
+
+	fields := fieldsOf(v.Type())
+	return func(name string) (interface{}, bool) {
+		index, ok := fields[name]
+		if !ok {
+			return nil, false
+		}
+		f, ok := fieldValue(v, index)
+		if !ok {
+			return nil, true // Field of nil embedded struct
+		}
+		return f.Interface(), true
+	}, nil
+}
+
+// Get field without allocation of nil pointers.
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
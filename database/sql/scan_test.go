@@ -0,0 +1,181 @@
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/generic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type arrayRows struct {
+	columns []string
+	data    Arrays
+	current Array
+}
+
+func (rows *arrayRows) Err() error                 { return nil }
+func (rows *arrayRows) Close() error               { return nil }
+func (rows *arrayRows) Columns() ([]string, error) { return rows.columns, nil }
+
+func (rows *arrayRows) Next() bool {
+	if len(rows.data) == 0 {
+		return false
+	}
+	rows.current, rows.data = rows.data[0], rows.data[1:]
+	return true
+}
+
+func (rows *arrayRows) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		if s, ok := d.(Scanner); ok {
+			if err := s.Scan(rows.current[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := generic.ConvertAssign(d, rows.current[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ScanAudit struct {
+	Created time.Time
+	Author  NullString `db:"author"`
+}
+
+type scanBase struct {
+	ID int64 `db:"id"`
+}
+
+type scanUser struct {
+	scanBase
+	*ScanAudit
+	UserName string `db:"name"`
+	LastIP   string
+	Secret   string `db:"-"`
+}
+
+func TestScanAll(t *testing.T) {
+	now := time.Now()
+	rows := &arrayRows{
+		columns: []string{"id", "name", "last_ip", "author", "created"},
+		data: Arrays{
+			{int64(1), "alice", "127.0.0.1", "root", now},
+			{int64(2), "bob", "::1", nil, now},
+		},
+	}
+
+	var users []*scanUser
+	require.NoError(t, ScanAll(rows, &users))
+	require.Len(t, users, 2)
+	assert.Equal(t, int64(1), users[0].ID)
+	assert.Equal(t, "alice", users[0].UserName)
+	assert.Equal(t, "127.0.0.1", users[0].LastIP)
+	assert.Equal(t, NullString{String: "root", Valid: true}, users[0].Author)
+	assert.Equal(t, now, users[0].Created)
+	assert.False(t, users[1].Author.Valid)
+
+	var ids []int64
+	rows = &arrayRows{columns: []string{"id"}, data: Arrays{{int64(3)}, {int64(4)}}}
+	require.NoError(t, ScanAll(rows, &ids))
+	assert.Equal(t, []int64{3, 4}, ids)
+
+	rows = &arrayRows{columns: []string{"unknown"}, data: Arrays{{int64(3)}}}
+	assert.Error(t, ScanAll(rows, &users))
+	assert.Equal(t, ErrInvalidDest, ScanAll(rows, users))
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":        "id",
+		"UserID":    "user_id",
+		"LastIP":    "last_ip",
+		"CreatedAt": "created_at",
+		"HTTPCode":  "http_code",
+		"name":      "name",
+	}
+
+	for src, dst := range tests {
+		assert.Equal(t, dst, snakeCase(src), src)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	type Test struct {
+		adapter Adapter
+		query   string
+		arg     interface{}
+		dst     string
+		args    []interface{}
+		err     bool
+	}
+
+	mysql := adapters["mysql"]
+	postgres := adapters["postgres"]
+	tests := map[string]Test{
+		"Map": {
+			adapter: mysql,
+			query:   "SELECT * FROM user WHERE name = :name AND id > :id",
+			arg:     Args{"name": "alice", "id": 1},
+			dst:     "SELECT * FROM user WHERE name = ? AND id > ?",
+			args:    []interface{}{"alice", 1},
+		},
+		"Expand slice": {
+			adapter: postgres,
+			query:   "SELECT * FROM user WHERE id IN (:ids) AND name <> :name",
+			arg:     Args{"ids": []int{1, 2, 3}, "name": "bob"},
+			dst:     "SELECT * FROM user WHERE id IN ($1, $2, $3) AND name <> $4",
+			args:    []interface{}{1, 2, 3, "bob"},
+		},
+		"Bytes are not expanded": {
+			adapter: mysql,
+			query:   "UPDATE file SET body = :body",
+			arg:     Args{"body": []byte("abc")},
+			dst:     "UPDATE file SET body = ?",
+			args:    []interface{}{[]byte("abc")},
+		},
+		"Quotes and casts": {
+			adapter: postgres,
+			query:   "SELECT ':skip', :id::text, \"a:b\" FROM t",
+			arg:     Args{"id": 1},
+			dst:     "SELECT ':skip', $1::text, \"a:b\" FROM t",
+			args:    []interface{}{1},
+		},
+		"Struct": {
+			adapter: mysql,
+			query:   "INSERT INTO user (id, name, author) VALUES (:id, :name, :author)",
+			arg:     &scanUser{scanBase: scanBase{ID: 5}, UserName: "eve"},
+			dst:     "INSERT INTO user (id, name, author) VALUES (?, ?, ?)",
+			args:    []interface{}{int64(5), "eve", nil},
+		},
+		"Missing": {
+			adapter: mysql,
+			query:   "SELECT :unknown",
+			arg:     Args{},
+			err:     true,
+		},
+		"Empty list": {
+			adapter: mysql,
+			query:   "SELECT * FROM user WHERE id IN (:ids)",
+			arg:     Args{"ids": []int{}},
+			err:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, args, err := Named(test.adapter, test.query, test.arg)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.dst, query)
+			assert.Equal(t, test.args, args)
+		})
+	}
+}
@@ -0,0 +1,393 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adverax/echo/generic"
+)
+
+type SlowLogOptions struct {
+	Threshold       time.Duration // Minimal duration of slow query (default 100ms)
+	Tracer          Tracer        // Receiver of slow queries (optional)
+	Explain         bool          // Capture execution plan of slow SELECT
+	ExplainTimeout  time.Duration // Timeout of EXPLAIN (default 5s)
+	Samples         int           // Count of the last durations for percentiles (default 256)
+	MaxFingerprints int           // Limit of tracked fingerprints (default 1000)
+}
+
+// QueryStats is aggregated statistics of queries with same fingerprint.
+type QueryStats struct {
+	Fingerprint string          `json:"fingerprint"`
+	Count       int64           `json:"count"`    // Count of executions
+	Slow        int64           `json:"slow"`     // Count of slow executions
+	Total       time.Duration   `json:"total"`    // Total duration
+	P50         time.Duration   `json:"p50"`      // Median of the last durations
+	P95         time.Duration   `json:"p95"`      // 95th percentile of the last durations
+	Max         time.Duration   `json:"max"`      // Maximal duration
+	Example     string          `json:"example"`  // Text of the last slow query
+	Explain     string          `json:"explain"`  // Execution plan of slow query
+	LastSlow    time.Time       `json:"lastSlow"` // Time of the last slow execution
+	samples     []time.Duration // Ring of the last durations
+	next        int             // Next index in samples
+	explaining  bool
+}
+
+func (stats *QueryStats) add(d time.Duration, limit int) {
+	stats.Count++
+	stats.Total += d
+	if d > stats.Max {
+		stats.Max = d
+	}
+	if len(stats.samples) < limit {
+		stats.samples = append(stats.samples, d)
+	} else {
+		stats.samples[stats.next] = d
+		stats.next = (stats.next + 1) % limit
+	}
+}
+
+func (stats *QueryStats) snapshot() QueryStats {
+	res := *stats
+	res.samples = nil
+	sorted := append([]time.Duration(nil), stats.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	res.P50 = percentile(sorted, 50)
+	res.P95 = percentile(sorted, 95)
+	return res
+}
+
+// Nearest-rank percentile of sorted list.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// SlowLog aggregates statistics of queries by fingerprints and
+// reports queries, that are slower than threshold.
+// It is http.Handler, that renders statistics as JSON:
+//
+//	e.Router().Get("/admin/sql", echo.WrapHandler(slowLog))
+type SlowLog struct {
+	mx      sync.Mutex
+	options SlowLogOptions
+	stats   map[string]*QueryStats
+}
+
+// Stats returns statistics ordered by total duration.
+func (log *SlowLog) Stats() []QueryStats {
+	log.mx.Lock()
+	res := make([]QueryStats, 0, len(log.stats))
+	for _, stats := range log.stats {
+		res = append(res, stats.snapshot())
+	}
+	log.mx.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+		return res[i].Fingerprint < res[j].Fingerprint
+	})
+	return res
+}
+
+// Reset statistics.
+func (log *SlowLog) Reset() {
+	log.mx.Lock()
+	defer log.mx.Unlock()
+
+	log.stats = make(map[string]*QueryStats, 64)
+}
+
+func (log *SlowLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_ = json.NewEncoder(w).Encode(log.Stats())
+}
+
+// Register duration of query. Returns true, if execution plan must be captured.
+func (log *SlowLog) observe(query string, d time.Duration) (fingerprint string, explain bool) {
+	fingerprint = Fingerprint(query)
+	slow := d >= log.options.Threshold
+
+	log.mx.Lock()
+	defer log.mx.Unlock()
+
+	stats, ok := log.stats[fingerprint]
+	if !ok {
+		if len(log.stats) >= log.options.MaxFingerprints {
+			return fingerprint, false
+		}
+		stats = &QueryStats{Fingerprint: fingerprint}
+		log.stats[fingerprint] = stats
+	}
+
+	stats.add(d, log.options.Samples)
+	if !slow {
+		return fingerprint, false
+	}
+
+	stats.Slow++
+	stats.Example = query
+	stats.LastSlow = time.Now()
+
+	explain = log.options.Explain && stats.Explain == "" && !stats.explaining && isSelect(query)
+	if explain {
+		stats.explaining = true
+	}
+	return fingerprint, explain
+}
+
+func (log *SlowLog) explained(fingerprint string, plan string) {
+	log.mx.Lock()
+	defer log.mx.Unlock()
+
+	if stats, ok := log.stats[fingerprint]; ok {
+		stats.Explain = plan
+		stats.explaining = false
+	}
+}
+
+// Profiler of single database
+type slowLogProfiler struct {
+	log *SlowLog
+	db  DB // Database without profiler
+}
+
+func (profiler *slowLogProfiler) finished(
+	query string,
+	args []interface{},
+	started time.Time,
+) {
+	duration := time.Since(started)
+	query = strings.TrimPrefix(query, "EXECUTE STATEMENT\n")
+	query = strings.TrimPrefix(query, "QUERY STATEMENT\n")
+	query = strings.TrimSpace(query)
+
+	fingerprint, explain := profiler.log.observe(query, duration)
+	if duration < profiler.log.options.Threshold {
+		return
+	}
+
+	if tracer := profiler.log.options.Tracer; tracer != nil {
+		tracer.Trace(fmt.Sprintf("SLOW SQL: Elapsed time %s\n%s", duration, query))
+	}
+
+	if explain {
+		go func() {
+			plan, err := profiler.explain(query, args)
+			if err != nil {
+				plan = "error: " + err.Error()
+			}
+			profiler.log.explained(fingerprint, plan)
+		}()
+	}
+}
+
+// Capture execution plan as text table.
+func (profiler *slowLogProfiler) explain(query string, args []interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), profiler.log.options.ExplainTimeout)
+	defer cancel()
+
+	rows, err := profiler.db.QueryContext(ctx, profiler.db.Adapter().ExplainQuery(query), args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{strings.Join(columns, "\t")}
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+	for rows.Next() {
+		if err := rows.Scan(values...); err != nil {
+			return "", err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i], _ = generic.ConvertToString(*(v.(*interface{})))
+		}
+		lines = append(lines, strings.Join(cells, "\t"))
+	}
+
+	return strings.Join(lines, "\n"), rows.Err()
+}
+
+func isSelect(query string) bool {
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
+}
+
+// Fingerprint normalizes query: literals are replaced by "?",
+// lists of values are collapsed and whitespaces are squeezed.
+// Queries, that differ only by values, have same fingerprint.
+func Fingerprint(query string) string {
+	var buf strings.Builder
+	var last byte // The last byte of source, that is written
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = buf.Len() != 0
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space = buf.Len() != 0
+			continue
+		}
+
+		if space {
+			buf.WriteByte(' ')
+			space = false
+			last = ' '
+		}
+
+		switch {
+		case c == '\'' || c == '"':
+			// String literal ("..." is identifier in some dialects,
+			// but MySQL treats it as string by default)
+			for i++; i < len(query); i++ {
+				if query[i] == '\\' {
+					i++
+					continue
+				}
+				if query[i] == c {
+					if i+1 < len(query) && query[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			buf.WriteByte('?')
+		case c == '`':
+			end := strings.IndexByte(query[i+1:], '`')
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			buf.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			for i+1 < len(query) && isDigit(query[i+1]) {
+				i++
+			}
+			buf.WriteByte('?')
+		case isDigit(c) && !isWordChar(last):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.' ||
+				query[i+1] == 'e' || query[i+1] == 'E' || query[i+1] == 'x' ||
+				query[i+1] >= 'a' && query[i+1] <= 'f' || query[i+1] >= 'A' && query[i+1] <= 'F') {
+				i++
+			}
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(c)
+		}
+		last = c
+	}
+
+	return collapseLists(buf.String())
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Check, that character belongs to identifier (digit after it is part of name).
+func isWordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c)
+}
+
+// Replace "?, ?, ?" by "?" and "(?), (?)" by "(?)".
+func collapseLists(s string) string {
+	for {
+		r := strings.Replace(s, "?, ?", "?", -1)
+		r = strings.Replace(r, "?,?", "?", -1)
+		r = strings.Replace(r, "(?), (?)", "(?)", -1)
+		r = strings.Replace(r, "(?),(?)", "(?)", -1)
+		if r == s {
+			return s
+		}
+		s = r
+	}
+}
+
+// Wrap database by slow query log
+func WithSlowLog(db DB, log *SlowLog) DB {
+	return &profilerDB{
+		DB:       db,
+		profiler: &slowLogProfiler{log: log, db: db},
+	}
+}
+
+// Open database with slow query log
+func OpenWithSlowLog(
+	log *SlowLog,
+	activator Activator,
+) Activator {
+	return func(dsc DSC) (DB, error) {
+		db, err := Open(dsc, activator)
+		if err != nil {
+			return nil, err
+		}
+
+		return WithSlowLog(db, log), nil
+	}
+}
+
+// NewSlowLog creates log of slow queries.
+func NewSlowLog(options SlowLogOptions) *SlowLog {
+	if options.Threshold == 0 {
+		options.Threshold = 100 * time.Millisecond
+	}
+	if options.ExplainTimeout == 0 {
+		options.ExplainTimeout = 5 * time.Second
+	}
+	if options.Samples <= 0 {
+		options.Samples = 256
+	}
+	if options.MaxFingerprints <= 0 {
+		options.MaxFingerprints = 1000
+	}
+
+	return &SlowLog{
+		options: options,
+		stats:   make(map[string]*QueryStats, 64),
+	}
+}
@@ -0,0 +1,127 @@
+package sql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM user WHERE id = 10":                             "SELECT * FROM user WHERE id = ?",
+		"SELECT *\n  FROM user\tWHERE name = 'O''Brien' -- comment\n":  "SELECT * FROM user WHERE name = ?",
+		"SELECT * FROM user WHERE id IN (1, 2, 3) AND x = -1.5e3":      "SELECT * FROM user WHERE id IN (?) AND x = -?",
+		"SELECT * FROM user WHERE id IN (?,?,?)":                       "SELECT * FROM user WHERE id IN (?)",
+		"INSERT INTO log2 (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z')":  "INSERT INTO log2 (a, b) VALUES (?)",
+		"SELECT `table1`.`col2` FROM `table1` WHERE a = $1 AND b = $2": "SELECT `table1`.`col2` FROM `table1` WHERE a = ? AND b = ?",
+		"UPDATE t SET s = \"a\\\"b\" WHERE id = 0x1F":                  "UPDATE t SET s = ? WHERE id = ?",
+	}
+
+	for src, dst := range tests {
+		assert.Equal(t, dst, sql.Fingerprint(src), src)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var list []time.Duration
+	for i := 1; i <= 100; i++ {
+		list = append(list, time.Duration(i))
+	}
+
+	assert.Equal(t, time.Duration(0), sql.Percentile(nil, 50))
+	assert.Equal(t, time.Duration(50), sql.Percentile(list, 50))
+	assert.Equal(t, time.Duration(95), sql.Percentile(list, 95))
+	assert.Equal(t, time.Duration(7), sql.Percentile([]time.Duration{7}, 95))
+}
+
+func TestSlowLog(t *testing.T) {
+	ctx := context.Background()
+	mock := sqltest.New()
+	defer mock.Close()
+	// Explain is executed in background concurrently with queries
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("SELECT value FROM t WHERE id = ?").WithArgs(7).
+		WillReturnRows(sqltest.NewRows("value").AddRow(7)).Times(3)
+	mock.ExpectExec("UPDATE t SET value = 1 WHERE id = ?").WithArgs(7)
+	mock.ExpectQuery("EXPLAIN SELECT value FROM t WHERE id = ?").WithArgs(7).
+		WillReturnRows(sqltest.NewRows("value").AddRow(7))
+	base := openStmtTest(t, 0, mock)
+	defer base.Close(ctx)
+
+	var traced []string
+	log := sql.NewSlowLog(sql.SlowLogOptions{
+		Threshold: time.Nanosecond,
+		Explain:   true,
+		Tracer:    tracerFunc(func(msg interface{}) { traced = append(traced, msg.(string)) }),
+		Samples:   2,
+	})
+	db := sql.WithSlowLog(base, log)
+
+	for i := 0; i < 3; i++ {
+		var value int
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT value FROM t WHERE id = ?", 7).Scan(&value))
+	}
+	_, err := db.ExecContext(ctx, "UPDATE t SET value = 1 WHERE id = ?", 7)
+	require.NoError(t, err)
+
+	var stats []sql.QueryStats
+	for i := 0; i < 100; i++ {
+		stats = log.Stats()
+		if len(stats) == 2 && (stats[0].Explain != "" || stats[1].Explain != "") {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Len(t, stats, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	byFingerprint := make(map[string]sql.QueryStats)
+	for _, s := range stats {
+		byFingerprint[s.Fingerprint] = s
+	}
+
+	sel := byFingerprint["SELECT value FROM t WHERE id = ?"]
+	assert.Equal(t, int64(3), sel.Count)
+	assert.Equal(t, int64(3), sel.Slow)
+	assert.True(t, sel.Max >= sel.P95 && sel.P95 >= sel.P50 && sel.P50 > 0)
+	assert.Equal(t, "value\n7", sel.Explain)
+
+	upd := byFingerprint["UPDATE t SET value = ? WHERE id = ?"]
+	assert.Equal(t, int64(1), upd.Count)
+	assert.Empty(t, upd.Explain)
+	assert.Len(t, traced, 4)
+
+	rec := httptest.NewRecorder()
+	log.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var report []sql.QueryStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Len(t, report, 2)
+
+	log.Reset()
+	assert.Empty(t, log.Stats())
+}
+
+func TestSlowLog_Threshold(t *testing.T) {
+	log := sql.NewSlowLog(sql.SlowLogOptions{Threshold: time.Hour, MaxFingerprints: 1})
+	sql.ObserveQuery(log, "SELECT 1", time.Now())
+	sql.ObserveQuery(log, "SELECT 2", time.Now())
+	sql.ObserveQuery(log, "SELECT a", time.Now())
+
+	stats := log.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Count)
+	assert.Equal(t, int64(0), stats[0].Slow)
+}
+
+type tracerFunc func(msg interface{})
+
+func (fn tracerFunc) Trace(msg interface{}) {
+	fn(msg)
+}
@@ -0,0 +1,278 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqltest
+
+import (
+	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+type mockDriver struct{}
+
+func (d *mockDriver) Open(name string) (driver.Conn, error) {
+	node, ok := nodes.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("sqltest: unknown node %q", name)
+	}
+	mock := node.(*Mock)
+	if err := mock.ping(); err != nil {
+		return nil, err
+	}
+	return &conn{mock: mock}, nil
+}
+
+type conn struct {
+	mock *Mock
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.mock.prepare(query); err != nil {
+		return nil, err
+	}
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var mode string
+	if opts.ReadOnly {
+		mode = readOnly
+	}
+	if _, err := c.do(ctx, kindBegin, mode, nil); err != nil {
+		return nil, err
+	}
+	return &tx{conn: c}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	return c.mock.ping()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.do(ctx, kindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.result == nil {
+		return driver.RowsAffected(0), nil
+	}
+	return e.result, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.do(ctx, kindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.rows == nil {
+		return &rows{Rows: new(Rows)}, nil
+	}
+	return &rows{Rows: e.rows}, nil
+}
+
+// Execute expected call.
+func (c *conn) do(ctx context.Context, kind, query string, args []driver.NamedValue) (*Expectation, error) {
+	e, err := c.mock.call(kind, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.delay != 0 {
+		select {
+		case <-time.After(e.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	return e, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (t *tx) Commit() error {
+	_, err := t.conn.do(context.Background(), kindCommit, "", nil)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, err := t.conn.do(context.Background(), kindRollback, "", nil)
+	return err
+}
+
+type rows struct {
+	*Rows
+	pos int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	return nil
+}
+
+type result struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	res := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		res[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return res
+}
+
+// Adapter of fake database. Named locks are executed as queries
+// "LOCK LOCAL|GLOBAL <latch>" and "UNLOCK LOCAL|GLOBAL <latch>".
+type adapter struct{}
+
+func (a *adapter) Driver() string {
+	return DriverName
+}
+
+func (a *adapter) DatabaseName(db sql.DB) (string, error) {
+	dsc := db.DSC()
+	return dsc.Primary().Database, nil
+}
+
+func (a *adapter) MakeConnectionString(dsn *sql.DSN) string {
+	return dsn.Host
+}
+
+func (a *adapter) IsDeadlock(db sql.DB, err error) bool {
+	return err == ErrDeadlock
+}
+
+func (a *adapter) LockLocal(ctx context.Context, tx sql.Tx, latch string, timeout int) error {
+	_, err := tx.ExecContext(ctx, "LOCK LOCAL "+latch)
+	return err
+}
+
+func (a *adapter) UnlockLocal(ctx context.Context, tx sql.Tx, latch string) error {
+	_, err := tx.ExecContext(ctx, "UNLOCK LOCAL "+latch)
+	return err
+}
+
+func (a *adapter) LockGlobal(ctx context.Context, tx sql.Tx, latch string, timeout int) error {
+	_, err := tx.ExecContext(ctx, "LOCK GLOBAL "+latch)
+	return err
+}
+
+func (a *adapter) UnlockGlobal(ctx context.Context, tx sql.Tx, latch string) error {
+	_, err := tx.ExecContext(ctx, "UNLOCK GLOBAL "+latch)
+	return err
+}
+
+func (a *adapter) Placeholder(n int) string {
+	return "?"
+}
+
+// Qualified name is quoted by parts, as adapters of real databases do.
+func (a *adapter) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.Replace(part, `"`, `""`, -1) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+func (a *adapter) ExplainQuery(query string) string {
+	return "EXPLAIN " + query
+}
+
+func init() {
+	stdsql.Register(DriverName, new(mockDriver))
+	sql.Register(DriverName, new(adapter))
+}
@@ -0,0 +1,441 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqltest provides scripted fake database for unit testing of code,
+// based on package database/sql without real database server.
+//
+// Each Mock is a separated node of database. Tests declare expected calls
+// (transaction boundaries, savepoints, queries) with canned results in the
+// order of execution and check, that all expectations were met.
+// Example:
+//   mock := sqltest.New()
+//   mock.ExpectBegin()
+//   mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").WithArgs("Bob", 1).WillReturnResult(0, 1)
+//   mock.ExpectCommit()
+//   db, err := sqltest.Open(nil, mock)
+//   ...
+//   assert.NoError(t, mock.ExpectationsWereMet())
+package sqltest
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+// DriverName is name of registered driver and adapter.
+const DriverName = "sqltest"
+
+var (
+	// ErrDeadlock is recognized by adapter as deadlock.
+	ErrDeadlock = errors.New("sqltest: deadlock")
+	// ErrConnLost is returned by node, that is down.
+	ErrConnLost = driver.ErrBadConn
+)
+
+// AnyArg matches any value of argument.
+var AnyArg = anyArg{}
+
+type anyArg struct{}
+
+// Kinds of expectations.
+const (
+	kindBegin    = "BEGIN"
+	kindCommit   = "COMMIT"
+	kindRollback = "ROLLBACK"
+	kindExec     = "EXEC"
+	kindQuery    = "QUERY"
+	kindPrepare  = "PREPARE"
+)
+
+// Mode of read-only transaction.
+const readOnly = "READ ONLY"
+
+// Expectation is a single expected call with canned response.
+type Expectation struct {
+	kind    string
+	query   string
+	args    []interface{}
+	result  driver.Result
+	rows    *Rows
+	err     error
+	delay   time.Duration
+	times   int
+	hasArgs bool
+}
+
+// WithArgs requires the arguments of query. Use AnyArg for skip the check of value.
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.hasArgs = true
+	return e
+}
+
+// WillReturnResult sets the result of Exec.
+func (e *Expectation) WillReturnResult(lastInsertId, rowsAffected int64) *Expectation {
+	e.result = &result{lastInsertId: lastInsertId, rowsAffected: rowsAffected}
+	return e
+}
+
+// WillReturnRows sets the rows of Query.
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnError makes the call failed.
+// Note: database/sql retries calls, failed with driver.ErrBadConn, on new connection.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// WillDelayFor delays the response (or until cancellation of the context).
+func (e *Expectation) WillDelayFor(delay time.Duration) *Expectation {
+	e.delay = delay
+	return e
+}
+
+// Times sets the count of repeats of the expectation (default 1).
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) String() string {
+	switch e.kind {
+	case kindBegin:
+		if e.query != "" {
+			return e.kind + " " + e.query
+		}
+		return e.kind
+	case kindExec, kindQuery:
+		if e.hasArgs {
+			return fmt.Sprintf("%s %q with args %v", e.kind, e.query, e.args)
+		}
+		return fmt.Sprintf("%s %q", e.kind, e.query)
+	default:
+		return e.kind
+	}
+}
+
+func (e *Expectation) match(kind, query string, args []driver.NamedValue) bool {
+	if e.kind != kind || e.query != query {
+		return false
+	}
+	if !e.hasArgs {
+		return true
+	}
+	if len(e.args) != len(args) {
+		return false
+	}
+	for i, arg := range e.args {
+		if arg == AnyArg {
+			continue
+		}
+		expected, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil || !reflect.DeepEqual(expected, args[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rows is a canned result set.
+type Rows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+// NewRows creates result set with required columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// AddRow appends row into the result set.
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	row := make([]driver.Value, len(values))
+	for i, v := range values {
+		val, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			panic(err)
+		}
+		row[i] = val
+	}
+	r.values = append(r.values, row)
+	return r
+}
+
+// RowError makes iteration failed after all rows.
+func (r *Rows) RowError(err error) *Rows {
+	r.err = err
+	return r
+}
+
+// Mock is a scripted database node.
+type Mock struct {
+	name     string
+	mu       sync.Mutex
+	expected []*Expectation
+	failures []error
+	log      []string
+	down     bool
+	any      bool // Expectations are matched in any order
+}
+
+// New creates and registers new node.
+func New() *Mock {
+	mock := &Mock{
+		name: "mock" + strconv.FormatInt(atomic.AddInt64(&sequence, 1), 10),
+	}
+	nodes.Store(mock.name, mock)
+	return mock
+}
+
+// Name returns the unique name of node (it is used as host of DSN).
+func (m *Mock) Name() string {
+	return m.name
+}
+
+// DSN returns data source name of node.
+func (m *Mock) DSN() *sql.DSN {
+	return &sql.DSN{Host: m.name, Database: m.name}
+}
+
+// Close unregisters node.
+func (m *Mock) Close() {
+	nodes.Delete(m.name)
+}
+
+// ExpectBegin expects the start of transaction.
+func (m *Mock) ExpectBegin() *Expectation {
+	return m.expect(kindBegin, "")
+}
+
+// ExpectBeginReadOnly expects the start of read-only transaction.
+func (m *Mock) ExpectBeginReadOnly() *Expectation {
+	return m.expect(kindBegin, readOnly)
+}
+
+// ExpectCommit expects the commit of transaction.
+func (m *Mock) ExpectCommit() *Expectation {
+	return m.expect(kindCommit, "")
+}
+
+// ExpectRollback expects the rollback of transaction.
+func (m *Mock) ExpectRollback() *Expectation {
+	return m.expect(kindRollback, "")
+}
+
+// ExpectSavepoint expects the start of nested transaction.
+func (m *Mock) ExpectSavepoint(name string) *Expectation {
+	return m.ExpectExec("SAVEPOINT " + name)
+}
+
+// ExpectRelease expects the commit of nested transaction.
+func (m *Mock) ExpectRelease(name string) *Expectation {
+	return m.ExpectExec("RELEASE SAVEPOINT " + name)
+}
+
+// ExpectRollbackTo expects the rollback of nested transaction.
+func (m *Mock) ExpectRollbackTo(name string) *Expectation {
+	return m.ExpectExec("ROLLBACK TO SAVEPOINT " + name)
+}
+
+// ExpectExec expects the query without result set.
+// Spaces of query are squeezed before comparison.
+func (m *Mock) ExpectExec(query string) *Expectation {
+	return m.expect(kindExec, query)
+}
+
+// ExpectQuery expects the query with result set.
+// Spaces of query are squeezed before comparison.
+func (m *Mock) ExpectQuery(query string) *Expectation {
+	return m.expect(kindQuery, query)
+}
+
+// MatchExpectationsInOrder sets, whether calls must match expectations in
+// the declared order (default) or in any order, for example for concurrent calls.
+func (m *Mock) MatchExpectationsInOrder(ordered bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.any = !ordered
+}
+
+// SetDown simulates the loss (or recovery) of connection to the node.
+// All calls (including Ping) fail with ErrConnLost, while node is down.
+func (m *Mock) SetDown(down bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.down = down
+}
+
+// Log returns all executed calls ("BEGIN", "BEGIN READ ONLY", "COMMIT",
+// "ROLLBACK", text of query or "PREPARE " and text of prepared query).
+// Preparations of statements are logged, but they are not expected.
+func (m *Mock) Log() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.log...)
+}
+
+// ExpectationsWereMet returns error, if there were unexpected calls or
+// some expectations were not met.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.failures) != 0 {
+		return m.failures[0]
+	}
+
+	if len(m.expected) != 0 {
+		list := make([]string, len(m.expected))
+		for i, e := range m.expected {
+			list[i] = e.String()
+		}
+		return fmt.Errorf("sqltest: %s: expectations were not met: %s", m.name, strings.Join(list, "; "))
+	}
+
+	return nil
+}
+
+// Reset clears expectations, failures and log.
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expected = nil
+	m.failures = nil
+	m.log = nil
+}
+
+func (m *Mock) expect(kind, query string) *Expectation {
+	e := &Expectation{kind: kind, query: squeeze(query), times: 1}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expected = append(m.expected, e)
+	return e
+}
+
+// Match call with the next expectation.
+func (m *Mock) call(kind, query string, args []driver.NamedValue) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.down {
+		return nil, ErrConnLost
+	}
+
+	query = squeeze(query)
+	switch {
+	case query == "":
+		m.log = append(m.log, kind)
+	case kind == kindBegin:
+		m.log = append(m.log, kind+" "+query)
+	default:
+		m.log = append(m.log, query)
+	}
+
+	var err error
+	if len(m.expected) == 0 {
+		err = fmt.Errorf("sqltest: %s: unexpected %s %q", m.name, kind, query)
+	} else if i := m.find(kind, query, args); i < 0 {
+		err = fmt.Errorf("sqltest: %s: unexpected %s %q with args %v, expected %s", m.name, kind, query, values(args), m.expected[0])
+	} else {
+		e := m.expected[i]
+		e.times--
+		if e.times <= 0 {
+			m.expected = append(m.expected[:i], m.expected[i+1:]...)
+		}
+		return e, nil
+	}
+
+	m.failures = append(m.failures, err)
+	return nil, err
+}
+
+// Find index of expectation, matched with call.
+func (m *Mock) find(kind, query string, args []driver.NamedValue) int {
+	for i, e := range m.expected {
+		if e.match(kind, query, args) {
+			return i
+		}
+		if !m.any {
+			break
+		}
+	}
+	return -1
+}
+
+func (m *Mock) prepare(query string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return ErrConnLost
+	}
+	m.log = append(m.log, kindPrepare+" "+squeeze(query))
+	return nil
+}
+
+func (m *Mock) ping() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return ErrConnLost
+	}
+	return nil
+}
+
+// NewDSC returns cluster of nodes (the first node is master).
+func NewDSC(nodes ...*Mock) sql.DSC {
+	dsc := sql.DSC{Driver: DriverName}
+	for _, node := range nodes {
+		dsc.DSN = append(dsc.DSN, node.DSN())
+	}
+	return dsc
+}
+
+// Open opens cluster of nodes (the first node is master).
+func Open(activator sql.Activator, nodes ...*Mock) (sql.DB, error) {
+	return sql.Open(NewDSC(nodes...), activator)
+}
+
+var (
+	nodes    sync.Map
+	sequence int64
+)
+
+func squeeze(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	res := make([]driver.Value, len(args))
+	for i, arg := range args {
+		res[i] = arg.Value
+	}
+	return res
+}
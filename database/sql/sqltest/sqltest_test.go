@@ -0,0 +1,200 @@
+package sqltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMock_Query(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.ExpectQuery("SELECT id, name\n  FROM users WHERE id > ?").
+		WithArgs(10).
+		WillReturnRows(NewRows("id", "name").AddRow(11, "Bob").AddRow(12, "Alice"))
+	mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").
+		WithArgs("Tom", AnyArg).
+		WillReturnResult(0, 1)
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	rows, err := db.Query("SELECT id, name FROM users WHERE id > ?", 10)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var id int
+		var name string
+		require.NoError(t, rows.Scan(&id, &name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"Bob", "Alice"}, names)
+
+	res, err := db.Exec("UPDATE users SET name = ? WHERE id = ?", "Tom", 11)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMock_Unexpected(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1)
+	mock.ExpectCommit()
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	_, err = db.Exec("DELETE FROM users WHERE id = ?", 2)
+	assert.Error(t, err)
+	assert.Error(t, mock.ExpectationsWereMet())
+
+	mock.Reset()
+	mock.ExpectExec("DELETE FROM users").Times(2)
+	mock.ExpectCommit()
+	_, err = db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+	assert.EqualError(t, mock.ExpectationsWereMet(), "sqltest: "+mock.Name()+": expectations were not met: COMMIT")
+	assert.Equal(t, []string{"DELETE FROM users", "DELETE FROM users"}, mock.Log())
+}
+
+func TestMock_Transaction(t *testing.T) {
+	errAction := errors.New("action")
+	mock := New()
+	defer mock.Close()
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL latch")
+	mock.ExpectSavepoint("trans1")
+	mock.ExpectExec("INSERT INTO users (name) VALUES (?)").WithArgs("Bob").WillReturnResult(5, 1)
+	mock.ExpectRollbackTo("trans1")
+	mock.ExpectSavepoint("trans1")
+	mock.ExpectRelease("trans1")
+	mock.ExpectCommit()
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	ctx := context.Background()
+	err = db.Transact(ctx, nil, func(tx sql.Tx) error {
+		if err := tx.Adapter().LockGlobal(ctx, tx, "latch", 0); err != nil {
+			return err
+		}
+
+		err := tx.Transact(ctx, nil, func(tx sql.Tx) error {
+			res, err := tx.Exec("INSERT INTO users (name) VALUES (?)", "Bob")
+			require.NoError(t, err)
+			id, err := res.LastInsertId()
+			require.NoError(t, err)
+			assert.Equal(t, int64(5), id)
+			return errAction
+		})
+		assert.Equal(t, errAction, err)
+
+		return tx.Transact(ctx, nil, func(tx sql.Tx) error {
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMock_Delay(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.ExpectExec("SELECT SLEEP(1)").WillDelayFor(time.Second)
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = db.ExecContext(ctx, "SELECT SLEEP(1)")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestMock_Down(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	require.NoError(t, db.Ping())
+	mock.SetDown(true)
+	assert.Equal(t, ErrConnLost, db.Ping())
+	mock.SetDown(false)
+	assert.NoError(t, db.Ping())
+
+	name, err := db.Adapter().DatabaseName(db)
+	require.NoError(t, err)
+	assert.Equal(t, mock.Name(), name)
+}
+
+func TestMock_ReadOnlyAndPrepare(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.ExpectBeginReadOnly()
+	mock.ExpectQuery("SELECT 1")
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT 2")
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	ctx := context.Background()
+	err = db.Transact(ctx, &sql.TxOptions{ReadOnly: true}, func(tx sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT 1")
+		if err != nil {
+			return err
+		}
+		return rows.Close()
+	})
+	require.NoError(t, err)
+
+	stmt, err := db.PrepareContext(ctx, "SELECT 2")
+	require.NoError(t, err)
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"BEGIN READ ONLY", "SELECT 1", "COMMIT", "PREPARE SELECT 2", "SELECT 2"}, mock.Log())
+}
+
+func TestMock_MatchExpectationsInOrder(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectExec("DELETE FROM users")
+	mock.ExpectExec("DELETE FROM orders")
+
+	db, err := Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	_, err = db.Exec("DELETE FROM orders")
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -20,6 +20,7 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"sync"
 )
 
 // Stmt is an aggregate prepared statement.
@@ -56,20 +57,21 @@ func (s *stmt1) Close() error {
 func (s *stmt1) Exec(args ...interface{}) (Result, error) {
 	started := s.database.beginExec()
 	res, err := s.stmt.Exec(args...)
+	s.database.endExec(started)
 	if err != nil {
 		return nil, err
 	}
-	s.database.endExec(started)
 	return &result{db: s.database, res: res}, nil
 }
 
 func (s *stmt1) ExecContext(ctx context.Context, args ...interface{}) (Result, error) {
+	markWritten(ctx)
 	started := s.database.beginExec()
 	res, err := s.stmt.ExecContext(ctx, args...)
+	s.database.endExec(started)
 	if err != nil {
 		return nil, err
 	}
-	s.database.endExec(started)
 	return &result{db: s.database, res: res}, nil
 }
 
@@ -114,16 +116,30 @@ func (s *stmt1) QueryRowContext(ctx context.Context, args ...interface{}) Row {
 	return &row{db: s.database, r: r}
 }
 
+// Statement of cluster. Statement of master is always prepared.
+// Statements of replicas are prepared lazily, if they were not
+// available at preparing.
 type stmt2 struct {
-	db    *database2
-	stmts []*sql.Stmt
+	db     *database2
+	query  string
+	mx     sync.Mutex
+	stmts  []*sql.Stmt
+	closed bool
 }
 
 // Close closes the statement by concurrently closing all underlying
 // statements concurrently, returning the first non nil error.
 func (s *stmt2) Close() error {
-	err := scatter(len(s.stmts), func(i int) error {
-		return s.stmts[i].Close()
+	s.mx.Lock()
+	s.closed = true
+	stmts := append([]*sql.Stmt(nil), s.stmts...)
+	s.mx.Unlock()
+
+	err := scatter(len(stmts), func(i int) error {
+		if stmts[i] == nil {
+			return nil
+		}
+		return stmts[i].Close()
 	})
 	if err != nil {
 		return err
@@ -137,20 +153,21 @@ func (s *stmt2) Close() error {
 func (s *stmt2) Exec(args ...interface{}) (Result, error) {
 	started := s.db.beginExec()
 	res, err := s.stmts[0].Exec(args...)
+	s.db.endExec(started)
 	if err != nil {
 		return nil, err
 	}
-	s.db.endExec(started)
 	return &result{db: s.db, res: res}, nil
 }
 
 func (s *stmt2) ExecContext(ctx context.Context, args ...interface{}) (Result, error) {
 	started := s.db.beginExec()
+	markWritten(ctx)
 	res, err := s.stmts[0].ExecContext(ctx, args...)
+	s.db.endExec(started)
 	if err != nil {
 		return nil, err
 	}
-	s.db.endExec(started)
 	return &result{db: s.db, res: res}, nil
 }
 
@@ -159,7 +176,7 @@ func (s *stmt2) ExecContext(ctx context.Context, args ...interface{}) (Result, e
 // Query uses a slave as the underlying physical db.
 func (s *stmt2) Query(args ...interface{}) (Rows, error) {
 	started := s.db.beginQuery()
-	rs, err := s.stmts[s.db.slave(len(s.db.pdbs))].Query(args...)
+	rs, err := s.stmt(context.Background()).Query(args...)
 	if err != nil {
 		return nil, recode(err)
 	}
@@ -168,7 +185,7 @@ func (s *stmt2) Query(args ...interface{}) (Rows, error) {
 
 func (s *stmt2) QueryContext(ctx context.Context, args ...interface{}) (Rows, error) {
 	started := s.db.beginQuery()
-	rs, err := s.stmts[s.db.slave(len(s.db.pdbs))].QueryContext(ctx, args...)
+	rs, err := s.stmt(ctx).QueryContext(ctx, args...)
 	if err != nil {
 		return nil, recode(err)
 	}
@@ -183,14 +200,46 @@ func (s *stmt2) QueryContext(ctx context.Context, args ...interface{}) (Rows, er
 // QueryRow uses a slave as the underlying physical db.
 func (s *stmt2) QueryRow(args ...interface{}) Row {
 	started := s.db.beginQuery()
-	r := s.stmts[s.db.slave(len(s.db.pdbs))].QueryRow(args...)
+	r := s.stmt(context.Background()).QueryRow(args...)
 	s.db.endQuery(started)
 	return &row{db: s.db, r: r}
 }
 
 func (s *stmt2) QueryRowContext(ctx context.Context, args ...interface{}) Row {
 	started := s.db.beginQuery()
-	r := s.stmts[s.db.slave(len(s.db.pdbs))].QueryRowContext(ctx, args...)
+	r := s.stmt(ctx).QueryRowContext(ctx, args...)
 	s.db.endQuery(started)
 	return &row{db: s.db, r: r}
 }
+
+// Get statement of replica for reading query. Statement of replica is
+// prepared at first use, if it is absent. Master is used, while replica
+// can't prepare statement.
+func (s *stmt2) stmt(ctx context.Context) *sql.Stmt {
+	i := s.db.slave(ctx)
+	s.mx.Lock()
+	stmt := s.stmts[i]
+	s.mx.Unlock()
+	if stmt != nil {
+		return stmt
+	}
+
+	stmt, err := s.db.nodes[i].db.PrepareContext(ctx, s.query)
+	if err != nil {
+		s.db.failed(i, err)
+		return s.stmts[0]
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.closed || s.stmts[i] != nil {
+		// Statement is closed or prepared concurrently
+		go stmt.Close()
+		if s.stmts[i] != nil {
+			return s.stmts[i]
+		}
+		return s.stmts[0]
+	}
+	s.stmts[i] = stmt
+	return stmt
+}
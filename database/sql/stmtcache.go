@@ -0,0 +1,334 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StmtMetrics is metrics of cached statement.
+type StmtMetrics struct {
+	Query    string      `json:"query"`
+	Calls    HalfMetrics `json:"calls"`    // Executions of statement
+	Errors   int32       `json:"errors"`   // Count of failed executions
+	Prepares int32       `json:"prepares"` // Count of preparations (including re-preparations)
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    Stmt
+	metrics StmtMetrics
+}
+
+func (entry *stmtEntry) begin() int64 {
+	return time.Now().UnixNano()
+}
+
+// Entry can be nil for statements, that are prepared within transaction.
+func (entry *stmtEntry) end(started int64, err error) {
+	if entry == nil {
+		return
+	}
+	atomic.AddInt32(&entry.metrics.Calls.Count, 1)
+	atomic.AddInt64(&entry.metrics.Calls.Time, time.Now().UnixNano()-started)
+	if err != nil {
+		atomic.AddInt32(&entry.metrics.Errors, 1)
+	}
+}
+
+// Cache of prepared statements, bounded by LRU.
+// Queries with arguments of DB and Tx are executed through
+// the cache, if DSC.StmtCache is positive.
+type stmtCache struct {
+	mx       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	lru      *list.List // Front is the most recently used
+	prepare  func(ctx context.Context, query string) (Stmt, error)
+}
+
+// Get statement from cache or prepare it.
+func (cache *stmtCache) get(ctx context.Context, query string) (*stmtEntry, error) {
+	cache.mx.Lock()
+	if el, ok := cache.items[query]; ok {
+		cache.lru.MoveToFront(el)
+		cache.mx.Unlock()
+		return el.Value.(*stmtEntry), nil
+	}
+	cache.mx.Unlock()
+
+	// Preparation is performed without lock, so concurrent preparations of
+	// the same query are possible. The loser closes own statement.
+	stmt, err := cache.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	if el, ok := cache.items[query]; ok {
+		go stmt.Close()
+		cache.lru.MoveToFront(el)
+		return el.Value.(*stmtEntry), nil
+	}
+
+	entry := &stmtEntry{query: query, stmt: stmt}
+	entry.metrics.Query = query
+	entry.metrics.Prepares = 1
+	cache.items[query] = cache.lru.PushFront(entry)
+
+	for cache.lru.Len() > cache.capacity {
+		cache.remove(cache.lru.Back())
+	}
+
+	return entry, nil
+}
+
+// Get statement from cache without preparation.
+func (cache *stmtCache) peek(query string) *stmtEntry {
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	if el, ok := cache.items[query]; ok {
+		cache.lru.MoveToFront(el)
+		return el.Value.(*stmtEntry)
+	}
+	return nil
+}
+
+// Replace broken statement by new one.
+func (cache *stmtCache) reprepare(ctx context.Context, broken *stmtEntry) (*stmtEntry, error) {
+	stmt, err := cache.prepare(ctx, broken.query)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	entry := &stmtEntry{query: broken.query, stmt: stmt}
+	entry.metrics = broken.snapshot()
+	entry.metrics.Prepares++
+
+	if el, ok := cache.items[broken.query]; ok {
+		if el.Value.(*stmtEntry) != broken {
+			// Already replaced by concurrent thread
+			go stmt.Close()
+			cache.lru.MoveToFront(el)
+			return el.Value.(*stmtEntry), nil
+		}
+		cache.remove(el)
+	}
+
+	cache.items[entry.query] = cache.lru.PushFront(entry)
+	for cache.lru.Len() > cache.capacity {
+		cache.remove(cache.lru.Back())
+	}
+
+	return entry, nil
+}
+
+// Remove element from cache. Statement is closed in background,
+// because closing waits for completion of active queries.
+func (cache *stmtCache) remove(el *list.Element) {
+	entry := cache.lru.Remove(el).(*stmtEntry)
+	delete(cache.items, entry.query)
+	go entry.stmt.Close()
+}
+
+func (cache *stmtCache) close() {
+	cache.mx.Lock()
+	defer cache.mx.Unlock()
+
+	for cache.lru.Len() != 0 {
+		entry := cache.lru.Remove(cache.lru.Front()).(*stmtEntry)
+		delete(cache.items, entry.query)
+		_ = entry.stmt.Close()
+	}
+}
+
+// Get metrics of cached statements ordered by count of calls.
+func (cache *stmtCache) metrics() []StmtMetrics {
+	cache.mx.Lock()
+	res := make([]StmtMetrics, 0, cache.lru.Len())
+	for el := cache.lru.Front(); el != nil; el = el.Next() {
+		res = append(res, el.Value.(*stmtEntry).snapshot())
+	}
+	cache.mx.Unlock()
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Calls.Count > res[j].Calls.Count
+	})
+	return res
+}
+
+func (entry *stmtEntry) snapshot() StmtMetrics {
+	return StmtMetrics{
+		Query: entry.query,
+		Calls: HalfMetrics{
+			Count: atomic.LoadInt32(&entry.metrics.Calls.Count),
+			Time:  atomic.LoadInt64(&entry.metrics.Calls.Time),
+		},
+		Errors:   atomic.LoadInt32(&entry.metrics.Errors),
+		Prepares: atomic.LoadInt32(&entry.metrics.Prepares),
+	}
+}
+
+// Execute action with cached statement. Statement is prepared again,
+// if it was closed (evicted concurrently) or connection was lost.
+func (cache *stmtCache) do(
+	ctx context.Context,
+	query string,
+	action func(stmt Stmt) error,
+) error {
+	entry, err := cache.get(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	started := entry.begin()
+	err = action(entry.stmt)
+	if isBrokenStmt(err) {
+		entry.end(started, err)
+		entry, err = cache.reprepare(ctx, entry)
+		if err != nil {
+			return err
+		}
+		started = entry.begin()
+		err = action(entry.stmt)
+	}
+	entry.end(started, err)
+	return err
+}
+
+func (cache *stmtCache) exec(ctx context.Context, query string, args []interface{}) (res Result, err error) {
+	err = cache.do(ctx, query, func(stmt Stmt) error {
+		res, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return res, err
+}
+
+func (cache *stmtCache) query(ctx context.Context, query string, args []interface{}) (res Rows, err error) {
+	err = cache.do(ctx, query, func(stmt Stmt) error {
+		res, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+	return res, err
+}
+
+// Statement is used until row is scanned, so broken statement
+// is detected only by preparation.
+func (cache *stmtCache) queryRow(ctx context.Context, query string, args []interface{}) Row {
+	entry, err := cache.get(ctx, query)
+	if err != nil {
+		return errRow{err: err}
+	}
+
+	started := entry.begin()
+	res := entry.stmt.QueryRowContext(ctx, args...)
+	entry.end(started, nil)
+	return res
+}
+
+// Get transaction-bound statement for query.
+func (cache *stmtCache) txStmt(ctx context.Context, t *tx, query string) (*sql.Stmt, *stmtEntry, error) {
+	t.stmts.Lock()
+	defer t.stmts.Unlock()
+
+	if s, ok := t.stmts.items[query]; ok {
+		return s.stmt, s.entry, nil
+	}
+
+	// Statement is not prepared through database, because it can
+	// require extra connection, while transaction holds own one.
+	var s *sql.Stmt
+	entry := cache.peek(query)
+	if entry != nil {
+		// Statement of transaction is closed with transaction.
+		// Parent statement is kept open until that moment by database/sql.
+		s = t.trans.StmtContext(ctx, masterStmt(entry.stmt))
+	} else {
+		var err error
+		s, err = t.trans.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if t.stmts.items == nil {
+		t.stmts.items = make(map[string]txStmt, 16)
+	}
+	t.stmts.items[query] = txStmt{stmt: s, entry: entry}
+	return s, entry, nil
+}
+
+type txStmt struct {
+	stmt  *sql.Stmt
+	entry *stmtEntry
+}
+
+// Statements of transaction (shared between savepoints).
+type txStmts struct {
+	sync.Mutex
+	items map[string]txStmt
+}
+
+// Get statement of master database.
+func masterStmt(stmt Stmt) *sql.Stmt {
+	switch s := stmt.(type) {
+	case *stmt1:
+		return s.stmt
+	case *stmt2:
+		return s.stmts[0]
+	}
+	return nil
+}
+
+func isBrokenStmt(err error) bool {
+	return err == driver.ErrBadConn ||
+		err != nil && err.Error() == "sql: statement is closed"
+}
+
+type errRow struct {
+	err error
+}
+
+func (row errRow) Scan(dest ...interface{}) error {
+	return row.err
+}
+
+func newStmtCache(
+	capacity int,
+	prepare func(ctx context.Context, query string) (Stmt, error),
+) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element, capacity),
+		lru:      list.New(),
+		prepare:  prepare,
+	}
+}
@@ -0,0 +1,158 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openStmtTest(t *testing.T, capacity int, nodes ...*sqltest.Mock) sql.DB {
+	dsc := sqltest.NewDSC(nodes...)
+	dsc.StmtCache = capacity
+	db, err := sql.Open(dsc, nil)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func calls(metrics []sql.StmtMetrics) map[string]int32 {
+	res := make(map[string]int32, len(metrics))
+	for _, m := range metrics {
+		res[m.Query] = m.Calls.Count
+	}
+	return res
+}
+
+// Count of preparations of query.
+func prepares(mock *sqltest.Mock, query string) int {
+	var n int
+	for _, call := range mock.Log() {
+		if call == "PREPARE "+query {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStmtCache(t *testing.T) {
+	ctx := context.Background()
+	mock := sqltest.New()
+	defer mock.Close()
+	for _, query := range []string{"q1", "q2", "q1", "q3"} {
+		mock.ExpectExec(query).WithArgs(1).WillReturnResult(0, 1)
+	}
+	mock.ExpectExec("q4").WithArgs()
+	mock.ExpectQuery("q2").WithArgs(7).WillReturnRows(sqltest.NewRows("value").AddRow(7))
+	mock.ExpectQuery("q5").WithArgs(1, 2, 3).WillReturnRows(sqltest.NewRows("value").AddRow(1).AddRow(2).AddRow(3))
+
+	db := openStmtTest(t, 2, mock)
+	defer db.Close(ctx)
+
+	for _, query := range []string{"q1", "q2", "q1", "q3"} {
+		res, err := db.ExecContext(ctx, query, 1)
+		require.NoError(t, err)
+		n, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+	}
+
+	// Query without arguments is not cached
+	_, err := db.ExecContext(ctx, "q4")
+	require.NoError(t, err)
+
+	metrics := db.GetMetrics()
+	assert.Equal(t, map[string]int32{"q1": 2, "q3": 1}, calls(metrics.Statements))
+	assert.Equal(t, "q1", metrics.Statements[0].Query)
+	assert.Equal(t, int32(5), metrics.Exec.Count)
+	assert.Equal(t, 1, prepares(mock, "q1"))
+	assert.Equal(t, 0, prepares(mock, "q4"))
+
+	// Evicted statement is prepared again
+	var value int
+	require.NoError(t, db.QueryRowContext(ctx, "q2", 7).Scan(&value))
+	assert.Equal(t, 7, value)
+	assert.Equal(t, 2, prepares(mock, "q2"))
+	assert.Equal(t, map[string]int32{"q2": 1, "q3": 1}, calls(db.GetMetrics().Statements))
+
+	var values []int
+	require.NoError(t, sql.Select(ctx, db, &values, "q5", 1, 2, 3))
+	assert.Equal(t, []int{1, 2, 3}, values)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStmtCache_Reprepare(t *testing.T) {
+	ctx := context.Background()
+	master, replica := sqltest.New(), sqltest.New()
+	defer master.Close()
+	defer replica.Close()
+	master.ExpectExec("q1").WithArgs(1)
+	master.ExpectExec("q1").WithArgs(2)
+
+	db := openStmtTest(t, 8, master, replica)
+	defer db.Close(ctx)
+
+	_, err := db.ExecContext(ctx, "q1", 1)
+	require.NoError(t, err)
+
+	// Statement is closed, for example by concurrent eviction
+	require.NoError(t, sql.CloseCachedStmt(ctx, db, "q1"))
+
+	_, err = db.ExecContext(ctx, "q1", 2)
+	require.NoError(t, err)
+
+	metrics := db.GetMetrics().Statements
+	require.Len(t, metrics, 1)
+	assert.Equal(t, int32(2), metrics[0].Prepares)
+	assert.Equal(t, int32(3), metrics[0].Calls.Count)
+	assert.Equal(t, int32(1), metrics[0].Errors)
+	assert.Equal(t, 2, prepares(master, "q1"))
+	assert.NoError(t, master.ExpectationsWereMet())
+}
+
+func TestStmtCache_Tx(t *testing.T) {
+	ctx := context.Background()
+	mock := sqltest.New()
+	defer mock.Close()
+	mock.ExpectExec("q1").WithArgs(1)
+	mock.ExpectBegin()
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec("q1").WithArgs(i)
+	}
+	mock.ExpectExec("q2").WithArgs(1)
+	mock.ExpectSavepoint("trans1")
+	mock.ExpectQuery("q1").WithArgs(5).WillReturnRows(sqltest.NewRows("value").AddRow(5))
+	mock.ExpectRelease("trans1")
+	mock.ExpectCommit()
+
+	db := openStmtTest(t, 8, mock)
+	defer db.Close(ctx)
+
+	_, err := db.ExecContext(ctx, "q1", 1)
+	require.NoError(t, err)
+
+	err = db.Transact(ctx, nil, func(tx sql.Tx) error {
+		for i := 0; i < 3; i++ {
+			if _, err := tx.ExecContext(ctx, "q1", i); err != nil {
+				return err
+			}
+		}
+		// Statement is not cached yet, so it is prepared within transaction
+		if _, err := tx.ExecContext(ctx, "q2", 1); err != nil {
+			return err
+		}
+		return tx.Transact(ctx, nil, func(tx sql.Tx) error {
+			var value int
+			return tx.QueryRowContext(ctx, "q1", 5).Scan(&value)
+		})
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int32{"q1": 5}, calls(db.GetMetrics().Statements))
+	assert.Equal(t, 1, prepares(mock, "q1"))
+	assert.Equal(t, 1, prepares(mock, "q2"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
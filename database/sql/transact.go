@@ -0,0 +1,142 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines retrying of transactions, that are failed by deadlock
+// or serialization failure (see Adapter.IsDeadlock).
+type RetryPolicy struct {
+	MaxAttempts int           // Maximum count of attempts (including the first)
+	MinBackoff  time.Duration // Delay before the second attempt
+	MaxBackoff  time.Duration // Maximum delay between attempts
+}
+
+// Delay before attempt (starting from 2) with exponential growth and jitter.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.MinBackoff
+	for i := 2; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Jitter in range [delay/2, delay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// TransactRetryPolicy is policy of retrying, used by Transact.
+var TransactRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	MinBackoff:  10 * time.Millisecond,
+	MaxBackoff:  time.Second,
+}
+
+// Transact executes action within transaction of scope.
+// If scope is transaction, action is executed within savepoint.
+// Transaction is committed, if action returns nil, otherwise it is rolled back.
+// Top level transaction is retried on deadlock or serialization failure
+// according to TransactRetryPolicy. Nested transactions are not retried,
+// because such failures abort the whole transaction, so error is passed
+// to the top level.
+func Transact(
+	ctx context.Context,
+	scope Scope,
+	opts *TxOptions,
+	action func(tx Tx) error,
+) error {
+	db, isDB := scope.(DB)
+	if !isDB {
+		return transactOnce(ctx, scope, opts, action)
+	}
+
+	policy := TransactRetryPolicy
+	for attempt := 1; ; attempt++ {
+		err := transactOnce(ctx, scope, opts, action)
+		if err == nil || attempt >= policy.MaxAttempts || !scope.Adapter().IsDeadlock(db, err) {
+			return err
+		}
+
+		db.retried()
+		timer := time.NewTimer(policy.backoff(attempt + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func transactOnce(
+	ctx context.Context,
+	scope Scope,
+	opts *TxOptions,
+	action func(tx Tx) error,
+) (err error) {
+	tx, err := scope.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			_ = tx.Rollback()
+			panic(e)
+		}
+	}()
+
+	err = action(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (db *database1) Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error {
+	return Transact(ctx, db, opts, action)
+}
+
+func (db *database2) Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error {
+	return Transact(ctx, db, opts, action)
+}
+
+func (t *tx) Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error {
+	return Transact(ctx, t, opts, action)
+}
+
+func (db *profilerDB) Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error {
+	return Transact(ctx, db, opts, action)
+}
+
+func (t *profilerTx) Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error {
+	return Transact(ctx, t, opts, action)
+}
+
+func (db *exclusiveDatabase) Transact(ctx context.Context, opts *TxOptions, action func(tx Tx) error) error {
+	return Transact(ctx, db, opts, action)
+}
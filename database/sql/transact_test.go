@@ -0,0 +1,109 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransact(t *testing.T) {
+	policy := sql.TransactRetryPolicy
+	defer func() { sql.TransactRetryPolicy = policy }()
+	sql.TransactRetryPolicy = sql.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	type Test struct {
+		expect   func(mock *sqltest.Mock)
+		action   error
+		attempts int
+		err      error
+	}
+
+	// Successful attempt with nested transaction
+	attempt := func(mock *sqltest.Mock, commit error) {
+		mock.ExpectBegin()
+		mock.ExpectSavepoint("trans1")
+		mock.ExpectRelease("trans1")
+		mock.ExpectCommit().WillReturnError(commit)
+	}
+
+	errAction := errors.New("action")
+	tests := map[string]Test{
+		"Commit": {
+			expect: func(mock *sqltest.Mock) {
+				attempt(mock, nil)
+			},
+			attempts: 1,
+		},
+		"Rollback": {
+			expect: func(mock *sqltest.Mock) {
+				mock.ExpectBegin()
+				mock.ExpectSavepoint("trans1")
+				mock.ExpectRollbackTo("trans1")
+				mock.ExpectRollback()
+			},
+			action:   errAction,
+			attempts: 1,
+			err:      errAction,
+		},
+		"Retry": {
+			expect: func(mock *sqltest.Mock) {
+				attempt(mock, sqltest.ErrDeadlock)
+				attempt(mock, nil)
+			},
+			attempts: 2,
+		},
+		"Too many deadlocks": {
+			expect: func(mock *sqltest.Mock) {
+				for i := 0; i < 3; i++ {
+					attempt(mock, sqltest.ErrDeadlock)
+				}
+			},
+			attempts: 3,
+			err:      sqltest.ErrDeadlock,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock := sqltest.New()
+			defer mock.Close()
+			test.expect(mock)
+
+			db, err := sqltest.Open(nil, mock)
+			require.NoError(t, err)
+			defer db.Close(context.Background())
+
+			attempts := 0
+			err = db.Transact(context.Background(), nil, func(tx sql.Tx) error {
+				attempts++
+				return tx.Transact(context.Background(), nil, func(tx sql.Tx) error {
+					assert.Equal(t, int16(1), tx.Level())
+					return test.action
+				})
+			})
+
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.attempts, attempts)
+			assert.Equal(t, int32(test.attempts-1), db.GetMetrics().Retries)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := sql.RetryPolicy{MinBackoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+	for i := 0; i < 10; i++ {
+		d := sql.Backoff(policy, 2)
+		assert.True(t, d >= 5*time.Millisecond && d <= 10*time.Millisecond, d)
+		d = sql.Backoff(policy, 3)
+		assert.True(t, d >= 10*time.Millisecond && d <= 20*time.Millisecond, d)
+		d = sql.Backoff(policy, 10)
+		assert.True(t, d >= 15*time.Millisecond && d <= 30*time.Millisecond, d)
+	}
+}
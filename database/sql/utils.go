@@ -61,17 +61,27 @@ func ToContext(
 	return context.WithValue(ctx, scope.DbId(), scope)
 }
 
+// Heartbeart pings database periodically.
+// Replicas of cluster are ejected on failure and re-admitted on recovery.
+// Returns error, when master is not available.
 func Heartbeart(
 	ctx context.Context,
 	db DB,
 	interval time.Duration,
 ) error {
+	cluster, isCluster := AsCluster(db)
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-time.After(interval):
-			if err := db.Ping(); err != nil {
+			var err error
+			if isCluster {
+				err = cluster.Check(ctx)
+			} else {
+				err = db.Ping()
+			}
+			if err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,233 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+)
+
+// UUID is an universally unique identifier (RFC 4122).
+type UUID [16]byte
+
+// NewUUID generates random UUID (version 4).
+func NewUUID() (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		return u, err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// ParseUUID parses UUID in canonical form ("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+// optionally enclosed into braces or with prefix "urn:uuid:", or as 32 hex digits.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	src := strings.TrimPrefix(s, "urn:uuid:")
+	if len(src) == 38 && src[0] == '{' && src[37] == '}' {
+		src = src[1:37]
+	}
+	if len(src) == 36 {
+		if src[8] != '-' || src[13] != '-' || src[18] != '-' || src[23] != '-' {
+			return u, fmt.Errorf("sql: invalid UUID %q", s)
+		}
+		src = src[0:8] + src[9:13] + src[14:18] + src[19:23] + src[24:]
+	}
+	if len(src) != 32 {
+		return u, fmt.Errorf("sql: invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return u, fmt.Errorf("sql: invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// String returns UUID in canonical form.
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// Scan reads UUID from BINARY(16) or text column.
+func (u *UUID) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case UUID:
+		*u = v
+		return nil
+	case NullUUID:
+		if !v.Valid {
+			return errors.New("sql: converting NULL to UUID is unsupported")
+		}
+		*u = v.UUID
+		return nil
+	case []byte:
+		if len(v) == len(u) {
+			copy(u[:], v)
+			return nil
+		}
+		return u.UnmarshalText(v)
+	case string:
+		return u.UnmarshalText([]byte(v))
+	case nil:
+		return errors.New("sql: converting NULL to UUID is unsupported")
+	default:
+		return fmt.Errorf("sql: converting %T to UUID is unsupported", value)
+	}
+}
+
+// Value writes UUID as text. Use NullUUID with Binary flag for BINARY(16) columns.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(data []byte) error {
+	res, err := ParseUUID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = res
+	return nil
+}
+
+// NullUUID represents an UUID that may be null.
+// NullUUID implements the Scanner interface so
+// it can be used as a scan destination, similar to NullString.
+// NullUUID implements the echo.Codec interface, so it can be used
+// as codec of form fields:
+//   &widget.FormText{Codec: &sql.NullUUID{Binary: true}}
+type NullUUID struct {
+	UUID   UUID
+	Valid  bool // Valid is true if UUID is not NULL
+	Binary bool // Value is stored as BINARY(16) instead of text
+}
+
+func (n *NullUUID) Scan(value interface{}) error {
+	if value == nil {
+		n.UUID, n.Valid = UUID{}, false
+		return nil
+	}
+	if v, ok := value.(NullUUID); ok {
+		n.UUID, n.Valid = v.UUID, v.Valid
+		return nil
+	}
+	n.Valid = true
+	return n.UUID.Scan(value)
+}
+
+func (n NullUUID) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	if n.Binary {
+		return n.UUID[:], nil
+	}
+	return n.UUID.String(), nil
+}
+
+func (n NullUUID) Internal() driver.Value {
+	value, _ := n.Value()
+	return value
+}
+
+func (n NullUUID) External() interface{} {
+	if !n.Valid {
+		return UUID{}
+	}
+	return n.UUID
+}
+
+func (n *NullUUID) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(n.UUID.String())
+}
+
+func (n *NullUUID) UnmarshalJSON(data []byte) error {
+	// Unmarshalling into a pointer will let us detect null
+	var x *UUID
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	if x != nil {
+		n.Valid = true
+		n.UUID = *x
+	} else {
+		n.UUID, n.Valid = UUID{}, false
+	}
+	return nil
+}
+
+func (n *NullUUID) Empty(ctx echo.Context) (interface{}, error) {
+	return NullUUID{Binary: n.Binary}, nil
+}
+
+func (n *NullUUID) Encode(ctx echo.Context, value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return NullUUID{Binary: n.Binary}, nil
+	}
+	u, err := ParseUUID(value)
+	if err != nil {
+		return nil, echo.ValidationErrors{
+			echo.ValidationErrorInvalidValue,
+		}
+	}
+	return NullUUID{UUID: u, Valid: true, Binary: n.Binary}, nil
+}
+
+func (n *NullUUID) Decode(ctx echo.Context, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case NullUUID:
+		if !v.Valid {
+			return "", nil
+		}
+		return v.UUID.String(), nil
+	case UUID:
+		return v.String(), nil
+	}
+	val, _ := generic.ConvertToString(value)
+	return val, nil
+}
@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	const canonical = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	for _, src := range []string{
+		canonical,
+		"6BA7B810-9DAD-11D1-80B4-00C04FD430C8",
+		"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}",
+		"urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"6ba7b8109dad11d180b400c04fd430c8",
+	} {
+		u, err := ParseUUID(src)
+		require.NoError(t, err, src)
+		assert.Equal(t, canonical, u.String(), src)
+	}
+
+	for _, src := range []string{"", "6ba7b810-9dad-11d1-80b4", "6ba7b810+9dad-11d1-80b4-00c04fd430c8", "xba7b8109dad11d180b400c04fd430c8"} {
+		_, err := ParseUUID(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	a, err := NewUUID()
+	require.NoError(t, err)
+	b, err := NewUUID()
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+	assert.False(t, a.IsZero())
+	assert.Equal(t, byte('4'), a.String()[14])
+}
+
+func TestNullUUID(t *testing.T) {
+	u, err := ParseUUID("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	require.NoError(t, err)
+
+	// BINARY(16)
+	n := NullUUID{Binary: true}
+	require.NoError(t, n.Scan(u[:]))
+	assert.Equal(t, NullUUID{UUID: u, Valid: true, Binary: true}, n)
+	value, err := n.Value()
+	require.NoError(t, err)
+	assert.Equal(t, u[:], value)
+
+	// Text
+	n = NullUUID{}
+	require.NoError(t, n.Scan([]byte(u.String())))
+	assert.Equal(t, u, n.UUID)
+	value, err = n.Value()
+	require.NoError(t, err)
+	assert.Equal(t, u.String(), value)
+
+	assert.Error(t, n.Scan(int64(1)))
+	require.NoError(t, n.Scan(nil))
+	assert.False(t, n.Valid)
+
+	data, err := json.Marshal(&struct {
+		Id     *NullUUID
+		Parent *NullUUID
+	}{
+		Id:     &NullUUID{UUID: u, Valid: true},
+		Parent: &NullUUID{},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"Id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","Parent":null}`, string(data))
+
+	require.NoError(t, json.Unmarshal([]byte(`"6ba7b810-9dad-11d1-80b4-00c04fd430c8"`), &n))
+	assert.Equal(t, NullUUID{UUID: u, Valid: true}, n)
+}
+
+func TestNullUUID_Codec(t *testing.T) {
+	var codec echo.Codec = &NullUUID{Binary: true}
+
+	val, err := codec.Encode(nil, " 6ba7b810-9dad-11d1-80b4-00c04fd430c8 ")
+	require.NoError(t, err)
+	s, err := codec.Decode(nil, val)
+	require.NoError(t, err)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", s)
+	assert.True(t, val.(NullUUID).Binary)
+
+	_, err = codec.Encode(nil, "123")
+	assert.Equal(t, echo.ValidationErrors{echo.ValidationErrorInvalidValue}, err)
+
+	var dst NullUUID
+	require.NoError(t, generic.ConvertAssign(&dst, val))
+	assert.Equal(t, val.(NullUUID).UUID, dst.UUID)
+}
@@ -0,0 +1,260 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/adverax/echo"
+)
+
+// WorkMode defines, how unit of work accesses the database.
+type WorkMode int
+
+const (
+	WorkReadWrite WorkMode = iota // Transaction on master
+	WorkReadOnly                  // Read-only transaction on master
+	WorkReplica                   // Queries without transaction on replica
+)
+
+// UnitOfWorkConfig defines the config for unit of work.
+type UnitOfWorkConfig struct {
+	// Skipper defines a function to skip unit of work.
+	// Optional.
+	Skipper func(ctx echo.Context) bool
+
+	// Mode of access to the database.
+	// Optional. Default value WorkReadWrite.
+	Mode WorkMode
+
+	// Open transaction only for unsafe methods (POST, PUT, PATCH, DELETE).
+	// Requests with safe methods are served by replica.
+	// Optional. Default value false.
+	Unsafe bool
+
+	// Isolation level of transaction.
+	// Optional. Default value is default level of driver.
+	Isolation IsolationLevel
+}
+
+// Transactional decorates handler with unit of work. Transaction is placed
+// into context of handler (see FromContext and Repository.Scope).
+// It is committed, when handler returns nil and it is rolled back on error or panic.
+// Example:
+//   router.Post("/users", sql.Transactional(db, sql.UnitOfWorkConfig{})(handler))
+//   router.Get("/users", sql.Transactional(db, sql.UnitOfWorkConfig{Mode: sql.WorkReplica})(handler))
+func Transactional(
+	db DB,
+	config UnitOfWorkConfig,
+) func(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) (err error) {
+			if config.Skipper != nil && config.Skipper(ctx) {
+				return next(ctx)
+			}
+
+			scope, tx, err := config.begin(ctx, db, ctx.Request().Method)
+			if err != nil {
+				return err
+			}
+			if tx == nil {
+				return next(ctx.WithValue(scope.DbId(), scope))
+			}
+
+			defer func() {
+				if rvr := recover(); rvr != nil {
+					_ = tx.Rollback()
+					panic(rvr)
+				}
+			}()
+
+			err = next(ctx.WithValue(scope.DbId(), scope))
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+
+			return tx.Commit()
+		}
+	}
+}
+
+// UnitOfWork is a middleware, that opens unit of work for each request.
+// In contrast with Transactional, errors of handlers are handled by echo
+// before the end of unit of work, so transaction is rolled back, when
+// status of response is 400 or greater.
+// Response of transaction is buffered and it is sent after commit, so
+// failed commit is reported to the client as error. Flush is ignored and
+// hijacking of connection is not supported (use Skipper for streaming).
+// Example:
+//   router.Use(sql.UnitOfWork(db, sql.UnitOfWorkConfig{Unsafe: true}))
+func UnitOfWork(
+	db DB,
+	config UnitOfWorkConfig,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := echo.RequestContext(r)
+			if config.Skipper != nil && config.Skipper(ctx) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scope, tx, err := config.begin(r.Context(), db, r.Method)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			r = r.WithContext(ToContext(r.Context(), scope))
+			if tx == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := ctx.Response()
+			writer := res.Writer
+			buf := newWorkBuffer(writer)
+			res.Writer = buf
+
+			defer func() {
+				res.Writer = writer
+				if rvr := recover(); rvr != nil {
+					_ = tx.Rollback()
+					panic(rvr)
+				}
+			}()
+
+			next.ServeHTTP(buf, r)
+			res.Writer = writer
+
+			if res.Status >= http.StatusBadRequest {
+				_ = tx.Rollback()
+				buf.flush()
+				return
+			}
+
+			if err := tx.Commit(); err != nil {
+				// Discard response of handler and report error instead
+				res.Status = http.StatusOK
+				res.Size = 0
+				res.Committed = false
+				ctx.Error(err)
+				return
+			}
+
+			buf.flush()
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Response, that is buffered until the end of unit of work.
+type workBuffer struct {
+	writer http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newWorkBuffer(writer http.ResponseWriter) *workBuffer {
+	header := make(http.Header, len(writer.Header()))
+	for key, values := range writer.Header() {
+		header[key] = append([]string(nil), values...)
+	}
+	return &workBuffer{writer: writer, header: header}
+}
+
+func (buf *workBuffer) Header() http.Header {
+	return buf.header
+}
+
+func (buf *workBuffer) WriteHeader(code int) {
+	if buf.status == 0 {
+		buf.status = code
+	}
+}
+
+func (buf *workBuffer) Write(data []byte) (int, error) {
+	if buf.status == 0 {
+		buf.status = http.StatusOK
+	}
+	return buf.body.Write(data)
+}
+
+// Flush is ignored until the end of unit of work.
+func (buf *workBuffer) Flush() {}
+
+// Send buffered response.
+func (buf *workBuffer) flush() {
+	header := buf.writer.Header()
+	for key := range header {
+		if _, ok := buf.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range buf.header {
+		header[key] = values
+	}
+	if buf.status == 0 {
+		return
+	}
+	buf.writer.WriteHeader(buf.status)
+	_, _ = buf.writer.Write(buf.body.Bytes())
+}
+
+// Open scope of unit of work. Returns nil transaction, if it is not required.
+func (config *UnitOfWorkConfig) begin(
+	ctx context.Context,
+	db DB,
+	method string,
+) (Scope, Tx, error) {
+	mode := config.Mode
+	if config.Unsafe && isSafeMethod(method) {
+		mode = WorkReplica
+	}
+
+	if mode == WorkReplica {
+		return db.Slave(), nil, nil
+	}
+
+	tx, err := db.BeginTx(
+		ctx,
+		&TxOptions{
+			Isolation: sql.IsolationLevel(config.Isolation),
+			ReadOnly:  mode == WorkReadOnly,
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx, tx, nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,212 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Expect statements of log, written by test of unit of work.
+func expectLog(mock *sqltest.Mock, log []string) {
+	for _, stmt := range log {
+		switch stmt {
+		case "BEGIN":
+			mock.ExpectBegin()
+		case "BEGIN READ ONLY":
+			mock.ExpectBeginReadOnly()
+		case "COMMIT":
+			mock.ExpectCommit()
+		case "DEADLOCK":
+			mock.ExpectCommit().WillReturnError(sqltest.ErrDeadlock)
+		case "ROLLBACK":
+			mock.ExpectRollback()
+		default:
+			mock.ExpectExec(stmt)
+		}
+	}
+}
+
+func TestTransactional(t *testing.T) {
+	type Test struct {
+		method string
+		config sql.UnitOfWorkConfig
+		err    error
+		status int
+		scope  string
+		log    []string
+	}
+
+	errAction := errors.New("action")
+	tests := map[string]Test{
+		"Commit": {
+			method: http.MethodPost,
+			status: http.StatusOK,
+			scope:  "tx",
+			log:    []string{"BEGIN", "INSERT", "COMMIT"},
+		},
+		"Rollback": {
+			method: http.MethodPost,
+			err:    errAction,
+			status: http.StatusInternalServerError,
+			scope:  "tx",
+			log:    []string{"BEGIN", "INSERT", "ROLLBACK"},
+		},
+		"Read only": {
+			method: http.MethodGet,
+			config: sql.UnitOfWorkConfig{Mode: sql.WorkReadOnly},
+			status: http.StatusOK,
+			scope:  "tx",
+			log:    []string{"BEGIN READ ONLY", "INSERT", "COMMIT"},
+		},
+		"Replica": {
+			method: http.MethodGet,
+			config: sql.UnitOfWorkConfig{Mode: sql.WorkReplica},
+			status: http.StatusOK,
+			scope:  "db",
+			log:    []string{"INSERT"},
+		},
+		"Safe method": {
+			method: http.MethodGet,
+			config: sql.UnitOfWorkConfig{Unsafe: true},
+			status: http.StatusOK,
+			scope:  "db",
+			log:    []string{"INSERT"},
+		},
+		"Unsafe method": {
+			method: http.MethodDelete,
+			config: sql.UnitOfWorkConfig{Unsafe: true},
+			status: http.StatusOK,
+			scope:  "tx",
+			log:    []string{"BEGIN", "INSERT", "COMMIT"},
+		},
+		"Skipper": {
+			method: http.MethodPost,
+			config: sql.UnitOfWorkConfig{Skipper: func(echo.Context) bool { return true }},
+			status: http.StatusOK,
+			scope:  "none",
+			log:    []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock := sqltest.New()
+			defer mock.Close()
+			expectLog(mock, test.log)
+			db, err := sqltest.Open(nil, mock)
+			require.NoError(t, err)
+			defer db.Close(context.Background())
+
+			var scope string
+			handler := func(ctx echo.Context) error {
+				switch s := sql.FromContext(ctx, db.DbId()).(type) {
+				case sql.Tx:
+					scope = "tx"
+				case sql.DB:
+					scope = "db"
+				case nil:
+					scope = "none"
+					return nil
+				default:
+					t.Fatalf("unexpected scope %T", s)
+				}
+				_, err := sql.NewRepository(db).Scope(ctx).ExecContext(ctx, "INSERT")
+				require.NoError(t, err)
+				return test.err
+			}
+
+			e := echo.New()
+			e.Router().Method(test.method, "/", sql.Transactional(db, test.config)(handler))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(test.method, "/", nil))
+
+			assert.Equal(t, test.status, rec.Code)
+			assert.Equal(t, test.scope, scope)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUnitOfWork(t *testing.T) {
+	type Test struct {
+		status int
+		panic  bool
+		code   int
+		body   string
+		log    []string
+	}
+
+	tests := map[string]Test{
+		"Commit": {
+			status: http.StatusCreated,
+			code:   http.StatusCreated,
+			body:   "created",
+			log:    []string{"BEGIN", "INSERT", "COMMIT"},
+		},
+		"Rollback on error status": {
+			status: http.StatusConflict,
+			code:   http.StatusConflict,
+			body:   "created",
+			log:    []string{"BEGIN", "INSERT", "ROLLBACK"},
+		},
+		"Rollback on panic": {
+			panic: true,
+			log:   []string{"BEGIN", "INSERT", "ROLLBACK"},
+		},
+		"Failed commit is reported to client": {
+			status: http.StatusCreated,
+			code:   http.StatusInternalServerError,
+			log:    []string{"BEGIN", "INSERT", "DEADLOCK"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock := sqltest.New()
+			defer mock.Close()
+			expectLog(mock, test.log)
+			db, err := sqltest.Open(nil, mock)
+			require.NoError(t, err)
+			defer db.Close(context.Background())
+
+			e := echo.New()
+			e.Router().Use(sql.UnitOfWork(db, sql.UnitOfWorkConfig{}))
+			e.Router().Post("/", func(ctx echo.Context) error {
+				scope := sql.FromContext(ctx.Request().Context(), db.DbId())
+				require.NotNil(t, scope)
+				_, err := scope.ExecContext(ctx, "INSERT")
+				require.NoError(t, err)
+				if test.panic {
+					panic("failure")
+				}
+				ctx.Response().Header().Set("X-Created", "1")
+				return ctx.String(test.status, "created")
+			})
+
+			rec := httptest.NewRecorder()
+			serve := func() { e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil)) }
+			if test.panic {
+				assert.Panics(t, serve)
+			} else {
+				serve()
+				assert.Equal(t, test.code, rec.Code)
+				if test.body != "" {
+					assert.Equal(t, test.body, rec.Body.String())
+					assert.Equal(t, "1", rec.Header().Get("X-Created"))
+				} else {
+					assert.NotContains(t, rec.Body.String(), "created")
+					assert.Empty(t, rec.Header().Get("X-Created"))
+				}
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
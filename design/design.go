@@ -107,19 +107,45 @@ func NewDesigner(
 	path string, // Path to the views folder
 	layouts string, // Path to the layouts folder
 	files ...string, // Loaded views
+) Designer {
+	return NewThemeDesigner(e, nil, funcs, path, layouts, files...)
+}
+
+// NewThemeDesigner creates designer, that contains templates of theme.
+// Loaded views may override any template of theme.
+func NewThemeDesigner(
+	e *echo.Echo,
+	theme *Theme, // Theme for widgets (optional)
+	funcs template.FuncMap,
+	path string, // Path to the views folder
+	layouts string, // Path to the layouts folder
+	files ...string, // Loaded views
 ) Designer {
 	path = addTrailingSlash(path)
 	layouts = addTrailingSlash(layouts)
 
-	if funcs == nil {
-		funcs = make(template.FuncMap)
+	fs := make(template.FuncMap)
+	if theme != nil {
+		for k, v := range theme.Funcs {
+			fs[k] = v
+		}
+	}
+	for k, v := range funcs {
+		fs[k] = v
 	}
+	funcs = fs
 
 	for i, file := range files {
 		files[i] = layouts + file
 	}
 
-	tpl := template.Must(template.New("root").Funcs(funcs).ParseFiles(files...))
+	tpl := template.New("root").Funcs(funcs)
+	if theme != nil {
+		theme.parse(tpl)
+	}
+	if theme == nil || len(files) != 0 {
+		template.Must(tpl.ParseFiles(files...))
+	}
 
 	return &designer{
 		echo:    e,
@@ -0,0 +1,358 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package design
+
+// ThemeBootstrap renders widgets with markup of Bootstrap 4.
+var ThemeBootstrap = &Theme{
+	Name:   "bootstrap",
+	Funcs:  themeFuncs,
+	Source: bootstrapSource,
+}
+
+const bootstrapSource = `
+{{- define "widget/alert"}}{{with .}}
+<div class="alert alert-{{if eq .Type "siccess"}}success{{else}}{{.Type}}{{end}}" role="alert">{{.Message}}</div>
+{{- end}}{{end}}
+
+{{- define "widget/breadcrumbs"}}{{if .}}
+<nav aria-label="breadcrumb">
+<ol class="breadcrumb">
+{{- range .}}
+{{- if .Action}}
+<li class="breadcrumb-item"><a href="{{.Action}}">{{.Label}}</a></li>
+{{- else}}
+<li class="breadcrumb-item active" aria-current="page">{{.Label}}</li>
+{{- end}}
+{{- end}}
+</ol>
+</nav>
+{{- end}}{{end}}
+
+{{- define "widget/action"}}{{with .}}
+{{- if .Post}}
+<form class="d-inline" method="post" action="{{.Action}}">
+<button type="submit" class="btn btn-primary"{{template "widget/action/attrs" .}}>{{.Label}}</button>
+</form>
+{{- else if .Action}}
+<a class="btn btn-primary{{if .Disabled}} disabled{{end}}" href="{{.Action}}" role="button"
+{{- with .Tooltip}} title="{{.}}"{{end}}
+{{- with .Confirm}} onclick="return confirm({{.}})"{{end}}>{{.Label}}</a>
+{{- else}}
+<button type="{{.Type}}" class="btn {{if eq .Type "submit"}}btn-primary{{else}}btn-secondary{{end}}"{{template "widget/action/attrs" .}}>{{.Label}}</button>
+{{- end}}
+{{- end}}{{end}}
+
+{{- define "widget/action/attrs"}}
+{{- with .Name}} name="{{.}}"{{end}}
+{{- with .Value}} value="{{.}}"{{end}}
+{{- with .Tooltip}} title="{{.}}"{{end}}
+{{- with .Confirm}} onclick="return confirm({{.}})"{{end}}
+{{- if .Disabled}} disabled{{end}}
+{{- end}}
+
+{{- define "widget/pager"}}{{with .}}
+<div class="d-flex justify-content-between align-items-center">
+<small class="text-muted">{{.Message}}</small>
+{{- with .Buttons}}
+<ul class="pagination mb-0">
+{{- with .Prev}}{{template "widget/pager/button" .}}{{end}}
+{{- range .Band}}{{template "widget/pager/button" .}}{{end}}
+{{- with .Next}}{{template "widget/pager/button" .}}{{end}}
+</ul>
+{{- end}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/pager/button"}}
+{{- if .Disabled}}
+<li class="page-item disabled"><span class="page-link">{{.Label}}</span></li>
+{{- else if .Active}}
+<li class="page-item active" aria-current="page"><span class="page-link">{{.Label}}</span></li>
+{{- else}}
+<li class="page-item"><a class="page-link" href="{{.Action}}">{{.Label}}</a></li>
+{{- end}}
+{{- end}}
+
+{{- define "widget/band"}}{{with .}}
+<div class="list-group mb-3">
+{{- range .Items}}{{template "widget/band/item" .}}{{end}}
+</div>
+{{- template "widget/pager" .Pager}}
+{{- end}}{{end}}
+
+{{- define "widget/band/item"}}
+<div class="list-group-item">{{.}}</div>
+{{- end}}
+
+{{- define "widget/table"}}{{with .}}
+{{- with .Filters}}
+<form id="{{.Id}}" class="form-inline mb-2" method="get" action="{{.Action}}">
+{{- range .Params}}
+<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+{{- end}}
+<button type="submit" class="btn btn-outline-secondary btn-sm">{{.Submit}}</button>
+</form>
+{{- end}}
+<table class="table table-striped table-hover">
+<thead>
+<tr>
+{{- range .Head}}
+<th scope="col"{{with .Class}} class="{{.}}"{{end}}>
+{{- if .Action}}<a href="{{.Action}}"{{with .Sorted}} class="sorted-{{.}}"{{end}}>{{.Label}}</a>
+{{- else}}{{.Label}}{{end}}</th>
+{{- end}}
+</tr>
+{{- if .Filters}}
+<tr class="table-filters">
+{{- range .Head}}
+<td>{{with .Filter}}{{template "widget/table/filter" .}}{{end}}</td>
+{{- end}}
+</tr>
+{{- end}}
+</thead>
+<tbody>
+{{- range .Body}}
+<tr{{with .Class}} class="{{.}}"{{end}}>
+{{- range .Cols}}
+<td>{{template "widget/table/cell" .}}</td>
+{{- end}}
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- template "widget/pager" .Pager}}
+{{- end}}{{end}}
+
+{{- define "widget/table/cell"}}
+{{- if isMap .}}
+{{- range $name, $action := .}}
+{{- if $action.Post}}
+<form class="d-inline" method="post" action="{{$action.Action}}"><button type="submit" class="btn btn-link btn-sm"
+{{- with $action.Tooltip}} title="{{.}}"{{end}}
+{{- with $action.Confirm}} onclick="return confirm({{.}})"{{end}}>{{$name}}</button></form>
+{{- else}}
+<a class="btn btn-link btn-sm" href="{{$action.Action}}"
+{{- with $action.Tooltip}} title="{{.}}"{{end}}
+{{- with $action.Confirm}} onclick="return confirm({{.}})"{{end}}>{{$name}}</a>
+{{- end}}
+{{- end}}
+{{- else}}{{.}}{{end}}
+{{- end}}
+
+{{- define "widget/table/filter"}}
+{{- if eq .Kind "select"}}
+<select class="form-control form-control-sm" name="{{.Name}}" form="{{.Form}}">
+{{- with .Empty}}
+<option value=""{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+{{- range .Items}}
+<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+</select>
+{{- else if eq .Kind "date-range"}}
+<input type="date" class="form-control form-control-sm" name="{{.From.Name}}" value="{{.From.Value}}" form="{{.Form}}">
+<input type="date" class="form-control form-control-sm" name="{{.To.Name}}" value="{{.To.Value}}" form="{{.Form}}">
+{{- else}}
+<input type="text" class="form-control form-control-sm" name="{{.Name}}"{{with .Value}} value="{{.}}"{{end}}{{with .Placeholder}} placeholder="{{.}}"{{end}} form="{{.Form}}">
+{{- end}}
+{{- end}}
+
+{{- define "widget/detail-view"}}{{with .}}
+<table class="table table-sm">
+<thead>
+<tr><th scope="col">{{.Head.Key}}</th><th scope="col">{{.Head.Value}}</th></tr>
+</thead>
+<tbody>
+{{- range .Body}}
+<tr{{with .Type}} class="detail-{{.}}"{{end}}><th scope="row">{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}{{end}}
+
+{{- define "widget/nav-bar"}}{{with .}}
+<nav class="navbar navbar-expand-lg navbar-light bg-light">
+{{- with .Brand}}
+<a class="navbar-brand" href="/">{{.}}</a>
+{{- end}}
+<ul class="navbar-nav mr-auto">
+{{- range .Items}}{{template "widget/nav-bar/item" .}}{{end}}
+</ul>
+</nav>
+{{- end}}{{end}}
+
+{{- define "widget/nav-bar/item"}}
+{{- $type := or .Type .type}}
+{{- if eq $type "item"}}
+<li class="nav-item{{if .Active}} active{{end}}"><a class="nav-link" href="{{.Action}}">{{.Label}}</a></li>
+{{- else if eq $type "separator"}}
+<li class="nav-item border-left mx-2" role="separator"></li>
+{{- else if eq $type "menu"}}
+<li class="nav-item dropdown">
+<a class="nav-link dropdown-toggle" href="#" role="button" data-toggle="dropdown" aria-haspopup="true" aria-expanded="false">{{.Label}}</a>
+<div class="dropdown-menu">
+{{- range .Items}}
+{{- if eq .Type "separator"}}
+<div class="dropdown-divider"></div>
+{{- else}}
+<a class="dropdown-item{{if .Active}} active{{end}}" href="{{.Action}}">{{.Label}}</a>
+{{- end}}
+{{- end}}
+</div>
+</li>
+{{- else if eq $type "text"}}
+<li class="nav-item"><span class="navbar-text">{{.Items}}</span></li>
+{{- else if eq $type "link"}}
+<li class="nav-item">
+{{- if .Post}}
+<form class="form-inline" method="post" action="{{.Action}}"><button type="submit" class="btn btn-link nav-link"{{with .Tooltip}} title="{{.}}"{{end}}>{{.Label}}</button></form>
+{{- else}}
+<a class="nav-link" href="{{.Action}}"{{with .Tooltip}} title="{{.}}"{{end}}>{{.Label}}</a>
+{{- end}}
+</li>
+{{- else if eq $type "form"}}
+<li class="nav-item">
+<form class="form-inline" method="{{.Method}}"{{with .Action}} action="{{.}}"{{end}}{{with .Id}} id="{{.}}"{{end}}{{with .Name}} name="{{.}}"{{end}}>
+{{- range .Model}}
+{{- if .Items}}{{template "widget/form/select" .}}{{else}}{{template "widget/form/text" .}}{{end}}
+{{- end}}
+</form>
+</li>
+{{- end}}
+{{- end}}
+
+{{- define "widget/form/begin"}}
+<form method="{{.Method}}"{{with .Action}} action="{{.}}"{{end}}{{with .Id}} id="{{.}}"{{end}}{{with .Name}} name="{{.}}"{{end}}>
+{{- end}}
+
+{{- define "widget/form/end"}}
+</form>
+{{- end}}
+
+{{- define "widget/form/attrs"}}
+{{- with .Id}} id="{{.}}"{{end}}
+{{- with .Name}} name="{{.}}"{{end}}
+{{- if .Required}} required{{end}}
+{{- if .Readonly}} readonly{{end}}
+{{- if .Disabled}} disabled{{end}}
+{{- end}}
+
+{{- define "widget/form/required"}}
+{{- if .Required}} <span class="text-danger">*</span>{{end}}
+{{- end}}
+
+{{- define "widget/form/label"}}
+{{- with .Label}}
+<label{{with $.Id}} for="{{.}}"{{end}}>{{.}}{{template "widget/form/required" $}}</label>
+{{- end}}
+{{- end}}
+
+{{- define "widget/form/errors"}}
+{{- range .Errors}}
+<div class="invalid-feedback d-block">{{.}}</div>
+{{- end}}
+{{- end}}
+
+{{- define "widget/form/text"}}{{with .}}
+<div class="form-group">
+{{- template "widget/form/label" .}}
+{{- if .Rows}}
+<textarea class="form-control{{if .Errors}} is-invalid{{end}}" rows="{{.Rows}}"{{template "widget/form/attrs" .}}
+{{- with .Placeholder}} placeholder="{{.}}"{{end}}
+{{- with .MaxLen}} maxlength="{{.}}"{{end}}>{{.Value}}</textarea>
+{{- else}}
+<input type="text" class="form-control{{if .Errors}} is-invalid{{end}}"{{template "widget/form/attrs" .}}
+{{- with .Placeholder}} placeholder="{{.}}"{{end}}
+{{- with .MaxLen}} maxlength="{{.}}"{{end}}
+{{- with .Pattern}} pattern="{{.}}"{{end}}
+{{- with .Value}} value="{{.}}"{{end}}>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/select"}}{{with .}}
+<div class="form-group">
+{{- template "widget/form/label" .}}
+<select class="form-control{{if .Errors}} is-invalid{{end}}"{{template "widget/form/attrs" .}}>
+{{- with .Empty}}
+<option value=""{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+{{- range .Items}}
+<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+</select>
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/flag"}}{{with .}}
+<div class="form-group form-check">
+<input type="checkbox" class="form-check-input{{if .Errors}} is-invalid{{end}}" value="{{.Value}}"{{template "widget/form/attrs" .}}{{if .Selected}} checked{{end}}>
+{{- with .Label}}
+<label class="form-check-label"{{with $.Id}} for="{{.}}"{{end}}>{{.}}</label>
+{{- end}}
+{{- with .Placeholder}}
+<small class="form-text text-muted">{{.}}</small>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/flags"}}{{with .}}
+<fieldset class="form-group">
+{{- with .Label}}
+<legend class="col-form-label">{{.}}{{template "widget/form/required" $}}</legend>
+{{- end}}
+{{- range .Items}}
+<div class="form-check">
+<label class="form-check-label"><input type="checkbox" class="form-check-input" name="{{$.Name}}" value="{{.Value}}"{{if .Selected}} checked{{end}}{{if $.Disabled}} disabled{{end}}> {{.Label}}</label>
+</div>
+{{- end}}
+{{- with .Placeholder}}
+<small class="form-text text-muted">{{.}}</small>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</fieldset>
+{{- end}}{{end}}
+
+{{- define "widget/form/submit"}}{{with .}}
+<div class="form-group">
+{{- if .Items}}
+{{- range .Items}}
+<button type="submit" class="btn {{if .Selected}}btn-primary{{else}}btn-secondary{{end}}" name="{{$.Name}}" value="{{.Value}}"{{if $.Disabled}} disabled{{end}}>{{.Label}}</button>
+{{- end}}
+{{- else}}
+<button type="submit" class="btn btn-primary"{{template "widget/form/attrs" .}}{{with .Value}} value="{{.}}"{{end}}>{{.Label}}</button>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/hidden"}}{{with .}}
+<input type="hidden"{{with .Id}} id="{{.}}"{{end}}{{with .Name}} name="{{.}}"{{end}}{{with .Value}} value="{{.}}"{{end}}>
+{{- end}}{{end}}
+
+{{- define "widget/form/file"}}{{with .}}
+<div class="form-group">
+{{- template "widget/form/label" .}}
+<input type="file" class="form-control-file{{if .Errors}} is-invalid{{end}}"{{template "widget/form/attrs" .}}{{with .Accept}} accept="{{.}}"{{end}}>
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+`
@@ -0,0 +1,355 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package design
+
+// ThemeMinimal renders widgets with plain semantic markup, that does not
+// depend on any CSS or JavaScript framework.
+var ThemeMinimal = &Theme{
+	Name:   "minimal",
+	Funcs:  themeFuncs,
+	Source: minimalSource,
+}
+
+const minimalSource = `
+{{- define "widget/alert"}}{{with .}}
+<p class="alert alert-{{if eq .Type "siccess"}}success{{else}}{{.Type}}{{end}}" role="alert">{{.Message}}</p>
+{{- end}}{{end}}
+
+{{- define "widget/breadcrumbs"}}{{if .}}
+<nav class="breadcrumbs">
+{{range $i, $item := .}}
+{{- if $i}} / {{end}}
+{{- if $item.Action}}<a href="{{$item.Action}}">{{$item.Label}}</a>{{else}}<span aria-current="page">{{$item.Label}}</span>{{end}}
+{{- end}}
+</nav>
+{{- end}}{{end}}
+
+{{- define "widget/action"}}{{with .}}
+{{- if .Post}}
+<form method="post" action="{{.Action}}"><button type="submit"{{template "widget/action/attrs" .}}>{{.Label}}</button></form>
+{{- else if .Action}}
+{{- if .Disabled}}
+<span class="disabled">{{.Label}}</span>
+{{- else}}
+<a href="{{.Action}}"
+{{- with .Tooltip}} title="{{.}}"{{end}}
+{{- with .Confirm}} onclick="return confirm({{.}})"{{end}}>{{.Label}}</a>
+{{- end}}
+{{- else}}
+<button type="{{.Type}}"{{template "widget/action/attrs" .}}>{{.Label}}</button>
+{{- end}}
+{{- end}}{{end}}
+
+{{- define "widget/action/attrs"}}
+{{- with .Name}} name="{{.}}"{{end}}
+{{- with .Value}} value="{{.}}"{{end}}
+{{- with .Tooltip}} title="{{.}}"{{end}}
+{{- with .Confirm}} onclick="return confirm({{.}})"{{end}}
+{{- if .Disabled}} disabled{{end}}
+{{- end}}
+
+{{- define "widget/pager"}}{{with .}}
+<nav class="pager">
+<span>{{.Message}}</span>
+{{- with .Buttons}}
+{{- with .Prev}}{{template "widget/pager/button" .}}{{end}}
+{{- range .Band}}{{template "widget/pager/button" .}}{{end}}
+{{- with .Next}}{{template "widget/pager/button" .}}{{end}}
+{{- end}}
+</nav>
+{{- end}}{{end}}
+
+{{- define "widget/pager/button"}}
+{{- if .Disabled}}
+<span class="disabled">{{.Label}}</span>
+{{- else if .Active}}
+<strong aria-current="page">{{.Label}}</strong>
+{{- else}}
+<a href="{{.Action}}">{{.Label}}</a>
+{{- end}}
+{{- end}}
+
+{{- define "widget/band"}}{{with .}}
+<ul class="band">
+{{- range .Items}}{{template "widget/band/item" .}}{{end}}
+</ul>
+{{- template "widget/pager" .Pager}}
+{{- end}}{{end}}
+
+{{- define "widget/band/item"}}
+<li>{{.}}</li>
+{{- end}}
+
+{{- define "widget/table"}}{{with .}}
+{{- with .Filters}}
+<form id="{{.Id}}" class="filters" method="get" action="{{.Action}}">
+{{- range .Params}}
+<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+{{- end}}
+<button type="submit">{{.Submit}}</button>
+</form>
+{{- end}}
+<table>
+<thead>
+<tr>
+{{- range .Head}}
+<th{{with .Class}} class="{{.}}"{{end}}>
+{{- if .Action}}<a href="{{.Action}}"{{with .Sorted}} class="sorted-{{.}}"{{end}}>{{.Label}}</a>
+{{- else}}{{.Label}}{{end}}</th>
+{{- end}}
+</tr>
+{{- if .Filters}}
+<tr class="filters">
+{{- range .Head}}
+<td>{{with .Filter}}{{template "widget/table/filter" .}}{{end}}</td>
+{{- end}}
+</tr>
+{{- end}}
+</thead>
+<tbody>
+{{- range .Body}}
+<tr{{with .Class}} class="{{.}}"{{end}}>
+{{- range .Cols}}
+<td>{{template "widget/table/cell" .}}</td>
+{{- end}}
+</tr>
+{{- end}}
+</tbody>
+</table>
+{{- template "widget/pager" .Pager}}
+{{- end}}{{end}}
+
+{{- define "widget/table/cell"}}
+{{- if isMap .}}
+{{- range $name, $action := .}}
+{{- if $action.Post}}
+<form method="post" action="{{$action.Action}}"><button type="submit"
+{{- with $action.Tooltip}} title="{{.}}"{{end}}
+{{- with $action.Confirm}} onclick="return confirm({{.}})"{{end}}>{{$name}}</button></form>
+{{- else}}
+<a href="{{$action.Action}}"
+{{- with $action.Tooltip}} title="{{.}}"{{end}}
+{{- with $action.Confirm}} onclick="return confirm({{.}})"{{end}}>{{$name}}</a>
+{{- end}}
+{{- end}}
+{{- else}}{{.}}{{end}}
+{{- end}}
+
+{{- define "widget/table/filter"}}
+{{- if eq .Kind "select"}}
+<select name="{{.Name}}" form="{{.Form}}">
+{{- with .Empty}}
+<option value=""{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+{{- range .Items}}
+<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+</select>
+{{- else if eq .Kind "date-range"}}
+<input type="date" name="{{.From.Name}}" value="{{.From.Value}}" form="{{.Form}}">
+<input type="date" name="{{.To.Name}}" value="{{.To.Value}}" form="{{.Form}}">
+{{- else}}
+<input type="text" name="{{.Name}}"{{with .Value}} value="{{.}}"{{end}}{{with .Placeholder}} placeholder="{{.}}"{{end}} form="{{.Form}}">
+{{- end}}
+{{- end}}
+
+{{- define "widget/detail-view"}}{{with .}}
+<table class="details">
+<thead>
+<tr><th>{{.Head.Key}}</th><th>{{.Head.Value}}</th></tr>
+</thead>
+<tbody>
+{{- range .Body}}
+<tr{{with .Type}} class="{{.}}"{{end}}><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}{{end}}
+
+{{- define "widget/nav-bar"}}{{with .}}
+<nav class="nav-bar">
+{{- with .Brand}}
+<a class="brand" href="/">{{.}}</a>
+{{- end}}
+<ul>
+{{- range .Items}}{{template "widget/nav-bar/item" .}}{{end}}
+</ul>
+</nav>
+{{- end}}{{end}}
+
+{{- define "widget/nav-bar/item"}}
+{{- $type := or .Type .type}}
+{{- if eq $type "item"}}
+<li{{if .Active}} class="active"{{end}}><a href="{{.Action}}">{{.Label}}</a></li>
+{{- else if eq $type "separator"}}
+<li role="separator"></li>
+{{- else if eq $type "menu"}}
+<li>
+<details>
+<summary>{{.Label}}</summary>
+<ul>
+{{- range .Items}}
+{{- if eq .Type "separator"}}
+<li role="separator"></li>
+{{- else}}
+<li{{if .Active}} class="active"{{end}}><a href="{{.Action}}">{{.Label}}</a></li>
+{{- end}}
+{{- end}}
+</ul>
+</details>
+</li>
+{{- else if eq $type "text"}}
+<li>{{.Items}}</li>
+{{- else if eq $type "link"}}
+<li>
+{{- if .Post}}
+<form method="post" action="{{.Action}}"><button type="submit"{{with .Tooltip}} title="{{.}}"{{end}}>{{.Label}}</button></form>
+{{- else}}
+<a href="{{.Action}}"{{with .Tooltip}} title="{{.}}"{{end}}>{{.Label}}</a>
+{{- end}}
+</li>
+{{- else if eq $type "form"}}
+<li>
+{{- template "widget/form/begin" .}}
+{{- range .Model}}
+{{- if .Items}}{{template "widget/form/select" .}}{{else}}{{template "widget/form/text" .}}{{end}}
+{{- end}}
+{{- template "widget/form/end" .}}
+</li>
+{{- end}}
+{{- end}}
+
+{{- define "widget/form/begin"}}
+<form method="{{.Method}}"{{with .Action}} action="{{.}}"{{end}}{{with .Id}} id="{{.}}"{{end}}{{with .Name}} name="{{.}}"{{end}}>
+{{- end}}
+
+{{- define "widget/form/end"}}
+</form>
+{{- end}}
+
+{{- define "widget/form/attrs"}}
+{{- with .Id}} id="{{.}}"{{end}}
+{{- with .Name}} name="{{.}}"{{end}}
+{{- if .Required}} required{{end}}
+{{- if .Readonly}} readonly{{end}}
+{{- if .Disabled}} disabled{{end}}
+{{- end}}
+
+{{- define "widget/form/required"}}
+{{- if .Required}} <abbr class="required" title="required">*</abbr>{{end}}
+{{- end}}
+
+{{- define "widget/form/label"}}
+{{- with .Label}}
+<label{{with $.Id}} for="{{.}}"{{end}}>{{.}}{{template "widget/form/required" $}}</label>
+{{- end}}
+{{- end}}
+
+{{- define "widget/form/errors"}}
+{{- with .Errors}}
+<ul class="errors">
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+
+{{- define "widget/form/text"}}{{with .}}
+<div class="field{{if .Errors}} invalid{{end}}">
+{{- template "widget/form/label" .}}
+{{- if .Rows}}
+<textarea rows="{{.Rows}}"{{template "widget/form/attrs" .}}
+{{- with .Placeholder}} placeholder="{{.}}"{{end}}
+{{- with .MaxLen}} maxlength="{{.}}"{{end}}>{{.Value}}</textarea>
+{{- else}}
+<input type="text"{{template "widget/form/attrs" .}}
+{{- with .Placeholder}} placeholder="{{.}}"{{end}}
+{{- with .MaxLen}} maxlength="{{.}}"{{end}}
+{{- with .Pattern}} pattern="{{.}}"{{end}}
+{{- with .Value}} value="{{.}}"{{end}}>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/select"}}{{with .}}
+<div class="field{{if .Errors}} invalid{{end}}">
+{{- template "widget/form/label" .}}
+<select{{template "widget/form/attrs" .}}>
+{{- with .Empty}}
+<option value=""{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+{{- range .Items}}
+<option value="{{.Value}}"{{if .Selected}} selected{{end}}>{{.Label}}</option>
+{{- end}}
+</select>
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/flag"}}{{with .}}
+<div class="field{{if .Errors}} invalid{{end}}">
+<label><input type="checkbox" value="{{.Value}}"{{template "widget/form/attrs" .}}{{if .Selected}} checked{{end}}> {{.Label}}</label>
+{{- with .Placeholder}}
+<small>{{.}}</small>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/flags"}}{{with .}}
+<fieldset class="field{{if .Errors}} invalid{{end}}">
+{{- with .Label}}
+<legend>{{.}}{{template "widget/form/required" $}}</legend>
+{{- end}}
+{{- range .Items}}
+<label><input type="checkbox" name="{{$.Name}}" value="{{.Value}}"{{if .Selected}} checked{{end}}{{if $.Disabled}} disabled{{end}}> {{.Label}}</label>
+{{- end}}
+{{- with .Placeholder}}
+<small>{{.}}</small>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</fieldset>
+{{- end}}{{end}}
+
+{{- define "widget/form/submit"}}{{with .}}
+<div class="buttons">
+{{- if .Items}}
+{{- range .Items}}
+<button type="submit" name="{{$.Name}}" value="{{.Value}}"{{if .Selected}} class="default"{{end}}{{if $.Disabled}} disabled{{end}}>{{.Label}}</button>
+{{- end}}
+{{- else}}
+<button type="submit"{{template "widget/form/attrs" .}}{{with .Value}} value="{{.}}"{{end}}>{{.Label}}</button>
+{{- end}}
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+
+{{- define "widget/form/hidden"}}{{with .}}
+<input type="hidden"{{with .Id}} id="{{.}}"{{end}}{{with .Name}} name="{{.}}"{{end}}{{with .Value}} value="{{.}}"{{end}}>
+{{- end}}{{end}}
+
+{{- define "widget/form/file"}}{{with .}}
+<div class="field{{if .Errors}} invalid{{end}}">
+{{- template "widget/form/label" .}}
+<input type="file"{{template "widget/form/attrs" .}}{{with .Accept}} accept="{{.}}"{{end}}>
+{{- template "widget/form/errors" .}}
+</div>
+{{- end}}{{end}}
+`
@@ -0,0 +1,53 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package design
+
+import (
+	"html/template"
+)
+
+// Theme is bundled set of templates, that renders output of widgets.
+// Every template is named by widget ("widget/table", "widget/form/text"...)
+// and may be overridden by layout or view, that defines template
+// with the same name.
+//
+// Form is rendered by parts, because output of form model does not
+// contain types of fields:
+//   {{template "widget/form/begin" .Form}}
+//   {{template "widget/form/text" .Form.Model.Name}}
+//   {{template "widget/form/select" .Form.Model.City}}
+//   {{template "widget/form/submit" .Form.Model.Save}}
+//   {{template "widget/form/end" .Form}}
+type Theme struct {
+	Name   string           // Name of theme
+	Funcs  template.FuncMap // Funcs, that used by templates
+	Source string           // Definitions of templates
+}
+
+func (theme *Theme) parse(tpl *template.Template) {
+	template.Must(tpl.New("theme:" + theme.Name).Parse(theme.Source))
+}
+
+var themeFuncs = template.FuncMap{
+	"isMap": isMap,
+}
+
+func isMap(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
@@ -0,0 +1,246 @@
+package design
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/data"
+	"github.com/adverax/echo/widget"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func themeWidgets(ctx echo.Context) map[string]echo.Widget {
+	cities := echo.NewDataSet(
+		map[string]string{
+			"1": "London",
+			"2": "Paris",
+		},
+		true,
+	)
+
+	name := &widget.FormText{
+		Id:          "name",
+		Name:        "Name",
+		Label:       "Name",
+		Placeholder: "Your name",
+		MaxLength:   32,
+		Required:    true,
+	}
+	_ = name.SetValue(ctx, []string{""})
+	_ = name.Validate(ctx)
+
+	city := &widget.FormSelect{
+		Id:    "city",
+		Name:  "City",
+		Label: "City",
+		Items: cities,
+	}
+	_ = city.SetValue(ctx, []string{"2"})
+
+	users := []string{"Bob", "Alice", "Tom", "Ann", "Sam"}
+	newPager := func() (widget.Pager, *data.ArrayProvider) {
+		provider := &data.ArrayProvider{
+			Loader: func(ctx context.Context) (int, error) {
+				return len(users), nil
+			},
+		}
+		return widget.Pager{
+			Capacity: 2,
+			BtnCount: 3,
+			Url:      &url.URL{Path: "/users", RawQuery: "pg=2"},
+			Provider: provider,
+		}, provider
+	}
+	tablePager, tableProvider := newPager()
+	tablePager.Url = &url.URL{Path: "/users", RawQuery: "pg=2&sort=name&f_name=o&f_joined_from=2019-01-01"}
+	bandPager, bandProvider := newPager()
+
+	return map[string]echo.Widget{
+		"alert": &widget.Alert{
+			Type:  widget.AlertSuccess,
+			Label: "Saved",
+		},
+		"breadcrumbs": widget.Breadcrumbs{
+			{Label: "Home", Action: "/"},
+			{Label: "Users", Action: "/users"},
+			{Label: "Bob"},
+		},
+		"action": &widget.Action{
+			Label:   "Delete",
+			Action:  "/users/delete/1",
+			Confirm: "Are you sure?",
+			Post:    true,
+		},
+		"table": &widget.Table{
+			Pager: tablePager,
+			Columns: widget.TableColumns{
+				"1_name": {
+					Label: "Name",
+					Sort:  "name",
+					Filter: &widget.TableFilter{
+						Kind:        data.FilterText,
+						Key:         "name",
+						Placeholder: "Search",
+					},
+					Data: func() (interface{}, error) {
+						return users[tableProvider.Index-1], nil
+					},
+				},
+				"2_city": {
+					Label: "City",
+					Filter: &widget.TableFilter{
+						Kind:  data.FilterSelect,
+						Key:   "city",
+						Items: cities,
+					},
+					Data: func() (interface{}, error) {
+						return "London", nil
+					},
+				},
+				"3_joined": {
+					Label: "Joined",
+					Sort:  "joined",
+					Filter: &widget.TableFilter{
+						Kind: data.FilterDateRange,
+						Key:  "joined",
+					},
+					Data: func() (interface{}, error) {
+						return "2019-03-01", nil
+					},
+				},
+				"4_actions": {
+					Data: func() (interface{}, error) {
+						return &widget.TableActions{
+							RowId: tableProvider.Index,
+							Path:  "/users",
+							Items: widget.Map{
+								"View": widget.DefaultTableActionView,
+							},
+						}, nil
+					},
+				},
+			},
+		},
+		"band": &widget.Band{
+			Pager: bandPager,
+			Data: func() (interface{}, error) {
+				return users[bandProvider.Index-1], nil
+			},
+		},
+		"detail-view": &widget.DetailView{
+			Items: widget.Details{
+				"1": {Label: "Name", Value: "Bob"},
+				"2": {Label: "Email", Value: "bob@example.com", Type: "email"},
+			},
+		},
+		"nav-bar": &widget.NavBar{
+			Brand: "Echo",
+			Items: widget.Map{
+				"1": &widget.NavBarItem{Label: "Home", Action: "/", Active: true},
+				"2": &widget.NavBarDropDown{
+					Label: "Users",
+					Items: widget.List{
+						&widget.NavBarItem{Label: "List", Action: "/users"},
+						&widget.NavBarItem{},
+						&widget.NavBarItem{Label: "Create", Action: "/users/create"},
+					},
+				},
+				"3": &widget.NavBarLink{Label: "Logout", Action: "/logout", Post: true},
+			},
+		},
+		"form/begin": &widget.Form{
+			Id:     "profile",
+			Action: "/profile",
+		},
+		"form/text":   name,
+		"form/select": city,
+		"form/flags": &widget.FormFlags{
+			Name:    "Cities",
+			Label:   "Cities",
+			Items:   cities,
+			Default: []string{"1"},
+		},
+		"form/flag": &widget.FormFlag{
+			Id:      "agree",
+			Name:    "Agree",
+			Label:   "I agree",
+			Default: true,
+		},
+		"form/submit": &widget.FormSubmit{
+			Name:  "Save",
+			Label: "Save",
+		},
+		"form/hidden": &widget.FormHidden{
+			Name:    "Token",
+			Default: "abc",
+		},
+		"form/file": &widget.FormFile{
+			Name:   "Avatar",
+			Label:  "Avatar",
+			Accept: "image/*",
+		},
+	}
+}
+
+func TestThemes(t *testing.T) {
+	e := echo.New()
+	themes := []*Theme{ThemeBootstrap, ThemeMinimal}
+	for _, theme := range themes {
+		d := NewThemeDesigner(e, theme, nil, "../_fixture/views", "../_fixture/views")
+		tpl := d.(*designer).tpl
+		ctx := e.NewContext(httptest.NewRequest("GET", "/users?pg=2", nil), httptest.NewRecorder())
+		for name, w := range themeWidgets(ctx) {
+			t.Run(theme.Name+"/"+name, func(t *testing.T) {
+				val, err := w.Render(ctx)
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+				err = tpl.ExecuteTemplate(&buf, "widget/"+name, val)
+				require.NoError(t, err)
+
+				golden := filepath.Join("testdata", theme.Name, strings.Replace(name, "/", "-", -1)+".html")
+				if *update {
+					require.NoError(t, ioutil.WriteFile(golden, buf.Bytes(), 0644))
+				}
+				expected, err := ioutil.ReadFile(golden)
+				require.NoError(t, err)
+				assert.Equal(t, string(expected), buf.String())
+			})
+		}
+	}
+}
+
+func TestThemes_Override(t *testing.T) {
+	d := NewThemeDesigner(
+		echo.New(),
+		ThemeMinimal,
+		nil,
+		"../_fixture/views",
+		"../_fixture/views",
+		"theme.tmpl",
+	)
+
+	var buf bytes.Buffer
+	err := d.(*designer).tpl.ExecuteTemplate(
+		&buf,
+		"widget/form/text",
+		map[string]interface{}{
+			"Name":     "Name",
+			"Label":    "Name",
+			"Required": true,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "\n<div class=\"field\">\n<label>Name<em>required</em></label>\n<input type=\"text\" name=\"Name\" required>\n</div>", buf.String())
+}
@@ -391,6 +391,7 @@ const (
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
+	MIMETextEventStream                  = "text/event-stream"
 )
 
 const (
@@ -405,14 +406,18 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
+	HeaderETag                = "ETag"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
 	HeaderLastModified        = "Last-Modified"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLocation            = "Location"
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
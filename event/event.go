@@ -15,67 +15,361 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package event implements bus of the application events.
+//
+// Event names are hierarchical, segments are separated by dot ("user.created").
+// Subscription pattern can contain wildcards: "*" matches exactly one segment
+// and "**" matches zero or more segments ("user.*", "user.**", "**").
 package event
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Subscriber handles event. Name of the event can be obtained by Name(ctx).
 type Subscriber func(ctx context.Context, event interface{}) error
 
-type subscribers []Subscriber
+// Subscription is handle of the subscriber, used for unsubscribe.
+type Subscription interface {
+	Unsubscribe()
+}
 
 type Messenger interface {
+	// Trigger calls all matched subscribers synchronously in order of
+	// their priorities. All subscribers are called regardless of errors.
+	// Returns nil, the single error or Errors.
 	Trigger(ctx context.Context, name string, event interface{}) error
+	// Publish delivers event to matched subscribers asynchronously by
+	// the pool of workers. Blocks, while queue is full.
+	// Subscribers receive values of ctx, but not its cancellation and deadline.
+	// Errors of subscribers are passed into Options.ErrorHandler.
+	Publish(ctx context.Context, name string, event interface{}) error
 }
 
 type Registrar interface {
-	On(name string, subscriber Subscriber)
-	Off(name string, subscriber *interface{})
+	// Subscribe to events, matched with pattern.
+	On(pattern string, subscriber Subscriber) Subscription
+	// Subscribe with priority. Subscribers with higher priority are called first.
+	OnPriority(pattern string, priority int, subscriber Subscriber) Subscription
+	// Unsubscribe
+	Off(subscription Subscription)
 }
 
 type Publisher interface {
 	Messenger
 	Registrar
+	// Close stops workers after delivery of all published events.
+	Close()
+}
+
+// Errors is list of errors, returned by subscribers.
+type Errors []error
+
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ErrorHandler handles errors of asynchronous delivery.
+type ErrorHandler func(ctx context.Context, name string, event interface{}, err error)
+
+type Options struct {
+	Workers      int          // Count of workers for asynchronous delivery (default 4)
+	Queue        int          // Capacity of queue for asynchronous delivery (default 256)
+	ErrorHandler ErrorHandler // Handler of asynchronous errors (default ignores errors)
+}
+
+var ErrClosed = errors.New("publisher is closed")
+
+type subscription struct {
+	pub        *publisher
+	id         uint64
+	pattern    []string
+	priority   int
+	subscriber Subscriber
+}
+
+func (sub *subscription) Unsubscribe() {
+	sub.pub.Off(sub)
+}
+
+type subscriptions []*subscription
+
+type message struct {
+	ctx         context.Context
+	name        string
+	event       interface{}
+	subscribers subscriptions
 }
 
 type publisher struct {
-	subscribers map[string]subscribers
+	sync.RWMutex
+	options     Options
+	lastId      uint64
+	subscribers subscriptions // Sorted by priority, copied on write
+
+	start  sync.Once
+	state  sync.RWMutex
+	closed bool
+	queue  chan *message
+	sends  sync.WaitGroup // In-flight sends into queue
+	wg     sync.WaitGroup
 }
 
-func (pub *publisher) On(name string, action Subscriber) {
-	if actions, found := pub.subscribers[name]; found {
-		pub.subscribers[name] = append(actions, action)
-	} else {
-		pub.subscribers[name] = subscribers{action}
+func (pub *publisher) On(pattern string, subscriber Subscriber) Subscription {
+	return pub.OnPriority(pattern, 0, subscriber)
+}
+
+func (pub *publisher) OnPriority(
+	pattern string,
+	priority int,
+	subscriber Subscriber,
+) Subscription {
+	pub.Lock()
+	defer pub.Unlock()
+
+	pub.lastId++
+	sub := &subscription{
+		pub:        pub,
+		id:         pub.lastId,
+		pattern:    strings.Split(pattern, "."),
+		priority:   priority,
+		subscriber: subscriber,
 	}
+
+	list := make(subscriptions, len(pub.subscribers), len(pub.subscribers)+1)
+	copy(list, pub.subscribers)
+	list = append(list, sub)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].priority > list[j].priority
+	})
+	pub.subscribers = list
+
+	return sub
 }
 
-func (pub *publisher) Off(name string, action *interface{}) {
-	if list, found := pub.subscribers[name]; found {
-		for i, act := range list {
-			if interface{}(act) == action {
-				pub.subscribers[name] = append(list[:i], list[i+1:]...)
-				break
-			}
+func (pub *publisher) Off(subscription Subscription) {
+	pub.Lock()
+	defer pub.Unlock()
+
+	for i, sub := range pub.subscribers {
+		if sub == subscription {
+			list := make(subscriptions, 0, len(pub.subscribers)-1)
+			list = append(list, pub.subscribers[:i]...)
+			list = append(list, pub.subscribers[i+1:]...)
+			pub.subscribers = list
+			return
 		}
 	}
 }
 
-func (pub *publisher) Trigger(ctx context.Context, name string, event interface{}) error {
-	if actions, found := pub.subscribers[name]; found {
-		for _, action := range actions {
-			err := action(ctx, event)
-			if err != nil {
-				return err
-			}
+func (pub *publisher) Trigger(
+	ctx context.Context,
+	name string,
+	event interface{},
+) error {
+	return pub.matches(name).deliver(withName(ctx, name), event)
+}
+
+func (pub *publisher) Publish(
+	ctx context.Context,
+	name string,
+	event interface{},
+) error {
+	subs := pub.matches(name)
+	if len(subs) == 0 {
+		return nil
+	}
+
+	pub.start.Do(pub.run)
+
+	// Lock is released before send, because subscriber can publish
+	// follow-on event, while Close waits for workers.
+	pub.state.RLock()
+	if pub.closed {
+		pub.state.RUnlock()
+		return ErrClosed
+	}
+	pub.sends.Add(1)
+	pub.state.RUnlock()
+	defer pub.sends.Done()
+
+	msg := &message{
+		ctx:         detach(ctx),
+		name:        name,
+		event:       event,
+		subscribers: subs,
+	}
+
+	select {
+	case pub.queue <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (pub *publisher) Close() {
+	pub.state.Lock()
+	if pub.closed {
+		pub.state.Unlock()
+		return
+	}
+	pub.closed = true
+	queue := pub.queue
+	pub.state.Unlock()
+
+	if queue != nil {
+		pub.sends.Wait()
+		close(queue)
+		pub.wg.Wait()
+	}
+}
+
+// Start workers of asynchronous delivery.
+func (pub *publisher) run() {
+	pub.state.Lock()
+	defer pub.state.Unlock()
+	if pub.closed {
+		return
+	}
+
+	pub.queue = make(chan *message, pub.options.Queue)
+	pub.wg.Add(pub.options.Workers)
+	for i := 0; i < pub.options.Workers; i++ {
+		go pub.worker()
+	}
+}
+
+func (pub *publisher) worker() {
+	defer pub.wg.Done()
+
+	for msg := range pub.queue {
+		err := msg.subscribers.deliver(withName(msg.ctx, msg.name), msg.event)
+		if err != nil && pub.options.ErrorHandler != nil {
+			pub.options.ErrorHandler(msg.ctx, msg.name, msg.event, err)
+		}
+	}
+}
+
+// Get subscribers, matched with event name.
+func (pub *publisher) matches(name string) subscriptions {
+	pub.RLock()
+	list := pub.subscribers
+	pub.RUnlock()
+
+	segments := strings.Split(name, ".")
+	var res subscriptions
+	for _, sub := range list {
+		if match(sub.pattern, segments) {
+			res = append(res, sub)
+		}
+	}
+	return res
+}
+
+func (subs subscriptions) deliver(ctx context.Context, event interface{}) error {
+	var errs Errors
+	for _, sub := range subs {
+		err := sub.subscriber(ctx, event)
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+// Context, that keeps values of parent, but is never cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (ctx detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (ctx detachedContext) Err() error {
 	return nil
 }
 
+func (ctx detachedContext) Value(key interface{}) interface{} {
+	return ctx.parent.Value(key)
+}
+
+func detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+type nameKey struct{}
+
+func withName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nameKey{}, name)
+}
+
+// Name returns name of the event, delivered to subscriber.
+func Name(ctx context.Context) string {
+	name, _ := ctx.Value(nameKey{}).(string)
+	return name
+}
+
+// Match checks, that event name is matched with pattern.
+func Match(pattern, name string) bool {
+	return match(strings.Split(pattern, "."), strings.Split(name, "."))
+}
+
+// Match segments of name with segments of pattern.
+func match(pattern, name []string) bool {
+	for i, p := range pattern {
+		if p == "**" {
+			for j := i; j <= len(name); j++ {
+				if match(pattern[i+1:], name[j:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if i >= len(name) || (p != "*" && p != name[i]) {
+			return false
+		}
+	}
+	return len(pattern) == len(name)
+}
+
+// New creates publisher with default options.
 func New() Publisher {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates publisher with custom options.
+func NewWithOptions(options Options) Publisher {
+	if options.Workers <= 0 {
+		options.Workers = 4
+	}
+	if options.Queue <= 0 {
+		options.Queue = 256
+	}
+
 	return &publisher{
-		subscribers: make(map[string]subscribers, 4),
+		options: options,
 	}
 }
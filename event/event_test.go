@@ -0,0 +1,187 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	type Test struct {
+		pattern string
+		name    string
+		match   bool
+	}
+
+	tests := map[string]Test{
+		"Exact":             {pattern: "user.created", name: "user.created", match: true},
+		"Other":             {pattern: "user.created", name: "user.deleted", match: false},
+		"Star":              {pattern: "user.*", name: "user.created", match: true},
+		"Star deep":         {pattern: "user.*", name: "user.profile.updated", match: false},
+		"Star parent":       {pattern: "user.*", name: "user", match: false},
+		"Star middle":       {pattern: "*.created", name: "order.created", match: true},
+		"Double star":       {pattern: "user.**", name: "user.profile.updated", match: true},
+		"Double star empty": {pattern: "user.**", name: "user", match: true},
+		"Double star all":   {pattern: "**", name: "order.created", match: true},
+		"Double star mid":   {pattern: "user.**.updated", name: "user.a.b.updated", match: true},
+		"Double star miss":  {pattern: "user.**.updated", name: "user.a.b.created", match: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.match, Match(test.pattern, test.name))
+		})
+	}
+}
+
+func TestPublisher_Trigger(t *testing.T) {
+	pub := New()
+	defer pub.Close()
+
+	var calls []string
+	handler := func(name string, err error) Subscriber {
+		return func(ctx context.Context, event interface{}) error {
+			assert.Equal(t, "user.", Name(ctx)[:5])
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	errA := errors.New("a")
+	errB := errors.New("b")
+	pub.On("user.created", handler("exact", errA))
+	sub := pub.On("user.*", handler("wildcard", nil))
+	pub.OnPriority("**", 10, handler("all", errB))
+	pub.OnPriority("order.*", 20, handler("order", nil))
+
+	err := pub.Trigger(context.Background(), "user.created", nil)
+	assert.Equal(t, Errors{errB, errA}, err)
+	assert.Equal(t, []string{"all", "exact", "wildcard"}, calls)
+
+	calls = nil
+	sub.Unsubscribe()
+	pub.Off(sub)
+	err = pub.Trigger(context.Background(), "user.deleted", nil)
+	assert.Equal(t, errB, err)
+	assert.Equal(t, []string{"all"}, calls)
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	var mx sync.Mutex
+	var errs []error
+	pub := NewWithOptions(Options{
+		Workers: 2,
+		Queue:   4,
+		ErrorHandler: func(ctx context.Context, name string, event interface{}, err error) {
+			mx.Lock()
+			defer mx.Unlock()
+			errs = append(errs, err)
+		},
+	})
+
+	var wg sync.WaitGroup
+	var sum int
+	pub.On("number", func(ctx context.Context, event interface{}) error {
+		defer wg.Done()
+		mx.Lock()
+		defer mx.Unlock()
+		n := event.(int)
+		sum += n
+		if n == 3 {
+			return errors.New("three")
+		}
+		return nil
+	})
+
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		require.NoError(t, pub.Publish(context.Background(), "number", i))
+	}
+	wg.Wait()
+	pub.Close()
+
+	assert.Equal(t, 55, sum)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "three")
+	assert.Equal(t, ErrClosed, pub.Publish(context.Background(), "number", 1))
+}
+
+func TestPublisher_Close(t *testing.T) {
+	pub := NewWithOptions(Options{Workers: 1, Queue: 1})
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mx sync.Mutex
+	var values []interface{}
+	var errs []error
+	pub.On("first", func(ctx context.Context, event interface{}) error {
+		close(started)
+		<-release
+		mx.Lock()
+		defer mx.Unlock()
+		values = append(values, ctx.Value(key{}))
+		errs = append(errs, ctx.Err())
+		// Follow-on event is published while Close waits for workers
+		return pub.Publish(ctx, "second", nil)
+	})
+	pub.On("second", func(ctx context.Context, event interface{}) error {
+		return nil
+	})
+
+	require.NoError(t, pub.Publish(ctx, "first", nil))
+	cancel()
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		pub.Close()
+		close(closed)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close is deadlocked")
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+	assert.Equal(t, []interface{}{"value"}, values)
+	assert.Equal(t, []error{nil}, errs)
+}
+
+type userCreated struct {
+	Name string
+}
+
+func (userCreated) EventName() string {
+	return "user.created"
+}
+
+func TestTyped(t *testing.T) {
+	pub := New()
+	defer pub.Close()
+
+	var names []string
+	pub.On("user.*", Typed(func(ctx context.Context, event *userCreated) error {
+		names = append(names, event.Name)
+		return nil
+	}))
+
+	require.NoError(t, Fire(context.Background(), pub, &userCreated{Name: "Bob"}))
+	require.NoError(t, pub.Trigger(context.Background(), "user.deleted", "Alice"))
+	assert.Equal(t, []string{"Bob"}, names)
+
+	assert.Panics(t, func() {
+		Typed(func(event string) {})
+	})
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/adverax/echo/database/sql"
+)
+
+// Dialect generates queries of outbox for specific database.
+type Dialect interface {
+	// Create table of outbox
+	CreateTable(table string) string
+	// Insert event. Args: name, payload, created, next.
+	Insert(table string) string
+	// Select batch of pending events. Args: now, max attempts, limit.
+	// Columns: id, name, payload, attempts.
+	Fetch(table string) string
+	// Claim pending event for delivery. Args: lease, id, now.
+	// Event is claimed, if single row is affected.
+	Claim(table string) string
+	// Mark event as delivered. Args: now, id.
+	Done(table string) string
+	// Schedule next attempt of delivery. Args: attempts, next, error, id.
+	Fail(table string) string
+}
+
+// Column types of outbox table.
+type columnTypes struct {
+	id      string // Definition of primary key
+	blob    string
+	time    string
+	options string // Options of table
+	key     bool   // Index is declared within table
+}
+
+var (
+	mySqlTypes = columnTypes{
+		id:      "BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY",
+		blob:    "MEDIUMBLOB",
+		time:    "DATETIME(6)",
+		options: " ENGINE=InnoDB",
+		key:     true,
+	}
+	postgresTypes = columnTypes{
+		id:   "BIGSERIAL PRIMARY KEY",
+		blob: "BYTEA",
+		time: "TIMESTAMPTZ",
+	}
+	sqliteTypes = columnTypes{
+		id:   "INTEGER PRIMARY KEY AUTOINCREMENT",
+		blob: "BLOB",
+		time: "TIMESTAMP",
+	}
+	standardTypes = columnTypes{
+		id:   "BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY",
+		blob: "BLOB",
+		time: "TIMESTAMP",
+	}
+)
+
+// Column types by driver. Other drivers use standard SQL.
+var driverTypes = map[string]columnTypes{
+	"mysql":    mySqlTypes,
+	"postgres": postgresTypes,
+	"pgx":      postgresTypes,
+	"sqlite3":  sqliteTypes,
+	"sqlite":   sqliteTypes,
+}
+
+// Dialect, that generates queries by placeholders and quoting of adapter.
+type sqlDialect struct {
+	adapter sql.Adapter
+	types   columnTypes
+}
+
+// NewDialect creates dialect for database adapter.
+func NewDialect(adapter sql.Adapter) Dialect {
+	types, ok := driverTypes[adapter.Driver()]
+	if !ok {
+		types = standardTypes
+	}
+
+	return &sqlDialect{
+		adapter: adapter,
+		types:   types,
+	}
+}
+
+func (dialect *sqlDialect) CreateTable(table string) string {
+	q := dialect.adapter.QuoteIdentifier
+	t := dialect.types
+	var b strings.Builder
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + q(table) + " (")
+	b.WriteString(q("id") + " " + t.id + ", ")
+	b.WriteString(q("name") + " VARCHAR(255) NOT NULL, ")
+	b.WriteString(q("payload") + " " + t.blob + " NOT NULL, ")
+	b.WriteString(q("created") + " " + t.time + " NOT NULL, ")
+	b.WriteString(q("attempts") + " INT NOT NULL DEFAULT 0, ")
+	b.WriteString(q("next_at") + " " + t.time + " NOT NULL, ")
+	b.WriteString(q("done_at") + " " + t.time + " NULL, ")
+	b.WriteString(q("error") + " TEXT NULL")
+	if t.key {
+		b.WriteString(", KEY " + q("pending") + " (" + q("done_at") + ", " + q("next_at") + ")")
+	}
+	b.WriteString(")" + t.options)
+	if !t.key {
+		b.WriteString("; CREATE INDEX IF NOT EXISTS " + q(table+"_pending") +
+			" ON " + q(table) + " (" + q("done_at") + ", " + q("next_at") + ")")
+	}
+	return b.String()
+}
+
+func (dialect *sqlDialect) Insert(table string) string {
+	q, p := dialect.adapter.QuoteIdentifier, dialect.adapter.Placeholder
+	return "INSERT INTO " + q(table) +
+		" (" + q("name") + ", " + q("payload") + ", " + q("created") + ", " + q("next_at") + ")" +
+		" VALUES (" + p(1) + ", " + p(2) + ", " + p(3) + ", " + p(4) + ")"
+}
+
+func (dialect *sqlDialect) Fetch(table string) string {
+	q, p := dialect.adapter.QuoteIdentifier, dialect.adapter.Placeholder
+	return "SELECT " + q("id") + ", " + q("name") + ", " + q("payload") + ", " + q("attempts") +
+		" FROM " + q(table) +
+		" WHERE " + q("done_at") + " IS NULL AND " + q("next_at") + " <= " + p(1) +
+		" AND " + q("attempts") + " < " + p(2) +
+		" ORDER BY " + q("id") + " LIMIT " + p(3)
+}
+
+func (dialect *sqlDialect) Claim(table string) string {
+	q, p := dialect.adapter.QuoteIdentifier, dialect.adapter.Placeholder
+	return "UPDATE " + q(table) + " SET " + q("next_at") + " = " + p(1) +
+		" WHERE " + q("id") + " = " + p(2) +
+		" AND " + q("done_at") + " IS NULL AND " + q("next_at") + " <= " + p(3)
+}
+
+func (dialect *sqlDialect) Done(table string) string {
+	q, p := dialect.adapter.QuoteIdentifier, dialect.adapter.Placeholder
+	return "UPDATE " + q(table) + " SET " + q("done_at") + " = " + p(1) + ", " + q("error") + " = NULL" +
+		" WHERE " + q("id") + " = " + p(2)
+}
+
+func (dialect *sqlDialect) Fail(table string) string {
+	q, p := dialect.adapter.QuoteIdentifier, dialect.adapter.Placeholder
+	return "UPDATE " + q(table) + " SET " + q("attempts") + " = " + p(1) + ", " +
+		q("next_at") + " = " + p(2) + ", " + q("error") + " = " + p(3) +
+		" WHERE " + q("id") + " = " + p(4)
+}
+
+type dialectRegistry struct {
+	sync.RWMutex
+	dialects map[string]Dialect
+}
+
+var dialects = &dialectRegistry{
+	dialects: make(map[string]Dialect),
+}
+
+// RegisterDialect registers custom dialect for the driver of database adapter.
+func RegisterDialect(driver string, dialect Dialect) {
+	dialects.Lock()
+	defer dialects.Unlock()
+	dialects.dialects[driver] = dialect
+}
+
+// DialectOf returns registered dialect of database adapter or dialect,
+// built from the adapter.
+func DialectOf(adapter sql.Adapter) Dialect {
+	dialects.RLock()
+	dialect, ok := dialects.dialects[adapter.Driver()]
+	dialects.RUnlock()
+	if ok {
+		return dialect
+	}
+	return NewDialect(adapter)
+}
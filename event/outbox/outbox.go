@@ -0,0 +1,330 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox implements transactional outbox for events.
+//
+// Events are stored into outbox table within the current database scope,
+// so they are discarded together with rolled back transaction.
+// Dispatcher polls table and delivers stored events to subscribers.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/event"
+)
+
+const DefaultTable = "outbox"
+
+type messenger struct {
+	repository sql.Repository
+	table      string
+}
+
+func (m *messenger) Trigger(
+	ctx context.Context,
+	name string,
+	ev interface{},
+) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	scope := m.repository.Scope(ctx)
+	dialect := DialectOf(scope.Adapter())
+	now := time.Now()
+	_, err = scope.ExecContext(ctx, dialect.Insert(m.table), name, payload, now, now)
+	return err
+}
+
+func (m *messenger) Publish(
+	ctx context.Context,
+	name string,
+	ev interface{},
+) error {
+	return m.Trigger(ctx, name, ev)
+}
+
+// NewMessenger creates messenger, that stores events into outbox table.
+// Events are written within scope of context (see sql.Repository.Scope),
+// so they are committed or rolled back together with the transaction.
+func NewMessenger(repository sql.Repository, table string) event.Messenger {
+	if table == "" {
+		table = DefaultTable
+	}
+
+	return &messenger{
+		repository: repository,
+		table:      table,
+	}
+}
+
+// CreateTable creates outbox table, if it is not exists.
+func CreateTable(ctx context.Context, scope sql.Scope, table string) error {
+	if table == "" {
+		table = DefaultTable
+	}
+
+	_, err := scope.ExecContext(ctx, DialectOf(scope.Adapter()).CreateTable(table))
+	return err
+}
+
+// Backoff returns delay before next attempt of delivery.
+type Backoff func(attempts int) time.Duration
+
+// ExponentialBackoff creates backoff, that doubles delay after each
+// attempt from min up to max.
+func ExponentialBackoff(min, max time.Duration) Backoff {
+	return func(attempts int) time.Duration {
+		delay := min
+		for i := 1; i < attempts && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		return delay
+	}
+}
+
+type Options struct {
+	Table       string        // Name of outbox table (default "outbox")
+	Batch       int           // Count of events, fetched per dispatch (default 100)
+	Lease       time.Duration // Time, for which claimed event is hidden from other dispatchers (default 1m)
+	Interval    time.Duration // Interval of polling (default 1s)
+	MaxAttempts int           // Attempts of delivery, after which event is abandoned (default 10)
+	Backoff     Backoff       // Delay between attempts (default from 1s to 1h)
+	OnError     func(err error)
+}
+
+// Dispatcher delivers events from outbox to subscribers.
+type Dispatcher struct {
+	db        sql.DB
+	messenger event.Messenger
+	options   Options
+
+	mx    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// Register type of event payload.
+// Payload of registered event is decoded into value of the prototype type,
+// payload of other events is delivered as json.RawMessage.
+func (d *Dispatcher) Register(name string, prototype interface{}) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.types[name] = reflect.TypeOf(prototype)
+}
+
+// Run polls outbox until context is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := d.Dispatch(ctx)
+		if err != nil && d.options.OnError != nil {
+			d.options.OnError(err)
+		}
+
+		if err == nil && n == d.options.Batch {
+			timer.Reset(0)
+		} else {
+			timer.Reset(d.options.Interval)
+		}
+	}
+}
+
+// Dispatch delivers single batch of pending events.
+// Each event is claimed by short update, so events are delivered without
+// locks and concurrent dispatchers skip claimed events. Event, that is not
+// marked after delivery (crash of dispatcher), is retried after lease.
+// Returns count of processed events.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	db := d.db.Master()
+	dialect := DialectOf(db.Adapter())
+
+	msgs, err := d.fetch(ctx, db, dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, msg := range msgs {
+		claimed, err := d.claim(ctx, db, dialect, msg)
+		if err != nil {
+			return n, err
+		}
+		if !claimed {
+			continue
+		}
+
+		err = d.deliver(ctx, db, dialect, msg)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+type message struct {
+	id       int64
+	name     string
+	payload  []byte
+	attempts int
+}
+
+func (d *Dispatcher) fetch(
+	ctx context.Context,
+	db sql.DB,
+	dialect Dialect,
+) ([]*message, error) {
+	query := dialect.Fetch(d.options.Table)
+	rows, err := db.QueryContext(ctx, query, time.Now(), d.options.MaxAttempts, d.options.Batch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []*message
+	for rows.Next() {
+		msg := new(message)
+		err = rows.Scan(&msg.id, &msg.name, &msg.payload, &msg.attempts)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, rows.Err()
+}
+
+// Claim event, that is still pending. Returns false, if event is claimed
+// or delivered by other dispatcher.
+func (d *Dispatcher) claim(
+	ctx context.Context,
+	db sql.DB,
+	dialect Dialect,
+	msg *message,
+) (bool, error) {
+	now := time.Now()
+	res, err := db.ExecContext(ctx, dialect.Claim(d.options.Table), now.Add(d.options.Lease), msg.id, now)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (d *Dispatcher) deliver(
+	ctx context.Context,
+	db sql.DB,
+	dialect Dialect,
+	msg *message,
+) error {
+	ev, err := d.decode(msg.name, msg.payload)
+	if err == nil {
+		err = d.messenger.Trigger(ctx, msg.name, ev)
+	}
+
+	if err == nil {
+		_, err = db.ExecContext(ctx, dialect.Done(d.options.Table), time.Now(), msg.id)
+		return err
+	}
+
+	attempts := msg.attempts + 1
+	next := time.Now().Add(d.options.Backoff(attempts))
+	_, err = db.ExecContext(ctx, dialect.Fail(d.options.Table), attempts, next, err.Error(), msg.id)
+	return err
+}
+
+func (d *Dispatcher) decode(name string, payload []byte) (interface{}, error) {
+	d.mx.RLock()
+	tp, ok := d.types[name]
+	d.mx.RUnlock()
+	if !ok {
+		return json.RawMessage(payload), nil
+	}
+
+	if tp.Kind() == reflect.Ptr {
+		val := reflect.New(tp.Elem())
+		err := json.Unmarshal(payload, val.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return val.Interface(), nil
+	}
+
+	val := reflect.New(tp)
+	err := json.Unmarshal(payload, val.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return val.Elem().Interface(), nil
+}
+
+// NewDispatcher creates dispatcher, that delivers events from outbox of
+// the database into messenger.
+func NewDispatcher(
+	db sql.DB,
+	messenger event.Messenger,
+	options Options,
+) *Dispatcher {
+	if options.Table == "" {
+		options.Table = DefaultTable
+	}
+	if options.Batch <= 0 {
+		options.Batch = 100
+	}
+	if options.Lease <= 0 {
+		options.Lease = time.Minute
+	}
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 10
+	}
+	if options.Backoff == nil {
+		options.Backoff = ExponentialBackoff(time.Second, time.Hour)
+	}
+
+	return &Dispatcher{
+		db:        db,
+		messenger: messenger,
+		options:   options,
+		types:     make(map[string]reflect.Type, 16),
+	}
+}
@@ -0,0 +1,161 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/adverax/echo/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scope struct {
+	sql.Scope
+	query string
+	args  []interface{}
+}
+
+func (s *scope) Adapter() sql.Adapter {
+	adapter, _ := sql.FindAdapter("mysql")
+	return adapter
+}
+
+func (s *scope) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	s.query = query
+	s.args = args
+	return nil, nil
+}
+
+type repository struct {
+	sql.Repository
+	scope *scope
+}
+
+func (r *repository) Scope(ctx context.Context) sql.Scope {
+	return r.scope
+}
+
+type userCreated struct {
+	Name string
+}
+
+func TestMessenger_Trigger(t *testing.T) {
+	s := new(scope)
+	m := NewMessenger(&repository{scope: s}, "")
+
+	err := m.Trigger(context.Background(), "user.created", &userCreated{Name: "Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `outbox` (`name`, `payload`, `created`, `next_at`) VALUES (?, ?, ?, ?)", s.query)
+	require.Len(t, s.args, 4)
+	assert.Equal(t, "user.created", s.args[0])
+	assert.Equal(t, []byte(`{"Name":"Bob"}`), s.args[1])
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, 8*time.Second, backoff(4))
+	assert.Equal(t, 10*time.Second, backoff(5))
+	assert.Equal(t, 10*time.Second, backoff(100))
+}
+
+func TestDispatcher_Decode(t *testing.T) {
+	d := NewDispatcher(nil, nil, Options{})
+	d.Register("user.created", &userCreated{})
+	d.Register("user.deleted", userCreated{})
+
+	ev, err := d.decode("user.created", []byte(`{"Name":"Bob"}`))
+	require.NoError(t, err)
+	assert.Equal(t, &userCreated{Name: "Bob"}, ev)
+
+	ev, err = d.decode("user.deleted", []byte(`{"Name":"Bob"}`))
+	require.NoError(t, err)
+	assert.Equal(t, userCreated{Name: "Bob"}, ev)
+
+	ev, err = d.decode("order.created", []byte(`{"Id":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{"Id":1}`), ev)
+
+	_, err = d.decode("user.created", []byte(`[`))
+	assert.Error(t, err)
+}
+
+func TestDialectOf(t *testing.T) {
+	postgres, err := sql.FindAdapter("postgres")
+	require.NoError(t, err)
+	dialect := DialectOf(postgres)
+	assert.Equal(t,
+		`SELECT "id", "name", "payload", "attempts" FROM "outbox" WHERE "done_at" IS NULL AND "next_at" <= $1 AND "attempts" < $2 ORDER BY "id" LIMIT $3`,
+		dialect.Fetch(DefaultTable),
+	)
+	assert.Equal(t,
+		`UPDATE "outbox" SET "next_at" = $1 WHERE "id" = $2 AND "done_at" IS NULL AND "next_at" <= $3`,
+		dialect.Claim(DefaultTable),
+	)
+	assert.Contains(t, dialect.CreateTable(DefaultTable), `"id" BIGSERIAL PRIMARY KEY`)
+	assert.Contains(t, dialect.CreateTable(DefaultTable), `CREATE INDEX IF NOT EXISTS "outbox_pending" ON "outbox" ("done_at", "next_at")`)
+
+	sqlite, err := sql.FindAdapter("sqlite3")
+	require.NoError(t, err)
+	assert.Equal(t,
+		`UPDATE "outbox" SET "done_at" = ?, "error" = NULL WHERE "id" = ?`,
+		DialectOf(sqlite).Done(DefaultTable),
+	)
+	assert.Contains(t, DialectOf(sqlite).CreateTable(DefaultTable), `"id" INTEGER PRIMARY KEY AUTOINCREMENT`)
+}
+
+const (
+	fetchQuery = `SELECT "id", "name", "payload", "attempts" FROM "outbox" WHERE "done_at" IS NULL AND "next_at" <= ? AND "attempts" < ? ORDER BY "id" LIMIT ?`
+	claimQuery = `UPDATE "outbox" SET "next_at" = ? WHERE "id" = ? AND "done_at" IS NULL AND "next_at" <= ?`
+	doneQuery  = `UPDATE "outbox" SET "done_at" = ?, "error" = NULL WHERE "id" = ?`
+	failQuery  = `UPDATE "outbox" SET "attempts" = ?, "next_at" = ?, "error" = ? WHERE "id" = ?`
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	mock := sqltest.New()
+	defer mock.Close()
+	db, err := sqltest.Open(nil, mock)
+	require.NoError(t, err)
+	defer db.Close(context.Background())
+
+	pub := event.New()
+	defer pub.Close()
+	var delivered []interface{}
+	pub.On("user.*", func(ctx context.Context, ev interface{}) error {
+		if u, ok := ev.(*userCreated); ok && u.Name == "Bad" {
+			return errors.New("rejected")
+		}
+		delivered = append(delivered, ev)
+		return nil
+	})
+
+	d := NewDispatcher(db, pub, Options{Batch: 10, MaxAttempts: 3})
+	d.Register("user.created", &userCreated{})
+
+	mock.ExpectQuery(fetchQuery).
+		WithArgs(sqltest.AnyArg, 3, 10).
+		WillReturnRows(sqltest.NewRows("id", "name", "payload", "attempts").
+			AddRow(1, "user.created", []byte(`{"Name":"Bob"}`), 0).
+			AddRow(2, "user.created", []byte(`{"Name":"Alice"}`), 0).
+			AddRow(3, "user.created", []byte(`{"Name":"Bad"}`), 1))
+	mock.ExpectExec(claimQuery).WithArgs(sqltest.AnyArg, 1, sqltest.AnyArg).WillReturnResult(0, 1)
+	mock.ExpectExec(doneQuery).WithArgs(sqltest.AnyArg, 1).WillReturnResult(0, 1)
+	// Event 2 is claimed by other dispatcher
+	mock.ExpectExec(claimQuery).WithArgs(sqltest.AnyArg, 2, sqltest.AnyArg).WillReturnResult(0, 0)
+	mock.ExpectExec(claimQuery).WithArgs(sqltest.AnyArg, 3, sqltest.AnyArg).WillReturnResult(0, 1)
+	mock.ExpectExec(failQuery).WithArgs(2, sqltest.AnyArg, "rejected", 3).WillReturnResult(0, 1)
+
+	n, err := d.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []interface{}{&userCreated{Name: "Bob"}}, delivered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	// Events are delivered outside of transaction
+	assert.NotContains(t, mock.Log(), "BEGIN")
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Event is event, that knows own name.
+type Event interface {
+	EventName() string
+}
+
+// Fire triggers event with own name.
+func Fire(ctx context.Context, messenger Messenger, event Event) error {
+	return messenger.Trigger(ctx, event.EventName(), event)
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Typed converts typed handler into Subscriber.
+// Handler must have signature func(ctx context.Context, event T) error.
+// Events, that are not assignable to T, are ignored, so typed handlers
+// can be safely subscribed with wildcard patterns.
+func Typed(handler interface{}) Subscriber {
+	fn := reflect.ValueOf(handler)
+	tp := fn.Type()
+	if tp.Kind() != reflect.Func ||
+		tp.NumIn() != 2 || tp.In(0) != contextType ||
+		tp.NumOut() != 1 || tp.Out(0) != errorType {
+		panic(fmt.Errorf("event: invalid typed handler %T", handler))
+	}
+
+	eventType := tp.In(1)
+	return func(ctx context.Context, event interface{}) error {
+		ev := reflect.ValueOf(event)
+		if !ev.IsValid() || !ev.Type().AssignableTo(eventType) {
+			return nil
+		}
+
+		res := fn.Call([]reflect.Value{reflect.ValueOf(ctx), ev})
+		if err, ok := res[0].Interface().(error); ok {
+			return err
+		}
+		return nil
+	}
+}
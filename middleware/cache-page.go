@@ -1,19 +1,81 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/adverax/echo"
 	"net/http"
 	"net/http/httptest"
 	"time"
 )
 
+// PageCacheConfig defines the config for PageCache middleware.
+type PageCacheConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Class of cached pages.
+	// Required.
+	Class string
+
+	// Dependencies of cached page, computed from request.
+	// Optional.
+	Dependencies func(c echo.Context) (map[string]string, error)
+
+	// Tags of cached page, computed from request.
+	// Tagged pages can be dropped with Cacher.InvalidateTags.
+	// Optional.
+	Tags func(c echo.Context) ([]string, error)
+
+	// Life time of cached page.
+	// Required.
+	Duration time.Duration
+
+	// Generate weak ETag (W/"...") instead of strong.
+	// Optional. Default value false.
+	WeakETag bool
+
+	// Value of Cache-Control header for the route.
+	// Optional.
+	CacheControl string
+}
+
+var (
+	// DefaultPageCacheConfig is the default PageCache middleware config.
+	DefaultPageCacheConfig = PageCacheConfig{
+		Skipper: DefaultSkipper,
+	}
+)
+
+// Page was built, but it can not be cached (non 200 status).
+var errUncacheablePage = errors.New("uncacheable page")
+
 // Cached is middleware for cache whole html page.
+// Cached page keeps response headers and supports conditional GET requests
+// (If-None-Match and If-Modified-Since).
 func PageCache(
 	e *echo.Echo,
 	class string,
 	dependencies func(c echo.Context) (map[string]string, error),
 	duration time.Duration,
 ) func(http.Handler) http.Handler {
+	c := DefaultPageCacheConfig
+	c.Class = class
+	c.Dependencies = dependencies
+	c.Duration = duration
+	return PageCacheWithConfig(e, c)
+}
+
+// PageCacheWithConfig returns a PageCache middleware with config.
+// See `PageCache()`.
+func PageCacheWithConfig(
+	e *echo.Echo,
+	config PageCacheConfig,
+) func(http.Handler) http.Handler {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultPageCacheConfig.Skipper
+	}
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			var ctx echo.Context
@@ -25,37 +87,74 @@ func PageCache(
 				ctx.Reset(r, w)
 			}
 
-			deps, err := dependencies(ctx)
-			if err != nil {
-				ctx.Error(err)
+			if config.Skipper(ctx) {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			var content []byte
-			err = e.Cacher.FetchData(
-				class,
+			var deps map[string]string
+			var err error
+			if config.Dependencies != nil {
+				deps, err = config.Dependencies(ctx)
+				if err != nil {
+					ctx.Error(err)
+					return
+				}
+			}
+
+			var tags []string
+			if config.Tags != nil {
+				tags, err = config.Tags(ctx)
+				if err != nil {
+					ctx.Error(err)
+					return
+				}
+			}
+
+			var content page
+			var built *page
+			err = e.Cacher.FetchTaggedData(
+				config.Class,
 				deps,
+				tags,
 				&content,
 				func() (interface{}, error) {
 					rec := httptest.NewRecorder()
 					next.ServeHTTP(rec, r)
 
-					for k, v := range rec.Header() {
-						w.Header()[k] = v
+					// Cookies are addressed to the current client only
+					header := rec.Header()
+					for _, cookie := range header[echo.HeaderSetCookie] {
+						w.Header().Add(echo.HeaderSetCookie, cookie)
 					}
+					header.Del(echo.HeaderSetCookie)
 
-					w.WriteHeader(rec.Code)
-					return rec.Body.Bytes(), nil
+					p := newPage(rec, config.WeakETag)
+					if p.Status != http.StatusOK {
+						built = &p
+						return nil, errUncacheablePage
+					}
+
+					if p.Modified.IsZero() {
+						p.Modified = time.Now()
+					}
+
+					return p, nil
 				},
-				duration,
+				config.Duration,
 			)
 
+			if err == errUncacheablePage {
+				built.serve(w, r, config.CacheControl)
+				return
+			}
+
 			if err != nil {
 				ctx.Error(err)
 				return
 			}
 
-			_, _ = w.Write(content)
+			content.serve(w, r, config.CacheControl)
 		}
 
 		return http.HandlerFunc(fn)
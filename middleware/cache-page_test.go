@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/cache/memory"
+	"github.com/adverax/echo/cacher"
+	cacherMemory "github.com/adverax/echo/cacher/memory"
+	"github.com/adverax/echo/sync/arbiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageCacheWithConfig(t *testing.T) {
+	c := memory.New(memory.Options{})
+	defer c.Stop()
+	storage := cacherMemory.New(arbiter.NewLocal(), c)
+
+	e := echo.New()
+	e.Cacher = cacher.New(storage)
+
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+		w.Header().Set(echo.HeaderSetCookie, "session=1")
+		_, _ = w.Write([]byte("<p>News</p>"))
+	})
+
+	handler := PageCacheWithConfig(e, PageCacheConfig{
+		Class: "news",
+		Dependencies: func(c echo.Context) (map[string]string, error) {
+			return map[string]string{"path": c.Request().URL.Path}, nil
+		},
+		Tags: func(c echo.Context) ([]string, error) {
+			return []string{"news"}, nil
+		},
+		Duration:     time.Hour,
+		CacheControl: "public, max-age=60",
+	})(next)
+
+	serve := func(etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/news", nil)
+		if etag != "" {
+			r.Header.Set(echo.HeaderIfNoneMatch, etag)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	// Miss builds page
+	rec := serve("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<p>News</p>", rec.Body.String())
+	assert.Equal(t, "session=1", rec.Header().Get(echo.HeaderSetCookie))
+	assert.Equal(t, "public, max-age=60", rec.Header().Get(echo.HeaderCacheControl))
+	etag := rec.Header().Get(echo.HeaderETag)
+	require.NotEmpty(t, etag)
+	assert.Equal(t, 1, calls)
+
+	// Hit is served from cache without cookies of other client
+	rec = serve("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<p>News</p>", rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get(echo.HeaderETag))
+	assert.Empty(t, rec.Header().Get(echo.HeaderSetCookie))
+	assert.Equal(t, 1, calls)
+
+	// Revalidation
+	rec = serve(etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get(echo.HeaderETag))
+	assert.Equal(t, 1, calls)
+
+	// Invalidation by tag rebuilds page
+	require.NoError(t, e.Cacher.InvalidateTags("news"))
+	rec = serve(etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, 2, calls)
+
+	rec = serve("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<p>News</p>", rec.Body.String())
+	assert.Equal(t, 2, calls)
+}
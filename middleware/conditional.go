@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/adverax/echo"
+)
+
+// ConditionalConfig defines the config for Conditional middleware.
+type ConditionalConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+
+	// Generate weak ETag (W/"...") instead of strong.
+	// Optional. Default value false.
+	Weak bool
+
+	// Value of Cache-Control header for the route,
+	// for example "private, max-age=0, must-revalidate".
+	// Optional.
+	CacheControl string
+}
+
+var (
+	// DefaultConditionalConfig is the default Conditional middleware config.
+	DefaultConditionalConfig = ConditionalConfig{
+		Skipper: DefaultSkipper,
+	}
+)
+
+// Conditional is a middleware that computes ETag of the response and
+// replies with 304 (Not Modified) for matched conditional GET requests.
+func Conditional(cacheControl string) func(http.Handler) http.Handler {
+	c := DefaultConditionalConfig
+	c.CacheControl = cacheControl
+	return ConditionalWithConfig(c)
+}
+
+// ConditionalWithConfig returns a Conditional middleware with config.
+// See `Conditional()`.
+func ConditionalWithConfig(config ConditionalConfig) func(http.Handler) http.Handler {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultConditionalConfig.Skipper
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			c := echo.RequestContext(r)
+			if config.Skipper(c) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+
+			p := newPage(rec, config.Weak)
+			p.serve(w, r, config.CacheControl)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// CacheControl is a middleware that sets Cache-Control header for the route.
+func CacheControl(value string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(echo.HeaderCacheControl, value)
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// MakeETag returns strong (or weak) entity tag of the content.
+func MakeETag(content []byte, weak bool) string {
+	sum := sha1.Sum(content)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// IsNotModified checks conditional headers of the request (RFC 7232)
+// and returns true, if client has actual copy of the resource.
+// If-None-Match has precedence over If-Modified-Since.
+func IsNotModified(r *http.Request, etag string, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get(echo.HeaderIfNoneMatch); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modified.IsZero() {
+		return false
+	}
+
+	ims := r.Header.Get(echo.HeaderIfModifiedSince)
+	if ims == "" {
+		return false
+	}
+
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// Last-Modified has a second precision
+	return !modified.Truncate(time.Second).After(t)
+}
+
+// Opaque part of entity tag for weak comparison.
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
+
+// Snapshot of response
+type page struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	ETag     string
+	Modified time.Time
+}
+
+func newPage(rec *httptest.ResponseRecorder, weak bool) page {
+	header := rec.Header()
+	p := page{
+		Status: rec.Code,
+		Header: header,
+		Body:   rec.Body.Bytes(),
+		ETag:   header.Get(echo.HeaderETag),
+	}
+
+	if p.ETag == "" {
+		p.ETag = MakeETag(p.Body, weak)
+	}
+	header.Del(echo.HeaderETag)
+
+	if lm := header.Get(echo.HeaderLastModified); lm != "" {
+		p.Modified, _ = http.ParseTime(lm)
+		header.Del(echo.HeaderLastModified)
+	}
+
+	return p
+}
+
+// Write page into the response (or 304, if client has actual copy).
+func (p *page) serve(w http.ResponseWriter, r *http.Request, cacheControl string) {
+	header := w.Header()
+	for k, v := range p.Header {
+		header[k] = v
+	}
+
+	if cacheControl != "" {
+		header.Set(echo.HeaderCacheControl, cacheControl)
+	}
+
+	if p.Status != http.StatusOK {
+		w.WriteHeader(p.Status)
+		_, _ = w.Write(p.Body)
+		return
+	}
+
+	header.Set(echo.HeaderETag, p.ETag)
+	if !p.Modified.IsZero() {
+		header.Set(echo.HeaderLastModified, p.Modified.UTC().Format(http.TimeFormat))
+	}
+
+	if IsNotModified(r, p.ETag, p.Modified) {
+		// RFC 7232, section 4.1
+		header.Del(echo.HeaderContentType)
+		header.Del(echo.HeaderContentLength)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(p.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(p.Body)
+	}
+}
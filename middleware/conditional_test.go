@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNotModified(t *testing.T) {
+	modified := time.Date(2019, 5, 1, 10, 0, 0, 500, time.UTC)
+	etag := MakeETag([]byte("hello"), false)
+
+	type Test struct {
+		method string
+		header map[string]string
+		result bool
+	}
+
+	tests := map[string]Test{
+		"Unconditional": {
+			method: http.MethodGet,
+			result: false,
+		},
+		"Strong match": {
+			method: http.MethodGet,
+			header: map[string]string{echo.HeaderIfNoneMatch: `"x", ` + etag},
+			result: true,
+		},
+		"Weak match": {
+			method: http.MethodGet,
+			header: map[string]string{echo.HeaderIfNoneMatch: "W/" + etag},
+			result: true,
+		},
+		"Mismatch has precedence": {
+			method: http.MethodGet,
+			header: map[string]string{
+				echo.HeaderIfNoneMatch:     `"x"`,
+				echo.HeaderIfModifiedSince: modified.Format(http.TimeFormat),
+			},
+			result: false,
+		},
+		"Not modified since": {
+			method: http.MethodHead,
+			header: map[string]string{echo.HeaderIfModifiedSince: modified.Format(http.TimeFormat)},
+			result: true,
+		},
+		"Modified since": {
+			method: http.MethodGet,
+			header: map[string]string{echo.HeaderIfModifiedSince: modified.Add(-time.Hour).Format(http.TimeFormat)},
+			result: false,
+		},
+		"Unsafe method": {
+			method: http.MethodPost,
+			header: map[string]string{echo.HeaderIfNoneMatch: "*"},
+			result: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/", nil)
+			for k, v := range test.header {
+				r.Header.Set(k, v)
+			}
+			assert.Equal(t, test.result, IsNotModified(r, etag, modified))
+		})
+	}
+}
+
+func TestPage_Serve(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	_, _ = rec.WriteString("<p>Hello</p>")
+	p := newPage(rec, true)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	p.serve(w, r, "public, max-age=60")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, echo.MIMETextHTMLCharsetUTF8, w.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "public, max-age=60", w.Header().Get(echo.HeaderCacheControl))
+	assert.Equal(t, "<p>Hello</p>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.Header.Set(echo.HeaderIfNoneMatch, p.ETag)
+	p.serve(w, r, "")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get(echo.HeaderContentType))
+}
@@ -0,0 +1,394 @@
+package echo
+
+import (
+	"bytes"
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adverax/echo/event"
+)
+
+var ErrStreamingUnsupported = errors.New("streaming is not supported")
+
+// SSEvent is server-sent event.
+// Data of type string or []byte is sent as is, other values are encoded as JSON.
+type SSEvent struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration // Reconnection time for client
+}
+
+// SSE is writer of server-sent events.
+type SSE struct {
+	mx       sync.Mutex
+	ctx      stdContext.Context
+	response *Response
+	lastId   string
+}
+
+// Done is closed, when client is disconnected.
+func (sse *SSE) Done() <-chan struct{} {
+	return sse.ctx.Done()
+}
+
+// LastEventID returns identifier of the last event, received by client
+// before reconnection.
+func (sse *SSE) LastEventID() string {
+	return sse.lastId
+}
+
+// Send event to the client.
+func (sse *SSE) Send(ev SSEvent) error {
+	var buf bytes.Buffer
+	if ev.ID != "" {
+		buf.WriteString("id: ")
+		buf.WriteString(sseLine(ev.ID))
+		buf.WriteByte('\n')
+	}
+	if ev.Event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(sseLine(ev.Event))
+		buf.WriteByte('\n')
+	}
+	if ev.Retry > 0 {
+		buf.WriteString("retry: ")
+		buf.WriteString(strconv.FormatInt(int64(ev.Retry/time.Millisecond), 10))
+		buf.WriteByte('\n')
+	}
+
+	data, err := sseData(ev.Data)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: ")
+		buf.WriteString(strings.TrimSuffix(line, "\r"))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	return sse.write(buf.Bytes())
+}
+
+// Comment sends comment, that is ignored by client.
+// It is used for keeping connection alive.
+func (sse *SSE) Comment(text string) error {
+	return sse.write([]byte(": " + sseLine(text) + "\n\n"))
+}
+
+// Stream sends events from source until client is disconnected or source
+// is closed. If keepalive is positive, comments are sent in idle periods.
+func (sse *SSE) Stream(source <-chan SSEvent, keepalive time.Duration) error {
+	var tick <-chan time.Time
+	if keepalive > 0 {
+		ticker := time.NewTicker(keepalive)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-sse.ctx.Done():
+			return nil
+		case <-tick:
+			if err := sse.Comment("keepalive"); err != nil {
+				return err
+			}
+		case ev, ok := <-source:
+			if !ok {
+				return nil
+			}
+			if err := sse.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (sse *SSE) write(data []byte) error {
+	sse.mx.Lock()
+	defer sse.mx.Unlock()
+
+	if err := sse.ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := sse.response.Write(data)
+	if err != nil {
+		return err
+	}
+	sse.response.Flush()
+	return nil
+}
+
+func sseLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+}
+
+func sseData(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		res, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(res), nil
+	}
+}
+
+func (c *context) SSE() (*SSE, error) {
+	if _, ok := c.response.Writer.(http.Flusher); !ok {
+		return nil, ErrStreamingUnsupported
+	}
+
+	header := c.response.Header()
+	header.Set(HeaderContentType, MIMETextEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.response.WriteHeader(http.StatusOK)
+	c.response.Flush()
+
+	lastId := c.request.Header.Get(HeaderLastEventID)
+	if lastId == "" {
+		lastId = c.QueryParam("lastEventId")
+	}
+
+	return &SSE{
+		ctx:      c.request.Context(),
+		response: c.response,
+		lastId:   lastId,
+	}, nil
+}
+
+// SSEHistory is ring buffer of the last events for resuming of streams.
+type SSEHistory struct {
+	mx     sync.Mutex
+	seq    uint64
+	events []SSEvent
+	head   int // Index of the oldest event
+	count  int
+}
+
+// Append event into history. Identifier of event is replaced by sequence number.
+func (h *SSEHistory) Append(ev SSEvent) SSEvent {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.seq++
+	ev.ID = strconv.FormatUint(h.seq, 10)
+	if len(h.events) == 0 {
+		return ev
+	}
+
+	if h.count < len(h.events) {
+		h.events[(h.head+h.count)%len(h.events)] = ev
+		h.count++
+	} else {
+		h.events[h.head] = ev
+		h.head = (h.head + 1) % len(h.events)
+	}
+	return ev
+}
+
+// Since returns retained events after event with identifier id.
+func (h *SSEHistory) Since(id string) []SSEvent {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	last, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	var res []SSEvent
+	for i := 0; i < h.count; i++ {
+		ev := h.events[(h.head+i)%len(h.events)]
+		seq, _ := strconv.ParseUint(ev.ID, 10, 64)
+		if seq > last {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+// NewSSEHistory creates history with capacity size.
+func NewSSEHistory(size int) *SSEHistory {
+	if size < 0 {
+		size = 0
+	}
+	return &SSEHistory{
+		events: make([]SSEvent, size),
+	}
+}
+
+type SSEBrokerOptions struct {
+	History   int           // Count of events, retained for resuming (default 0)
+	Buffer    int           // Capacity of client queue (default 64)
+	Keepalive time.Duration // Interval of keepalive comments (default 15s)
+}
+
+type sseClient struct {
+	patterns []string
+	events   chan SSEvent
+}
+
+func (client *sseClient) accept(name string) bool {
+	for _, pattern := range client.patterns {
+		if event.Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SSEBroker bridges events of publisher to browsers.
+// Name of the published event is used as SSE event type.
+// Slow clients, that overflow own queue, are disconnected and
+// can resume stream by Last-Event-ID.
+type SSEBroker struct {
+	mx            sync.Mutex
+	options       SSEBrokerOptions
+	history       *SSEHistory
+	clients       map[*sseClient]struct{}
+	subscriptions []event.Subscription
+}
+
+// Handler streams events, matched with patterns, to the client.
+func (broker *SSEBroker) Handler(patterns ...string) HandlerFunc {
+	return func(ctx Context) error {
+		sse, err := ctx.SSE()
+		if err != nil {
+			return err
+		}
+
+		client := &sseClient{
+			patterns: patterns,
+			events:   make(chan SSEvent, broker.options.Buffer),
+		}
+
+		backlog := broker.attach(client, sse.LastEventID())
+		defer broker.detach(client)
+
+		for _, ev := range backlog {
+			if err := sse.Send(ev); err != nil {
+				return err
+			}
+		}
+
+		return sse.Stream(client.events, broker.options.Keepalive)
+	}
+}
+
+// Close unsubscribes broker from publisher and disconnects all clients.
+func (broker *SSEBroker) Close() {
+	broker.mx.Lock()
+	defer broker.mx.Unlock()
+
+	for _, sub := range broker.subscriptions {
+		sub.Unsubscribe()
+	}
+	broker.subscriptions = nil
+
+	for client := range broker.clients {
+		close(client.events)
+		delete(broker.clients, client)
+	}
+}
+
+// Register client and return missed events
+func (broker *SSEBroker) attach(client *sseClient, lastId string) []SSEvent {
+	broker.mx.Lock()
+	defer broker.mx.Unlock()
+
+	broker.clients[client] = struct{}{}
+
+	if lastId == "" {
+		return nil
+	}
+
+	var res []SSEvent
+	for _, ev := range broker.history.Since(lastId) {
+		if client.accept(ev.Event) {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+func (broker *SSEBroker) detach(client *sseClient) {
+	broker.mx.Lock()
+	defer broker.mx.Unlock()
+
+	if _, ok := broker.clients[client]; ok {
+		close(client.events)
+		delete(broker.clients, client)
+	}
+}
+
+func (broker *SSEBroker) publish(ctx stdContext.Context, data interface{}) error {
+	broker.mx.Lock()
+	defer broker.mx.Unlock()
+
+	ev := broker.history.Append(SSEvent{
+		Event: event.Name(ctx),
+		Data:  data,
+	})
+
+	for client := range broker.clients {
+		if !client.accept(ev.Event) {
+			continue
+		}
+
+		select {
+		case client.events <- ev:
+		default:
+			close(client.events)
+			delete(broker.clients, client)
+		}
+	}
+
+	return nil
+}
+
+// NewSSEBroker creates broker, subscribed to topics of registrar.
+func NewSSEBroker(
+	registrar event.Registrar,
+	topics []string,
+	options SSEBrokerOptions,
+) *SSEBroker {
+	if options.Buffer <= 0 {
+		options.Buffer = 64
+	}
+	if options.Keepalive == 0 {
+		options.Keepalive = 15 * time.Second
+	}
+
+	broker := &SSEBroker{
+		options: options,
+		history: NewSSEHistory(options.History),
+		clients: make(map[*sseClient]struct{}, 64),
+	}
+
+	for _, topic := range topics {
+		broker.subscriptions = append(
+			broker.subscriptions,
+			registrar.On(topic, broker.publish),
+		)
+	}
+
+	return broker
+}
@@ -0,0 +1,109 @@
+package echo
+
+import (
+	"bufio"
+	stdContext "context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/event"
+	testify "github.com/stretchr/testify/assert"
+)
+
+func TestContextSSE(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(GET, "/", nil)
+	req.Header.Set(HeaderLastEventID, "7")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert := testify.New(t)
+	sse, err := c.SSE()
+	if assert.NoError(err) {
+		assert.Equal("7", sse.LastEventID())
+		assert.NoError(sse.Send(SSEvent{ID: "8", Event: "user.created", Data: "a\nb", Retry: 3 * time.Second}))
+		assert.NoError(sse.Send(SSEvent{Data: map[string]int{"id": 1}}))
+		assert.NoError(sse.Comment("ping"))
+		assert.Equal(MIMETextEventStream, rec.Header().Get(HeaderContentType))
+		assert.Equal(
+			"id: 8\nevent: user.created\nretry: 3000\ndata: a\ndata: b\n\n"+
+				"data: {\"id\":1}\n\n"+
+				": ping\n\n",
+			rec.Body.String(),
+		)
+	}
+}
+
+func TestSSEHistory(t *testing.T) {
+	h := NewSSEHistory(3)
+	for _, data := range []string{"a", "b", "c", "d"} {
+		h.Append(SSEvent{Data: data})
+	}
+
+	assert := testify.New(t)
+	events := h.Since("1")
+	if assert.Len(events, 3) {
+		assert.Equal("2", events[0].ID)
+		assert.Equal("d", events[2].Data)
+	}
+	assert.Len(h.Since("3"), 1)
+	assert.Empty(h.Since("4"))
+	assert.Empty(h.Since("bad"))
+}
+
+func TestSSEBroker(t *testing.T) {
+	pub := event.New()
+	defer pub.Close()
+	broker := NewSSEBroker(pub, []string{"user.*"}, SSEBrokerOptions{History: 10})
+	defer broker.Close()
+
+	ctx := stdContext.Background()
+	assert := testify.New(t)
+	assert.NoError(pub.Trigger(ctx, "user.created", "Bob"))
+
+	e := New()
+	e.Router().Get("/events", broker.Handler("user.*"))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(GET, srv.URL+"/events", nil)
+	req.Header.Set(HeaderLastEventID, "0")
+	res, err := http.DefaultClient.Do(req)
+	if !assert.NoError(err) {
+		return
+	}
+	defer res.Body.Close()
+
+	r := bufio.NewReader(res.Body)
+	read := func() string {
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	// Replayed from history
+	assert.Equal("id: 1\nevent: user.created\ndata: Bob\n", read())
+
+	// Wait for live subscription of the client
+	for i := 0; i < 100; i++ {
+		broker.mx.Lock()
+		n := len(broker.clients)
+		broker.mx.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NoError(pub.Trigger(ctx, "order.created", 1))
+	assert.NoError(pub.Trigger(ctx, "user.deleted", "Alice"))
+	assert.Equal("id: 2\nevent: user.deleted\ndata: Alice\n", read())
+}
@@ -1,7 +1,67 @@
 package arbiter
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
 // Arbiter is multi mutex manager for exclusive access.
 type Arbiter interface {
 	Lock(key string)
 	Unlock(key string)
+	// Lock key or fail, when context is done.
+	LockContext(ctx context.Context, key string) error
+	// Lock key without waiting. Returns true, if key is locked.
+	TryLock(key string) bool
+}
+
+// RWArbiter is multi mutex manager for exclusive and shared access.
+type RWArbiter interface {
+	Arbiter
+	RLock(key string)
+	RUnlock(key string)
+	// Lock key for reading or fail, when context is done.
+	RLockContext(ctx context.Context, key string) error
+	// Lock key for reading without waiting. Returns true, if key is locked.
+	TryRLock(key string) bool
+}
+
+// Semaphore is manager of limited permits for each key.
+type Semaphore interface {
+	// Acquire n permits of key or fail, when context is done.
+	Acquire(ctx context.Context, key string, n int) error
+	// Acquire n permits of key without waiting. Returns true, if permits are acquired.
+	TryAcquire(key string, n int) bool
+	// Release n permits of key.
+	Release(key string, n int)
 }
+
+// Lease is exclusive lock with limited life time.
+// Token is fencing token, that increases with each acquired lease, so
+// resource can reject requests of the holders with outdated leases.
+type Lease struct {
+	Key     string
+	Token   uint64
+	Expires time.Time
+}
+
+// Leaser is manager of leases.
+type Leaser interface {
+	// Acquire lease of key or fail, when context is done.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+	// Acquire lease without waiting. Returns ErrLocked, if key is leased.
+	TryAcquire(key string, ttl time.Duration) (Lease, error)
+	// Extend life time of the lease.
+	Renew(lease Lease, ttl time.Duration) (Lease, error)
+	// Release lease.
+	Release(lease Lease) error
+	// Check, that lease is still actual.
+	Validate(lease Lease) error
+}
+
+var (
+	ErrLocked         = errors.New("key is locked")
+	ErrLeaseLost      = errors.New("lease is expired or superseded")
+	ErrTooManyPermits = errors.New("too many permits requested")
+)
@@ -0,0 +1,111 @@
+package arbiter
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestArbiter_TryLock(t *testing.T) {
+	a := NewLocalRW()
+
+	require.True(t, a.TryLock("a"))
+	assert.False(t, a.TryLock("a"))
+	assert.False(t, a.TryRLock("a"))
+	assert.True(t, a.TryLock("b"))
+	a.Unlock("a")
+	a.Unlock("b")
+
+	require.True(t, a.TryRLock("a"))
+	assert.True(t, a.TryRLock("a"))
+	assert.False(t, a.TryLock("a"))
+	a.RUnlock("a")
+	a.RUnlock("a")
+	assert.True(t, a.TryLock("a"))
+	a.Unlock("a")
+
+	assert.Empty(t, a.(*localArbiter).latches)
+}
+
+func TestArbiter_LockContext(t *testing.T) {
+	a := NewLocalRW()
+	a.RLock("a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := a.LockContext(ctx, "a")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.LockContext(context.Background(), "a")
+	}()
+
+	// Waiting writer blocks new readers
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, a.TryRLock("a"))
+
+	a.RUnlock("a")
+	require.NoError(t, <-done)
+	a.Unlock("a")
+	assert.Empty(t, a.(*localArbiter).latches)
+}
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore(3)
+
+	assert.Equal(t, ErrTooManyPermits, s.Acquire(context.Background(), "a", 4))
+	require.True(t, s.TryAcquire("a", 2))
+	assert.False(t, s.TryAcquire("a", 2))
+	assert.True(t, s.TryAcquire("a", 1))
+	assert.True(t, s.TryAcquire("b", 3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Acquire(ctx, "a", 1))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Acquire(context.Background(), "a", 2)
+	}()
+	s.Release("a", 1)
+	s.Release("a", 1)
+	require.NoError(t, <-done)
+	s.Release("a", 3)
+	s.Release("b", 3)
+	assert.Empty(t, s.(*localSemaphore).permits)
+}
+
+func TestLeaser(t *testing.T) {
+	l := NewLeaser()
+
+	l1, err := l.TryAcquire("a", 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.NoError(t, l.Validate(l1))
+	_, err = l.TryAcquire("a", time.Second)
+	assert.Equal(t, ErrLocked, err)
+
+	// Expired lease is superseded by the new one
+	l2, err := l.Acquire(context.Background(), "a", time.Second)
+	require.NoError(t, err)
+	assert.True(t, l2.Token > l1.Token)
+	assert.Equal(t, ErrLeaseLost, l.Validate(l1))
+	_, err = l.Renew(l1, time.Second)
+	assert.Equal(t, ErrLeaseLost, err)
+	assert.Equal(t, ErrLeaseLost, l.Release(l1))
+
+	l2, err = l.Renew(l2, time.Minute)
+	require.NoError(t, err)
+	done := make(chan Lease, 1)
+	go func() {
+		l3, _ := l.Acquire(context.Background(), "a", time.Second)
+		done <- l3
+	}()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, l.Release(l2))
+	l3 := <-done
+	assert.True(t, l3.Token > l2.Token)
+	assert.NoError(t, l.Validate(l3))
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package database implements arbiter, that coordinates cluster nodes
+// through global latches of the shared database.
+package database
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/sync/arbiter"
+)
+
+// Bounds of pause between attempts of Lock, while database is not available.
+var (
+	minRetryPause = 10 * time.Millisecond
+	maxRetryPause = time.Second
+)
+
+type engine struct {
+	sync.Mutex
+	db     sql.DB
+	prefix string
+	held   map[string]sql.Tx // Latch is bound to connection, so each key keeps own transaction
+}
+
+// Lock key. Waits for database, while it is not available.
+func (engine *engine) Lock(key string) {
+	pause := minRetryPause
+	for engine.acquire(context.Background(), key, -1) != nil {
+		time.Sleep(pause)
+		if pause < maxRetryPause {
+			pause *= 2
+		}
+	}
+}
+
+func (engine *engine) Unlock(key string) {
+	engine.Mutex.Lock()
+	tx, ok := engine.held[key]
+	delete(engine.held, key)
+	engine.Mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	_ = tx.Adapter().UnlockGlobal(context.Background(), tx, engine.prefix+key)
+	_ = tx.Rollback()
+}
+
+func (engine *engine) LockContext(ctx context.Context, key string) error {
+	timeout := -1
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = int(math.Ceil(time.Until(deadline).Seconds()))
+		if timeout < 0 {
+			timeout = 0
+		}
+	}
+
+	err := engine.acquire(ctx, key, timeout)
+	if err == sql.ErrCaptureLock {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+func (engine *engine) TryLock(key string) bool {
+	return engine.acquire(context.Background(), key, 0) == nil
+}
+
+func (engine *engine) acquire(
+	ctx context.Context,
+	key string,
+	timeout int, // seconds
+) error {
+	// Latch is bound to session, so transaction must survive cancellation
+	// of ctx. Otherwise connection returns into pool with acquired latch.
+	// The ctx limits only waiting for the latch.
+	tx, err := engine.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	latch := engine.prefix + key
+	err = tx.Adapter().LockGlobal(ctx, tx, latch, timeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Latch can be acquired before cancellation of the query
+			_ = tx.Adapter().UnlockGlobal(context.Background(), tx, latch)
+		}
+		_ = tx.Rollback()
+		return err
+	}
+
+	engine.Mutex.Lock()
+	defer engine.Mutex.Unlock()
+	engine.held[key] = tx
+	return nil
+}
+
+// New creates arbiter, based on global latches of database.
+// Prefix separates latches of the different applications.
+func New(db sql.DB, prefix string) arbiter.Arbiter {
+	return &engine{
+		db:     db,
+		prefix: prefix,
+		held:   make(map[string]sql.Tx, 16),
+	}
+}
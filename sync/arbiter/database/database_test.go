@@ -0,0 +1,117 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo/database/sql"
+	"github.com/adverax/echo/database/sql/sqltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEngine(t *testing.T) (*engine, *sqltest.Mock) {
+	mock := sqltest.New()
+	db, err := sqltest.Open(nil, mock)
+	require.NoError(t, err)
+	return New(db, "app:").(*engine), mock
+}
+
+func TestEngine_LockContext(t *testing.T) {
+	engine, mock := newTestEngine(t)
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL app:a")
+	mock.ExpectExec("UNLOCK GLOBAL app:a")
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	require.NoError(t, engine.LockContext(ctx, "a"))
+
+	// Cancellation of the context must not finish transaction with latch
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	engine.Unlock("a")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, engine.held)
+}
+
+func TestEngine_LockContext_Timeout(t *testing.T) {
+	engine, mock := newTestEngine(t)
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL app:a").WillReturnError(sql.ErrCaptureLock)
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, engine.LockContext(ctx, "a"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL app:a").WillDelayFor(time.Minute)
+	mock.ExpectExec("UNLOCK GLOBAL app:a")
+	mock.ExpectRollback()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, engine.LockContext(ctx, "a"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, engine.held)
+}
+
+func TestEngine_TryLock(t *testing.T) {
+	engine, mock := newTestEngine(t)
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL app:a").WillReturnError(sql.ErrCaptureLock)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL app:b")
+
+	assert.False(t, engine.TryLock("a"))
+	assert.True(t, engine.TryLock("b"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Len(t, engine.held, 1)
+}
+
+func TestEngine_Lock(t *testing.T) {
+	engine, mock := newTestEngine(t)
+	defer mock.Close()
+
+	// Lock waits, while database is not available
+	mock.ExpectBegin().WillReturnError(errors.New("database is down"))
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK GLOBAL app:a")
+	mock.ExpectExec("UNLOCK GLOBAL app:a")
+	mock.ExpectRollback()
+
+	engine.Lock("a")
+	engine.Unlock("a")
+	engine.Unlock("a")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, engine.held)
+}
@@ -0,0 +1,138 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type lease struct {
+	token   uint64
+	expires time.Time
+}
+
+type localLeaser struct {
+	sync.Mutex
+	token   uint64 // Last issued fencing token
+	leases  map[string]*lease
+	changed chan struct{} // Closed on each release
+}
+
+func (leaser *localLeaser) Acquire(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+) (Lease, error) {
+	leaser.Mutex.Lock()
+	defer leaser.Mutex.Unlock()
+
+	for {
+		res, err := leaser.acquire(key, ttl)
+		if err != ErrLocked {
+			return res, err
+		}
+
+		changed := leaser.changed
+		timer := time.NewTimer(time.Until(leaser.leases[key].expires))
+		leaser.Mutex.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			leaser.Mutex.Lock()
+			return Lease{}, ctx.Err()
+		}
+		timer.Stop()
+		leaser.Mutex.Lock()
+	}
+}
+
+func (leaser *localLeaser) TryAcquire(
+	key string,
+	ttl time.Duration,
+) (Lease, error) {
+	leaser.Mutex.Lock()
+	defer leaser.Mutex.Unlock()
+
+	return leaser.acquire(key, ttl)
+}
+
+func (leaser *localLeaser) Renew(
+	l Lease,
+	ttl time.Duration,
+) (Lease, error) {
+	leaser.Mutex.Lock()
+	defer leaser.Mutex.Unlock()
+
+	current, err := leaser.check(l)
+	if err != nil {
+		return Lease{}, err
+	}
+
+	current.expires = time.Now().Add(ttl)
+	l.Expires = current.expires
+	return l, nil
+}
+
+func (leaser *localLeaser) Release(l Lease) error {
+	leaser.Mutex.Lock()
+	defer leaser.Mutex.Unlock()
+
+	_, err := leaser.check(l)
+	if err != nil {
+		return err
+	}
+
+	delete(leaser.leases, l.Key)
+	close(leaser.changed)
+	leaser.changed = make(chan struct{})
+	return nil
+}
+
+func (leaser *localLeaser) Validate(l Lease) error {
+	leaser.Mutex.Lock()
+	defer leaser.Mutex.Unlock()
+
+	_, err := leaser.check(l)
+	return err
+}
+
+func (leaser *localLeaser) acquire(
+	key string,
+	ttl time.Duration,
+) (Lease, error) {
+	now := time.Now()
+	if current, ok := leaser.leases[key]; ok && current.expires.After(now) {
+		return Lease{}, ErrLocked
+	}
+
+	leaser.token++
+	current := &lease{
+		token:   leaser.token,
+		expires: now.Add(ttl),
+	}
+	leaser.leases[key] = current
+
+	return Lease{
+		Key:     key,
+		Token:   current.token,
+		Expires: current.expires,
+	}, nil
+}
+
+func (leaser *localLeaser) check(l Lease) (*lease, error) {
+	current, ok := leaser.leases[l.Key]
+	if !ok || current.token != l.Token || !current.expires.After(time.Now()) {
+		return nil, ErrLeaseLost
+	}
+	return current, nil
+}
+
+// NewLeaser creates local manager of leases.
+func NewLeaser() Leaser {
+	return &localLeaser{
+		leases:  make(map[string]*lease, 64),
+		changed: make(chan struct{}),
+	}
+}
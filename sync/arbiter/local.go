@@ -1,10 +1,31 @@
 package arbiter
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type latch struct {
-	sync.Mutex
-	usage int
+	usage   int           // Count of holders and waiters
+	readers int           // Count of shared holders
+	writer  bool          // Latch has exclusive holder
+	writers int           // Count of waiting exclusive holders
+	changed chan struct{} // Closed on each release
+}
+
+// Check, that latch can be captured.
+// Waiting writers have priority over new readers.
+func (l *latch) free(exclusive bool) bool {
+	if exclusive {
+		return !l.writer && l.readers == 0
+	}
+	return !l.writer && l.writers == 0
+}
+
+// Wake up all waiters.
+func (l *latch) broadcast() {
+	close(l.changed)
+	l.changed = make(chan struct{})
 }
 
 type localArbiter struct {
@@ -13,32 +34,131 @@ type localArbiter struct {
 }
 
 func (arbiter *localArbiter) Lock(key string) {
+	_ = arbiter.acquire(context.Background(), key, true, true)
+}
+
+func (arbiter *localArbiter) Unlock(key string) {
+	arbiter.release(key, true)
+}
+
+func (arbiter *localArbiter) LockContext(ctx context.Context, key string) error {
+	return arbiter.acquire(ctx, key, true, true)
+}
+
+func (arbiter *localArbiter) TryLock(key string) bool {
+	return arbiter.acquire(context.Background(), key, true, false) == nil
+}
+
+func (arbiter *localArbiter) RLock(key string) {
+	_ = arbiter.acquire(context.Background(), key, false, true)
+}
+
+func (arbiter *localArbiter) RUnlock(key string) {
+	arbiter.release(key, false)
+}
+
+func (arbiter *localArbiter) RLockContext(ctx context.Context, key string) error {
+	return arbiter.acquire(ctx, key, false, true)
+}
+
+func (arbiter *localArbiter) TryRLock(key string) bool {
+	return arbiter.acquire(context.Background(), key, false, false) == nil
+}
+
+func (arbiter *localArbiter) acquire(
+	ctx context.Context,
+	key string,
+	exclusive bool,
+	wait bool,
+) error {
 	arbiter.Mutex.Lock()
 	aLatch, ok := arbiter.latches[key]
 	if !ok {
-		aLatch = new(latch)
+		aLatch = &latch{changed: make(chan struct{})}
 		arbiter.latches[key] = aLatch
 	}
 	aLatch.usage++
+	if exclusive {
+		aLatch.writers++
+	}
+
+	for !aLatch.free(exclusive) {
+		if !wait {
+			arbiter.cancel(key, aLatch, exclusive)
+			arbiter.Mutex.Unlock()
+			return ErrLocked
+		}
+
+		changed := aLatch.changed
+		arbiter.Mutex.Unlock()
+		select {
+		case <-changed:
+			arbiter.Mutex.Lock()
+		case <-ctx.Done():
+			arbiter.Mutex.Lock()
+			arbiter.cancel(key, aLatch, exclusive)
+			arbiter.Mutex.Unlock()
+			return ctx.Err()
+		}
+	}
+
+	if exclusive {
+		aLatch.writers--
+		aLatch.writer = true
+	} else {
+		aLatch.readers++
+	}
 	arbiter.Mutex.Unlock()
-	aLatch.Lock()
+	return nil
 }
 
-func (arbiter *localArbiter) Unlock(key string) {
+// Rollback of waiting
+func (arbiter *localArbiter) cancel(key string, aLatch *latch, exclusive bool) {
+	aLatch.usage--
+	if exclusive {
+		aLatch.writers--
+		aLatch.broadcast()
+	}
+	if aLatch.usage == 0 {
+		delete(arbiter.latches, key)
+	}
+}
+
+func (arbiter *localArbiter) release(key string, exclusive bool) {
 	arbiter.Mutex.Lock()
-	if latch, ok := arbiter.latches[key]; ok {
-		latch.usage--
-		if latch.usage == 0 {
-			delete(arbiter.latches, key)
+	defer arbiter.Mutex.Unlock()
+
+	aLatch, ok := arbiter.latches[key]
+	if !ok {
+		return
+	}
+
+	if exclusive {
+		if !aLatch.writer {
+			return
 		}
-		arbiter.Mutex.Unlock()
-		latch.Unlock()
+		aLatch.writer = false
 	} else {
-		arbiter.Mutex.Unlock()
+		if aLatch.readers == 0 {
+			return
+		}
+		aLatch.readers--
+	}
+
+	aLatch.usage--
+	aLatch.broadcast()
+	if aLatch.usage == 0 {
+		delete(arbiter.latches, key)
 	}
 }
 
+// NewLocal creates arbiter for current process.
 func NewLocal() Arbiter {
+	return NewLocalRW()
+}
+
+// NewLocalRW creates arbiter with shared locks for current process.
+func NewLocalRW() RWArbiter {
 	return &localArbiter{
 		latches: make(map[string]*latch, 1024),
 	}
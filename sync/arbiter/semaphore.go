@@ -0,0 +1,101 @@
+package arbiter
+
+import (
+	"context"
+	"sync"
+)
+
+type permits struct {
+	used    int           // Count of acquired permits
+	usage   int           // Count of holders and waiters
+	changed chan struct{} // Closed on each release
+}
+
+type localSemaphore struct {
+	sync.Mutex
+	limit   int
+	permits map[string]*permits
+}
+
+func (sem *localSemaphore) Acquire(ctx context.Context, key string, n int) error {
+	return sem.acquire(ctx, key, n, true)
+}
+
+func (sem *localSemaphore) TryAcquire(key string, n int) bool {
+	return sem.acquire(context.Background(), key, n, false) == nil
+}
+
+func (sem *localSemaphore) Release(key string, n int) {
+	sem.Mutex.Lock()
+	defer sem.Mutex.Unlock()
+
+	p, ok := sem.permits[key]
+	if !ok {
+		return
+	}
+
+	if n > p.used {
+		n = p.used
+	}
+	p.used -= n
+	close(p.changed)
+	p.changed = make(chan struct{})
+	if p.used == 0 && p.usage == 0 {
+		delete(sem.permits, key)
+	}
+}
+
+func (sem *localSemaphore) acquire(
+	ctx context.Context,
+	key string,
+	n int,
+	wait bool,
+) error {
+	if n > sem.limit {
+		return ErrTooManyPermits
+	}
+
+	sem.Mutex.Lock()
+	defer sem.Mutex.Unlock()
+
+	p, ok := sem.permits[key]
+	if !ok {
+		p = &permits{changed: make(chan struct{})}
+		sem.permits[key] = p
+	}
+
+	p.usage++
+	defer func() {
+		p.usage--
+		if p.used == 0 && p.usage == 0 {
+			delete(sem.permits, key)
+		}
+	}()
+
+	for p.used+n > sem.limit {
+		if !wait {
+			return ErrLocked
+		}
+
+		changed := p.changed
+		sem.Mutex.Unlock()
+		select {
+		case <-changed:
+			sem.Mutex.Lock()
+		case <-ctx.Done():
+			sem.Mutex.Lock()
+			return ctx.Err()
+		}
+	}
+
+	p.used += n
+	return nil
+}
+
+// NewSemaphore creates local semaphore with limit permits for each key.
+func NewSemaphore(limit int) Semaphore {
+	return &localSemaphore{
+		limit:   limit,
+		permits: make(map[string]*permits, 64),
+	}
+}
@@ -0,0 +1,519 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package websocket implements server side of the WebSocket protocol (RFC 6455)
+// with optional per-message compression (RFC 7692).
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+const continuationFrame = 0
+
+// Close codes
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+	CloseTryAgainLater           = 1013
+)
+
+const (
+	finalBit = 0x80
+	rsv1Bit  = 0x40
+	rsv2Bit  = 0x20
+	rsv3Bit  = 0x10
+	maskBit  = 0x80
+
+	maxControlPayload = 125
+	defaultReadLimit  = 32 << 20 // 32 MB
+	closeTimeout      = time.Second
+)
+
+var (
+	ErrReadLimit    = errors.New("websocket: message is too big")
+	ErrCloseSent    = errors.New("websocket: close frame is sent")
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+)
+
+// CloseError is returned by ReadMessage, when close frame is received
+// or protocol is violated.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(e.Code) + " " + e.Text
+}
+
+// IsCloseError checks, that err is CloseError with one of codes.
+func IsCloseError(err error, codes ...int) bool {
+	if e, ok := err.(*CloseError); ok {
+		for _, code := range codes {
+			if e.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conn is WebSocket connection.
+// Only one goroutine can read from connection, writes are serialized.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	server      bool
+	subprotocol string
+	compress    bool // Compression is negotiated
+	level       int
+
+	readLimit   int64
+	pongHandler func(data string) error
+
+	wmx       sync.Mutex
+	closeSent bool
+}
+
+// Subprotocol returns negotiated subprotocol.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets maximum size of message.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets deadline of reading.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets deadline of writing.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPongHandler sets handler of pong messages.
+func (c *Conn) SetPongHandler(h func(data string) error) {
+	c.pongHandler = h
+}
+
+// SetCompressionLevel sets level of compression for outgoing messages.
+func (c *Conn) SetCompressionLevel(level int) {
+	c.level = level
+}
+
+// ReadMessage reads next data message. Control frames are handled internally:
+// pings are answered with pongs and close frame is confirmed and
+// returned as CloseError.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	var buf bytes.Buffer
+	compressed := false
+
+	for {
+		h, err := c.readHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if h.opcode >= CloseMessage {
+			payload, err := c.readPayload(h)
+			if err != nil {
+				return 0, nil, err
+			}
+			err = c.control(h.opcode, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		if messageType == 0 {
+			if h.opcode == continuationFrame {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation")
+			}
+			messageType = h.opcode
+			compressed = h.rsv1
+		} else if h.opcode != continuationFrame {
+			return 0, nil, c.fail(CloseProtocolError, "expected continuation")
+		}
+
+		if int64(buf.Len())+h.length > c.readLimit {
+			_ = c.fail(CloseMessageTooBig, "")
+			return 0, nil, ErrReadLimit
+		}
+
+		payload, err := c.readPayload(h)
+		if err != nil {
+			return 0, nil, err
+		}
+		buf.Write(payload)
+
+		if h.final {
+			break
+		}
+	}
+
+	data = buf.Bytes()
+	if compressed {
+		data, err = c.inflate(data)
+		if err != nil {
+			return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid compressed data")
+		}
+	}
+
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid utf8")
+	}
+
+	return messageType, data, nil
+}
+
+// WriteMessage writes data message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return c.WriteControl(messageType, data, time.Time{})
+	}
+
+	rsv1 := false
+	if c.compress && len(data) > 0 {
+		compressed, err := c.deflate(data)
+		if err != nil {
+			return err
+		}
+		data = compressed
+		rsv1 = true
+	}
+
+	c.wmx.Lock()
+	defer c.wmx.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	return c.writeFrame(messageType, rsv1, data)
+}
+
+// WriteControl writes control message with deadline.
+func (c *Conn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType < CloseMessage || messageType > PongMessage {
+		return errors.New("websocket: invalid control message type")
+	}
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: control message is too big")
+	}
+
+	c.wmx.Lock()
+	defer c.wmx.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+
+	_ = c.conn.SetWriteDeadline(deadline)
+	defer c.conn.SetWriteDeadline(time.Time{})
+	return c.writeFrame(messageType, false, data)
+}
+
+// Ping sends ping message.
+func (c *Conn) Ping(data []byte) error {
+	return c.WriteControl(PingMessage, data, time.Now().Add(closeTimeout))
+}
+
+// Close sends close frame with code and reason and closes connection.
+func (c *Conn) Close(code int, reason string) error {
+	_ = c.WriteControl(CloseMessage, FormatCloseMessage(code, reason), time.Now().Add(closeTimeout))
+	return c.conn.Close()
+}
+
+// FormatCloseMessage formats payload of close frame.
+func FormatCloseMessage(code int, reason string) []byte {
+	if code == CloseNoStatusReceived {
+		return []byte{}
+	}
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	buf := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(buf, uint16(code))
+	copy(buf[2:], reason)
+	return buf
+}
+
+func (c *Conn) control(opcode int, payload []byte) error {
+	switch opcode {
+	case PingMessage:
+		err := c.WriteControl(PongMessage, payload, time.Now().Add(closeTimeout))
+		if err != nil && err != ErrCloseSent {
+			return err
+		}
+		return nil
+	case PongMessage:
+		if c.pongHandler != nil {
+			return c.pongHandler(string(payload))
+		}
+		return nil
+	case CloseMessage:
+		code := CloseNoStatusReceived
+		text := ""
+		if len(payload) == 1 {
+			return c.fail(CloseProtocolError, "invalid close payload")
+		}
+		if len(payload) >= 2 {
+			code = int(binary.BigEndian.Uint16(payload))
+			text = string(payload[2:])
+			if !isValidCloseCode(code) || !utf8.ValidString(text) {
+				return c.fail(CloseProtocolError, "invalid close payload")
+			}
+		}
+		reply := code
+		if reply == CloseNoStatusReceived {
+			reply = CloseNormalClosure
+		}
+		_ = c.WriteControl(CloseMessage, FormatCloseMessage(reply, ""), time.Now().Add(closeTimeout))
+		return &CloseError{Code: code, Text: text}
+	default:
+		return c.fail(CloseProtocolError, "unknown opcode")
+	}
+}
+
+// Send close frame because of protocol violation.
+func (c *Conn) fail(code int, text string) error {
+	_ = c.WriteControl(CloseMessage, FormatCloseMessage(code, text), time.Now().Add(closeTimeout))
+	return &CloseError{Code: code, Text: text}
+}
+
+type header struct {
+	final  bool
+	rsv1   bool
+	opcode int
+	masked bool
+	mask   [4]byte
+	length int64
+}
+
+func (c *Conn) readHeader() (h header, err error) {
+	var b [8]byte
+	if _, err = io.ReadFull(c.br, b[:2]); err != nil {
+		return h, err
+	}
+
+	h.final = b[0]&finalBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = int(b[0] & 0x0f)
+	h.masked = b[1]&maskBit != 0
+	h.length = int64(b[1] & 0x7f)
+
+	if b[0]&(rsv2Bit|rsv3Bit) != 0 || (h.rsv1 && (!c.compress || h.opcode == continuationFrame || h.opcode >= CloseMessage)) {
+		return h, c.fail(CloseProtocolError, "unexpected reserved bits")
+	}
+	switch h.opcode {
+	case continuationFrame, TextMessage, BinaryMessage, CloseMessage, PingMessage, PongMessage:
+	default:
+		return h, c.fail(CloseProtocolError, "unknown opcode")
+	}
+	if h.opcode >= CloseMessage && (!h.final || h.length > maxControlPayload) {
+		return h, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if h.masked != c.server {
+		return h, c.fail(CloseProtocolError, "invalid masking")
+	}
+
+	switch h.length {
+	case 126:
+		if _, err = io.ReadFull(c.br, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err = io.ReadFull(c.br, b[:8]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint64(b[:8]))
+		if h.length < 0 {
+			return h, c.fail(CloseProtocolError, "invalid length")
+		}
+	}
+
+	if h.masked {
+		if _, err = io.ReadFull(c.br, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+
+	return h, nil
+}
+
+func (c *Conn) readPayload(h header) ([]byte, error) {
+	if h.length > c.readLimit {
+		_ = c.fail(CloseMessageTooBig, "")
+		return nil, ErrReadLimit
+	}
+
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return nil, err
+	}
+	if h.masked {
+		maskBytes(h.mask, payload)
+	}
+	return payload, nil
+}
+
+// Must be called under write lock.
+func (c *Conn) writeFrame(opcode int, rsv1 bool, payload []byte) error {
+	var buf [14]byte
+	buf[0] = byte(opcode) | finalBit
+	if rsv1 {
+		buf[0] |= rsv1Bit
+	}
+
+	n := 2
+	length := len(payload)
+	switch {
+	case length <= 125:
+		buf[1] = byte(length)
+	case length <= 0xffff:
+		buf[1] = 126
+		binary.BigEndian.PutUint16(buf[2:], uint16(length))
+		n += 2
+	default:
+		buf[1] = 127
+		binary.BigEndian.PutUint64(buf[2:], uint64(length))
+		n += 8
+	}
+
+	if !c.server {
+		// Client frames must be masked
+		var mask [4]byte
+		binary.BigEndian.PutUint32(mask[:], rand.Uint32())
+		buf[1] |= maskBit
+		copy(buf[n:], mask[:])
+		n += 4
+		masked := make([]byte, length)
+		copy(masked, payload)
+		maskBytes(mask, masked)
+		payload = masked
+	}
+
+	if _, err := c.conn.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+func maskBytes(mask [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i&3]
+	}
+}
+
+// Tail of flush block, that is removed from compressed messages.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+func (c *Conn) deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+func (c *Conn) inflate(data []byte) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(
+		bytes.NewReader(data),
+		bytes.NewReader(deflateTail),
+	))
+	defer r.Close()
+
+	res, err := ioutil.ReadAll(io.LimitReader(r, c.readLimit+1))
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if int64(len(res)) > c.readLimit {
+		return nil, ErrReadLimit
+	}
+	return res, nil
+}
+
+func isValidCloseCode(code int) bool {
+	switch code {
+	case CloseNoStatusReceived, CloseAbnormalClosure, 1004, 1015:
+		return false
+	}
+	return (code >= 1000 && code <= 1014) || (code >= 3000 && code <= 4999)
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, server bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &Conn{
+		conn:      conn,
+		br:        br,
+		server:    server,
+		level:     flate.BestSpeed,
+		readLimit: defaultReadLimit,
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/adverax/echo/event"
+)
+
+type HubOptions struct {
+	Buffer       int           // Capacity of client queue (default 64)
+	WriteTimeout time.Duration // Timeout of writing message (default 10s)
+	PingInterval time.Duration // Interval of pings (default 30s)
+}
+
+type outgoing struct {
+	messageType int
+	data        []byte
+}
+
+// Client is connection, registered in hub.
+type Client struct {
+	hub   *Hub
+	conn  *Conn
+	send  chan outgoing
+	rooms map[string]struct{} // Guarded by hub
+	done  chan struct{}
+	once  sync.Once
+	// Close frame, sent by writer after done
+	code   int
+	reason string
+}
+
+// Conn returns connection of the client.
+func (client *Client) Conn() *Conn {
+	return client.conn
+}
+
+// Join client to the room.
+func (client *Client) Join(room string) {
+	hub := client.hub
+	hub.mx.Lock()
+	defer hub.mx.Unlock()
+
+	if _, ok := hub.clients[client]; !ok {
+		return
+	}
+
+	members, ok := hub.rooms[room]
+	if !ok {
+		members = make(map[*Client]struct{}, 16)
+		hub.rooms[room] = members
+	}
+	members[client] = struct{}{}
+	client.rooms[room] = struct{}{}
+}
+
+// Leave the room.
+func (client *Client) Leave(room string) {
+	hub := client.hub
+	hub.mx.Lock()
+	defer hub.mx.Unlock()
+
+	hub.leave(client, room)
+}
+
+// Send message to the client without waiting.
+// Slow client, that overflows own queue, is disconnected.
+func (client *Client) Send(messageType int, data []byte) {
+	select {
+	case <-client.done:
+	case client.send <- outgoing{messageType: messageType, data: data}:
+	default:
+		client.close(CloseTryAgainLater, "client is too slow")
+	}
+}
+
+// Close disconnects client.
+func (client *Client) Close() {
+	client.close(CloseNormalClosure, "")
+}
+
+// Connection is closed by writer, so caller never waits for writing
+// to the slow client.
+func (client *Client) close(code int, reason string) {
+	client.once.Do(func() {
+		client.hub.unregister(client)
+		client.code = code
+		client.reason = reason
+		close(client.done)
+	})
+}
+
+// Write messages from queue and send pings.
+func (client *Client) writer() {
+	options := client.hub.options
+	ticker := time.NewTicker(options.PingInterval)
+	defer ticker.Stop()
+	defer func() {
+		_ = client.conn.Close(client.code, client.reason)
+	}()
+
+	for {
+		select {
+		case <-client.done:
+			return
+		case msg := <-client.send:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(options.WriteTimeout))
+			if err := client.conn.WriteMessage(msg.messageType, msg.data); err != nil {
+				client.close(CloseGoingAway, "")
+				return
+			}
+		case <-ticker.C:
+			if err := client.conn.WriteControl(PingMessage, nil, time.Now().Add(options.WriteTimeout)); err != nil {
+				client.close(CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+// Hub is registry of the connections, grouped by rooms.
+type Hub struct {
+	mx      sync.RWMutex
+	options HubOptions
+	clients map[*Client]struct{}
+	rooms   map[string]map[*Client]struct{}
+}
+
+// Register connection in hub.
+func (hub *Hub) Register(conn *Conn) *Client {
+	client := &Client{
+		hub:   hub,
+		conn:  conn,
+		send:  make(chan outgoing, hub.options.Buffer),
+		rooms: make(map[string]struct{}, 4),
+		done:  make(chan struct{}),
+	}
+
+	hub.mx.Lock()
+	hub.clients[client] = struct{}{}
+	hub.mx.Unlock()
+
+	go client.writer()
+	return client
+}
+
+// Serve registers connection and reads messages until connection is closed.
+// Handler is called for each received message.
+func (hub *Hub) Serve(
+	conn *Conn,
+	handler func(client *Client, messageType int, data []byte) error,
+) error {
+	client := hub.Register(conn)
+	defer client.Close()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-client.done:
+				// Disconnected by hub
+				return nil
+			default:
+				return err
+			}
+		}
+
+		if handler != nil {
+			err = handler(client, messageType, data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Broadcast message to all members of room.
+// Empty room means all clients of hub.
+func (hub *Hub) Broadcast(room string, messageType int, data []byte) {
+	for _, client := range hub.members(room) {
+		client.Send(messageType, data)
+	}
+}
+
+// BroadcastJSON sends value, encoded as JSON, to all members of room.
+func (hub *Hub) BroadcastJSON(room string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	hub.Broadcast(room, TextMessage, data)
+	return nil
+}
+
+// Count of clients in the room.
+func (hub *Hub) Count(room string) int {
+	hub.mx.RLock()
+	defer hub.mx.RUnlock()
+
+	if room == "" {
+		return len(hub.clients)
+	}
+	return len(hub.rooms[room])
+}
+
+// Message is event, sent to clients by Bridge.
+type Message struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// Bridge subscribes hub to events, matched with pattern, of registrar.
+// Each event is sent as Message to the room, returned by function room
+// (nil function means all clients).
+func (hub *Hub) Bridge(
+	registrar event.Registrar,
+	pattern string,
+	room func(name string, data interface{}) string,
+) event.Subscription {
+	return registrar.On(pattern, func(ctx context.Context, data interface{}) error {
+		name := event.Name(ctx)
+		target := ""
+		if room != nil {
+			target = room(name, data)
+		}
+		return hub.BroadcastJSON(target, Message{Event: name, Data: data})
+	})
+}
+
+// Close disconnects all clients.
+func (hub *Hub) Close() {
+	for _, client := range hub.members("") {
+		client.close(CloseGoingAway, "")
+	}
+}
+
+func (hub *Hub) members(room string) []*Client {
+	hub.mx.RLock()
+	defer hub.mx.RUnlock()
+
+	list := hub.clients
+	if room != "" {
+		list = hub.rooms[room]
+	}
+
+	res := make([]*Client, 0, len(list))
+	for client := range list {
+		res = append(res, client)
+	}
+	return res
+}
+
+func (hub *Hub) unregister(client *Client) {
+	hub.mx.Lock()
+	defer hub.mx.Unlock()
+
+	for room := range client.rooms {
+		hub.leave(client, room)
+	}
+	delete(hub.clients, client)
+}
+
+func (hub *Hub) leave(client *Client, room string) {
+	delete(client.rooms, room)
+	if members, ok := hub.rooms[room]; ok {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(hub.rooms, room)
+		}
+	}
+}
+
+// NewHub creates hub of connections.
+func NewHub(options HubOptions) *Hub {
+	if options.Buffer <= 0 {
+		options.Buffer = 64
+	}
+	if options.WriteTimeout <= 0 {
+		options.WriteTimeout = 10 * time.Second
+	}
+	if options.PingInterval <= 0 {
+		options.PingInterval = 30 * time.Second
+	}
+
+	return &Hub{
+		options: options,
+		clients: make(map[*Client]struct{}, 64),
+		rooms:   make(map[string]map[*Client]struct{}, 16),
+	}
+}
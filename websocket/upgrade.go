@@ -0,0 +1,197 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adverax/echo"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrader upgrades HTTP connections to WebSocket.
+type Upgrader struct {
+	// Supported subprotocols in order of preference.
+	Subprotocols []string
+	// Enable per-message compression, if client supports it.
+	EnableCompression bool
+	// Check origin of request. By default origin must match the host.
+	CheckOrigin func(r *http.Request) bool
+	// Timeout of writing handshake response.
+	HandshakeTimeout time.Duration
+}
+
+// Upgrade upgrades HTTP connection of the context to WebSocket.
+// On failure, error is HTTP error, that can be returned from handler.
+func (u *Upgrader) Upgrade(ctx echo.Context) (*Conn, error) {
+	r := ctx.Request()
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, echo.HeaderUpgrade, "websocket") {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "websocket: not a websocket handshake")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		ctx.Response().Header().Set("Sec-Websocket-Version", "13")
+		return nil, echo.NewHTTPError(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+
+	key := r.Header.Get("Sec-Websocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "websocket: invalid key")
+	}
+
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "websocket: origin is not allowed")
+	}
+
+	subprotocol := u.selectSubprotocol(r)
+	compress := u.EnableCompression && acceptsCompression(r)
+
+	netConn, brw, err := ctx.Response().Hijack()
+	if err != nil {
+		return nil, err
+	}
+	ctx.Response().Committed = true
+
+	var buf strings.Builder
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	buf.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	buf.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		buf.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		buf.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	if u.HandshakeTimeout > 0 {
+		_ = netConn.SetWriteDeadline(time.Now().Add(u.HandshakeTimeout))
+	}
+	if _, err = netConn.Write([]byte(buf.String())); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	_ = netConn.SetWriteDeadline(time.Time{})
+
+	var br *bufio.Reader
+	if brw.Reader.Buffered() > 0 {
+		br = brw.Reader
+	}
+
+	conn := newConn(netConn, br, true)
+	conn.subprotocol = subprotocol
+	conn.compress = compress
+	return conn, nil
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	for _, offered := range headerTokens(r.Header, "Sec-Websocket-Protocol") {
+		for _, supported := range u.Subprotocols {
+			if offered == supported {
+				return supported
+			}
+		}
+	}
+	return ""
+}
+
+// Handler creates handler, that upgrades connection and passes it to fn.
+// Connection is closed after fn returns.
+func Handler(
+	upgrader *Upgrader,
+	fn func(ctx echo.Context, conn *Conn) error,
+) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		conn, err := upgrader.Upgrade(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Response is hijacked, so errors can not be sent to client
+		code := CloseNormalClosure
+		err = fn(ctx, conn)
+		if err != nil && err != io.EOF && !IsCloseError(err, CloseNormalClosure, CloseGoingAway, CloseNoStatusReceived) {
+			ctx.Logger().Error(err)
+			code = CloseInternalServerErr
+		}
+		_ = conn.Close(code, "")
+		return nil
+	}
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptsCompression(r *http.Request) bool {
+	for _, ext := range headerTokens(r.Header, "Sec-Websocket-Extensions") {
+		if strings.TrimSpace(strings.SplitN(ext, ";", 2)[0]) == "permessage-deflate" {
+			return true
+		}
+	}
+	return false
+}
+
+// Get comma separated tokens of header.
+func headerTokens(header http.Header, name string) []string {
+	var res []string
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				res = append(res, token)
+			}
+		}
+	}
+	return res
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example of RFC 6455
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestConn(t *testing.T) {
+	type Test struct {
+		compress bool
+		frames   [][]byte // Raw frames, written by client
+		typ      int
+		data     string
+		code     int // Expected close code
+	}
+
+	tests := map[string]Test{
+		"Text": {
+			frames: [][]byte{{0x81, 0x85, 0, 0, 0, 0, 'h', 'e', 'l', 'l', 'o'}},
+			typ:    TextMessage,
+			data:   "hello",
+		},
+		"Fragmented with ping": {
+			frames: [][]byte{
+				{0x01, 0x82, 0, 0, 0, 0, 'h', 'e'},
+				{0x89, 0x80, 0, 0, 0, 0},
+				{0x80, 0x83, 0, 0, 0, 0, 'l', 'l', 'o'},
+			},
+			typ:  TextMessage,
+			data: "hello",
+		},
+		"Compressed": {
+			compress: true,
+			frames:   [][]byte{{0xc1, 0x87, 0, 0, 0, 0, 0xf2, 0x48, 0xcd, 0xc9, 0xc9, 0x07, 0x00}},
+			typ:      TextMessage,
+			data:     "Hello",
+		},
+		"Unmasked": {
+			frames: [][]byte{{0x81, 0x01, 'a'}},
+			code:   CloseProtocolError,
+		},
+		"Unexpected continuation": {
+			frames: [][]byte{{0x80, 0x81, 0, 0, 0, 0, 'a'}},
+			code:   CloseProtocolError,
+		},
+		"Reserved bits": {
+			frames: [][]byte{{0xc1, 0x81, 0, 0, 0, 0, 'a'}},
+			code:   CloseProtocolError,
+		},
+		"Invalid utf8": {
+			frames: [][]byte{{0x81, 0x81, 0, 0, 0, 0, 0xff}},
+			code:   CloseInvalidFramePayloadData,
+		},
+		"Close": {
+			frames: [][]byte{{0x88, 0x84, 0, 0, 0, 0, 0x03, 0xe8, 'o', 'k'}},
+			code:   CloseNormalClosure,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, c := net.Pipe()
+			defer s.Close()
+			defer c.Close()
+
+			server := newConn(s, nil, true)
+			server.compress = test.compress
+			go func() {
+				for _, frame := range test.frames {
+					if _, err := c.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+			// Drain responses of server (pongs and close frames)
+			go func() {
+				buf := make([]byte, 256)
+				for {
+					if _, err := c.Read(buf); err != nil {
+						return
+					}
+				}
+			}()
+
+			typ, data, err := server.ReadMessage()
+			if test.code != 0 {
+				assert.True(t, IsCloseError(err, test.code), "unexpected error %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.typ, typ)
+			assert.Equal(t, test.data, string(data))
+		})
+	}
+}
+
+func TestConn_WriteMessage(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		s, c := net.Pipe()
+		server := newConn(s, nil, true)
+		client := newConn(c, nil, false)
+		server.compress = compress
+		client.compress = compress
+
+		long := strings.Repeat("abc", 30000)
+		go func() {
+			_ = client.WriteMessage(TextMessage, []byte("hi"))
+			_ = client.WriteMessage(BinaryMessage, []byte(long))
+		}()
+
+		typ, data, err := server.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, TextMessage, typ)
+		assert.Equal(t, "hi", string(data))
+
+		typ, data, err = server.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, BinaryMessage, typ)
+		assert.Equal(t, long, string(data))
+
+		server.SetReadLimit(10)
+		go func() {
+			_ = client.WriteMessage(TextMessage, []byte(long))
+		}()
+		go func() {
+			_, _, _ = client.ReadMessage()
+		}()
+		_, _, err = server.ReadMessage()
+		assert.Equal(t, ErrReadLimit, err)
+
+		_ = s.Close()
+		_ = c.Close()
+	}
+}
+
+func dial(t *testing.T, host, path string, compress bool) *Conn {
+	conn, err := net.Dial("tcp", host)
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+host+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Protocol", "chat, json")
+	if compress {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_max_window_bits")
+	}
+	require.NoError(t, req.Write(conn))
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "json", res.Header.Get("Sec-WebSocket-Protocol"))
+
+	client := newConn(conn, br, false)
+	client.compress = strings.HasPrefix(res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	assert.Equal(t, compress, client.compress)
+	return client
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub(HubOptions{})
+	defer hub.Close()
+
+	pub := event.New()
+	defer pub.Close()
+	hub.Bridge(pub, "chat.*", func(name string, data interface{}) string {
+		return name
+	})
+
+	upgrader := &Upgrader{
+		Subprotocols:      []string{"json"},
+		EnableCompression: true,
+	}
+
+	e := echo.New()
+	e.Router().Get("/ws", Handler(upgrader, func(ctx echo.Context, conn *Conn) error {
+		return hub.Serve(conn, func(client *Client, messageType int, data []byte) error {
+			client.Join(string(data))
+			client.Send(TextMessage, []byte("joined "+string(data)))
+			return nil
+		})
+	}))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	// Plain request is rejected
+	res, err := http.Get(srv.URL + "/ws")
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	alice := dial(t, srv.Listener.Addr().String(), "/ws", true)
+	bob := dial(t, srv.Listener.Addr().String(), "/ws", false)
+
+	require.NoError(t, alice.WriteMessage(TextMessage, []byte("chat.news")))
+	_, data, err := alice.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "joined chat.news", string(data))
+
+	require.NoError(t, bob.WriteMessage(TextMessage, []byte("chat.sport")))
+	_, data, err = bob.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "joined chat.sport", string(data))
+
+	require.NoError(t, pub.Trigger(context.Background(), "chat.news", "hello"))
+	_, data, err = alice.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, `{"event":"chat.news","data":"hello"}`, string(data))
+
+	hub.Broadcast("", TextMessage, []byte("all"))
+	for _, client := range []*Conn{alice, bob} {
+		_, data, err = client.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "all", string(data))
+	}
+
+	require.NoError(t, bob.Close(CloseNormalClosure, "bye"))
+	for i := 0; i < 100 && hub.Count("") != 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, hub.Count(""))
+	assert.Equal(t, 0, hub.Count("chat.sport"))
+
+	hub.Close()
+	_, _, err = alice.ReadMessage()
+	assert.True(t, IsCloseError(err, CloseGoingAway), "unexpected error %v", err)
+}
+
+func TestHub_SlowClient(t *testing.T) {
+	hub := NewHub(HubOptions{Buffer: 1, WriteTimeout: time.Hour})
+	defer hub.Close()
+
+	s, c := net.Pipe()
+	defer c.Close()
+	client := newConn(c, nil, false)
+	hub.Register(newConn(s, nil, true))
+
+	// Writer is blocked by the first message, because nobody reads the pipe
+	hub.Broadcast("", TextMessage, []byte("first"))
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			hub.Broadcast("", TextMessage, []byte("next"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast is blocked by slow client")
+	}
+	assert.Equal(t, 0, hub.Count(""))
+
+	_, data, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+	for err == nil {
+		_, _, err = client.ReadMessage()
+	}
+	assert.True(t, IsCloseError(err, CloseTryAgainLater), "unexpected error %v", err)
+}
@@ -18,6 +18,8 @@
 package widget
 
 import (
+	"io"
+
 	"github.com/adverax/echo"
 )
 
@@ -126,3 +128,56 @@ func (w *Action) Render(
 
 	return res, nil
 }
+
+func (w *Action) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	switch {
+	case w.Post:
+		hw.raw("\n<form method=\"post\" action=\"")
+		hw.link(m["Action"])
+		hw.raw(`"><button type="submit"`)
+		writeActionAttrs(hw, m)
+		hw.raw(">")
+		hw.value(m["Label"])
+		hw.raw("</button></form>")
+	case htmlString(m["Action"]) != "":
+		if w.Disabled {
+			hw.raw("\n<span class=\"disabled\">")
+			hw.value(m["Label"])
+			hw.raw("</span>")
+			break
+		}
+		hw.raw("\n<a")
+		hw.href("href", m["Action"])
+		hw.attr("title", m["Tooltip"])
+		hw.confirm(m["Confirm"])
+		hw.raw(">")
+		hw.value(m["Label"])
+		hw.raw("</a>")
+	default:
+		hw.raw("\n<button")
+		hw.attr("type", m["Type"])
+		writeActionAttrs(hw, m)
+		hw.raw(">")
+		hw.value(m["Label"])
+		hw.raw("</button>")
+	}
+	return hw.err
+}
+
+func writeActionAttrs(hw *htmlWriter, m map[string]interface{}) {
+	hw.attr("name", m["Name"])
+	hw.attr("value", m["Value"])
+	hw.attr("title", m["Tooltip"])
+	hw.confirm(m["Confirm"])
+	hw.flag("disabled", m["Disabled"])
+}
@@ -18,6 +18,9 @@
 package widget
 
 import (
+	"html"
+	"io"
+
 	"github.com/adverax/echo"
 )
 
@@ -65,3 +68,27 @@ func (w *Alert) Render(
 
 	return res, nil
 }
+
+func (w *Alert) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+
+	tp := w.Type
+	switch tp {
+	case "":
+		tp = AlertInfo
+	case AlertSuccess:
+		tp = "success"
+	}
+
+	hw := &htmlWriter{w: out}
+	hw.printf(`%s<p class="alert alert-%s" role="alert">`, "\n", html.EscapeString(string(tp)))
+	hw.widget(ctx, mapOf(res)["Message"])
+	hw.raw("</p>")
+	return hw.err
+}
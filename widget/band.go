@@ -18,6 +18,8 @@
 package widget
 
 import (
+	"io"
+
 	"github.com/adverax/echo"
 )
 
@@ -84,3 +86,22 @@ func (w *Band) renderItems(
 
 	return res, nil
 }
+
+func (w *Band) RenderHTML(ctx echo.Context, out io.Writer) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<ul class=\"band\">")
+	for _, item := range m["Items"].([]interface{}) {
+		hw.raw("\n<li>")
+		hw.value(item)
+		hw.raw("</li>")
+	}
+	hw.raw("\n</ul>")
+	writePager(hw, mapOf(m["Pager"]))
+	return hw.err
+}
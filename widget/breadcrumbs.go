@@ -18,6 +18,8 @@
 package widget
 
 import (
+	"io"
+
 	"github.com/adverax/echo"
 )
 
@@ -64,3 +66,39 @@ func (w Breadcrumbs) Render(
 
 	return res, nil
 }
+
+func (w Breadcrumbs) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil {
+		return err
+	}
+	items := res.([]interface{})
+	if len(items) == 0 {
+		return nil
+	}
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<nav class=\"breadcrumbs\">\n")
+	for i, v := range items {
+		item := mapOf(v)
+		if i != 0 {
+			hw.raw(" / ")
+		}
+		if action, ok := item["Action"]; ok && action != "" {
+			hw.raw(`<a href="`)
+			hw.link(action)
+			hw.raw(`">`)
+			hw.value(item["Label"])
+			hw.raw("</a>")
+		} else {
+			hw.raw(`<span aria-current="page">`)
+			hw.value(item["Label"])
+			hw.raw("</span>")
+		}
+	}
+	hw.raw("\n</nav>")
+	return hw.err
+}
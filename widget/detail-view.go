@@ -18,6 +18,8 @@
 package widget
 
 import (
+	"io"
+
 	"github.com/adverax/echo"
 )
 
@@ -127,3 +129,35 @@ func (w *Detail) Render(
 
 	return res, nil
 }
+
+func (w *DetailView) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+	head := mapOf(m["Head"])
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<table class=\"details\">\n<thead>\n<tr><th>")
+	hw.value(head["Key"])
+	hw.raw("</th><th>")
+	hw.value(head["Value"])
+	hw.raw("</th></tr>\n</thead>\n<tbody>")
+	body := mapOf(m["Body"])
+	for _, key := range sortedKeys(body) {
+		row := mapOf(body[key])
+		hw.raw("\n<tr")
+		hw.attr("class", row["Type"])
+		hw.raw("><th>")
+		hw.value(row["Label"])
+		hw.raw("</th><td>")
+		hw.value(row["Value"])
+		hw.raw("</td></tr>")
+	}
+	hw.raw("\n</tbody>\n</table>")
+	return hw.err
+}
@@ -1155,3 +1155,324 @@ func intersect(as, bs []string) []string {
 
 	return cs
 }
+
+func (w *Form) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	if w.Hidden {
+		return nil
+	}
+
+	method := w.Method
+	if method == "" {
+		method = echo.POST
+	}
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<form")
+	hw.attr("method", method)
+	if w.Action != nil {
+		action, err := RenderLink(ctx, w.Action)
+		if err != nil {
+			return err
+		}
+		hw.href("action", action)
+	}
+	hw.attr("id", w.Id)
+	hw.attr("name", w.Name)
+	hw.raw(">")
+
+	// Fields are written in order of names
+	names := make([]string, 0, len(w.Model))
+	for name := range w.Model {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if field := w.Model[name]; field != nil {
+			hw.widget(ctx, field)
+		}
+	}
+
+	hw.raw("\n</form>")
+	return hw.err
+}
+
+func (w *FormText) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	writeFieldBegin(hw, "div", m)
+	writeFieldLabel(hw, m)
+	if w.Rows != 0 {
+		hw.raw("\n<textarea")
+		hw.attr("rows", m["Rows"])
+		writeFieldAttrs(hw, m)
+		hw.attr("placeholder", m["Placeholder"])
+		hw.attr("maxlength", m["MaxLen"])
+		hw.raw(">")
+		hw.value(m["Value"])
+		hw.raw("</textarea>")
+	} else {
+		hw.raw("\n<input type=\"text\"")
+		writeFieldAttrs(hw, m)
+		hw.attr("placeholder", m["Placeholder"])
+		hw.attr("maxlength", m["MaxLen"])
+		hw.attr("pattern", m["Pattern"])
+		hw.attr("value", m["Value"])
+		hw.raw(">")
+	}
+	writeFieldErrors(hw, m)
+	hw.raw("\n</div>")
+	return hw.err
+}
+
+func (w *FormSelect) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	writeFieldBegin(hw, "div", m)
+	writeFieldLabel(hw, m)
+	hw.raw("\n<select")
+	writeFieldAttrs(hw, m)
+	hw.raw(">")
+	if empty := mapOf(m["Empty"]); empty != nil {
+		hw.raw("\n<option value=\"\"")
+		hw.flag("selected", empty["Selected"])
+		hw.raw(">")
+		hw.value(empty["Label"])
+		hw.raw("</option>")
+	}
+	items, _ := m["Items"].([]interface{})
+	for _, v := range items {
+		item := mapOf(v)
+		hw.raw("\n<option value=\"")
+		hw.value(item["Value"])
+		hw.raw(`"`)
+		hw.flag("selected", item["Selected"])
+		hw.raw(">")
+		hw.value(item["Label"])
+		hw.raw("</option>")
+	}
+	hw.raw("\n</select>")
+	writeFieldErrors(hw, m)
+	hw.raw("\n</div>")
+	return hw.err
+}
+
+func (w *FormFlag) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	writeFieldBegin(hw, "div", m)
+	hw.raw("\n<label><input type=\"checkbox\"")
+	hw.attr("value", m["Value"])
+	writeFieldAttrs(hw, m)
+	hw.flag("checked", m["Selected"])
+	hw.raw("> ")
+	hw.value(m["Label"])
+	hw.raw("</label>")
+	writeFieldHint(hw, m)
+	writeFieldErrors(hw, m)
+	hw.raw("\n</div>")
+	return hw.err
+}
+
+func (w *FormFlags) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	writeFieldBegin(hw, "fieldset", m)
+	if label, ok := m["Label"]; ok {
+		hw.raw("\n<legend>")
+		hw.value(label)
+		writeFieldRequired(hw, m)
+		hw.raw("</legend>")
+	}
+	items, _ := m["Items"].([]interface{})
+	for _, v := range items {
+		item := mapOf(v)
+		hw.raw("\n<label><input type=\"checkbox\" name=\"")
+		hw.value(m["Name"])
+		hw.raw("\" value=\"")
+		hw.value(item["Value"])
+		hw.raw(`"`)
+		hw.flag("checked", item["Selected"])
+		hw.flag("disabled", m["Disabled"])
+		hw.raw("> ")
+		hw.value(item["Label"])
+		hw.raw("</label>")
+	}
+	writeFieldHint(hw, m)
+	writeFieldErrors(hw, m)
+	hw.raw("\n</fieldset>")
+	return hw.err
+}
+
+func (w *FormSubmit) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<div class=\"buttons\">")
+	if items, ok := m["Items"].([]interface{}); ok && len(items) != 0 {
+		for _, v := range items {
+			item := mapOf(v)
+			hw.raw("\n<button type=\"submit\" name=\"")
+			hw.value(m["Name"])
+			hw.raw("\" value=\"")
+			hw.value(item["Value"])
+			hw.raw(`"`)
+			if item["Selected"] == true {
+				hw.raw(` class="default"`)
+			}
+			hw.flag("disabled", m["Disabled"])
+			hw.raw(">")
+			hw.value(item["Label"])
+			hw.raw("</button>")
+		}
+	} else {
+		hw.raw("\n<button type=\"submit\"")
+		writeFieldAttrs(hw, m)
+		hw.attr("value", m["Value"])
+		hw.raw(">")
+		hw.value(m["Label"])
+		hw.raw("</button>")
+	}
+	writeFieldErrors(hw, m)
+	hw.raw("\n</div>")
+	return hw.err
+}
+
+func (w *FormHidden) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<input type=\"hidden\"")
+	hw.attr("id", m["Id"])
+	hw.attr("name", m["Name"])
+	hw.attr("value", m["Value"])
+	hw.raw(">")
+	return hw.err
+}
+
+func (w *FormFile) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	writeFieldBegin(hw, "div", m)
+	writeFieldLabel(hw, m)
+	hw.raw("\n<input type=\"file\"")
+	writeFieldAttrs(hw, m)
+	hw.attr("accept", m["Accept"])
+	hw.raw(">")
+	writeFieldErrors(hw, m)
+	hw.raw("\n</div>")
+	return hw.err
+}
+
+func writeFieldBegin(hw *htmlWriter, tag string, m map[string]interface{}) {
+	if m["Errors"] != nil {
+		hw.printf("\n<%s class=\"field invalid\">", tag)
+	} else {
+		hw.printf("\n<%s class=\"field\">", tag)
+	}
+}
+
+func writeFieldAttrs(hw *htmlWriter, m map[string]interface{}) {
+	hw.attr("id", m["Id"])
+	hw.attr("name", m["Name"])
+	hw.flag("required", m["Required"])
+	hw.flag("readonly", m["Readonly"])
+	hw.flag("disabled", m["Disabled"])
+}
+
+func writeFieldRequired(hw *htmlWriter, m map[string]interface{}) {
+	if m["Required"] == true {
+		hw.raw(` <abbr class="required" title="required">*</abbr>`)
+	}
+}
+
+func writeFieldLabel(hw *htmlWriter, m map[string]interface{}) {
+	label, ok := m["Label"]
+	if !ok {
+		return
+	}
+	hw.raw("\n<label")
+	hw.attr("for", m["Id"])
+	hw.raw(">")
+	hw.value(label)
+	writeFieldRequired(hw, m)
+	hw.raw("</label>")
+}
+
+func writeFieldHint(hw *htmlWriter, m map[string]interface{}) {
+	if hint, ok := m["Placeholder"]; ok {
+		hw.raw("\n<small>")
+		hw.value(hint)
+		hw.raw("</small>")
+	}
+}
+
+func writeFieldErrors(hw *htmlWriter, m map[string]interface{}) {
+	errors, _ := m["Errors"].([]string)
+	if len(errors) == 0 {
+		return
+	}
+	hw.raw("\n<ul class=\"errors\">")
+	for _, err := range errors {
+		hw.raw("\n<li>")
+		hw.value(err)
+		hw.raw("</li>")
+	}
+	hw.raw("\n</ul>")
+}
@@ -0,0 +1,291 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package widget
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/generic"
+)
+
+// HTMLRenderer is optional interface of widget, that writes escaped html
+// directly without templates. Markup of bundled widgets is the same, as
+// markup of minimal theme of package design.
+type HTMLRenderer interface {
+	RenderHTML(ctx echo.Context, w io.Writer) error
+}
+
+// HTMLRenderFunc writes html representation of widget.
+type HTMLRenderFunc func(ctx echo.Context, w io.Writer, widget interface{}) error
+
+type htmlRegistry struct {
+	sync.RWMutex
+	renderers map[reflect.Type]HTMLRenderFunc
+}
+
+var htmlRenderers = &htmlRegistry{
+	renderers: make(map[reflect.Type]HTMLRenderFunc, 16),
+}
+
+func (registry *htmlRegistry) find(tp reflect.Type) (HTMLRenderFunc, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	renderer, ok := registry.renderers[tp]
+	return renderer, ok
+}
+
+// RegisterHTMLRenderer overrides html renderer for the type of widget.
+// Nil renderer restores default behavior.
+// Example:
+//   widget.RegisterHTMLRenderer(
+//       (*widget.Alert)(nil),
+//       func(ctx echo.Context, w io.Writer, widget interface{}) error {
+//           _, err := io.WriteString(w, "<div class=\"my-alert\">...</div>")
+//           return err
+//       },
+//   )
+func RegisterHTMLRenderer(widget interface{}, renderer HTMLRenderFunc) {
+	tp := reflect.TypeOf(widget)
+	htmlRenderers.Lock()
+	defer htmlRenderers.Unlock()
+	if renderer == nil {
+		delete(htmlRenderers.renderers, tp)
+		return
+	}
+	htmlRenderers.renderers[tp] = renderer
+}
+
+// WriteHTML writes html representation of widget.
+// Registered renderer is used first, after that HTMLRenderer of widget.
+// Other widgets are rendered and written as text.
+func WriteHTML(ctx echo.Context, w io.Writer, widget interface{}) error {
+	if widget == nil {
+		return nil
+	}
+
+	if renderer, ok := htmlRenderers.find(reflect.TypeOf(widget)); ok {
+		return renderer(ctx, w, widget)
+	}
+
+	if r, ok := widget.(HTMLRenderer); ok {
+		return r.RenderHTML(ctx, w)
+	}
+
+	val, err := echo.RenderWidget(ctx, widget)
+	if err != nil {
+		return err
+	}
+
+	hw := &htmlWriter{w: w}
+	hw.value(val)
+	return hw.err
+}
+
+// RenderHTML renders widget into html fragment.
+func RenderHTML(ctx echo.Context, widget interface{}) (template.HTML, error) {
+	var buf bytes.Buffer
+	err := WriteHTML(ctx, &buf, widget)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// Fragment is widget, that renders inner widget into html fragment.
+// It allows embed html of widget into template or other widget.
+type Fragment struct {
+	Widget interface{} // Inner widget
+}
+
+func (w *Fragment) Render(ctx echo.Context) (interface{}, error) {
+	return RenderHTML(ctx, w.Widget)
+}
+
+func (w *Fragment) RenderHTML(ctx echo.Context, out io.Writer) error {
+	return WriteHTML(ctx, out, w.Widget)
+}
+
+// Write widgets in order of keys.
+func (ws Map) RenderHTML(ctx echo.Context, w io.Writer) error {
+	keys := make([]string, 0, len(ws))
+	for key := range ws {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := WriteHTML(ctx, w, ws[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ws List) RenderHTML(ctx echo.Context, w io.Writer) error {
+	for _, widget := range ws {
+		err := WriteHTML(ctx, w, widget)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w HTML) RenderHTML(ctx echo.Context, out io.Writer) error {
+	_, err := io.WriteString(out, string(w))
+	return err
+}
+
+// Writer of html with sticky error.
+type htmlWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Write raw html.
+func (hw *htmlWriter) raw(s string) {
+	if hw.err == nil {
+		_, hw.err = io.WriteString(hw.w, s)
+	}
+}
+
+// Write formatted raw html. Arguments must be escaped.
+func (hw *htmlWriter) printf(format string, args ...interface{}) {
+	if hw.err == nil {
+		_, hw.err = fmt.Fprintf(hw.w, format, args...)
+	}
+}
+
+// Write rendered value as escaped text.
+func (hw *htmlWriter) value(v interface{}) {
+	switch val := v.(type) {
+	case nil:
+	case template.HTML:
+		hw.raw(string(val))
+	case []interface{}:
+		for _, item := range val {
+			hw.value(item)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(val) {
+			hw.value(val[key])
+		}
+	default:
+		hw.raw(html.EscapeString(htmlString(val)))
+	}
+}
+
+// Write url without attribute.
+func (hw *htmlWriter) link(v interface{}) {
+	hw.raw(html.EscapeString(safeURL(htmlString(v))))
+}
+
+// Write nested widget.
+func (hw *htmlWriter) widget(ctx echo.Context, widget interface{}) {
+	if hw.err == nil {
+		hw.err = WriteHTML(ctx, hw.w, widget)
+	}
+}
+
+// Write attribute, if value is not empty.
+func (hw *htmlWriter) attr(name string, v interface{}) {
+	if s := htmlString(v); s != "" {
+		hw.printf(` %s="%s"`, name, html.EscapeString(s))
+	}
+}
+
+// Write attribute with url, if value is not empty.
+func (hw *htmlWriter) href(name string, v interface{}) {
+	if s := htmlString(v); s != "" {
+		hw.printf(` %s="%s"`, name, html.EscapeString(safeURL(s)))
+	}
+}
+
+// Write boolean attribute.
+func (hw *htmlWriter) flag(name string, v interface{}) {
+	if on, _ := v.(bool); on {
+		hw.printf(" %s", name)
+	}
+}
+
+// Write confirmation handler, if text is not empty.
+func (hw *htmlWriter) confirm(v interface{}) {
+	if s := htmlString(v); s != "" {
+		hw.printf(
+			` onclick="return confirm(&#34;%s&#34;)"`,
+			html.EscapeString(template.JSEscapeString(s)),
+		)
+	}
+}
+
+func htmlString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case template.HTML:
+		return string(val)
+	default:
+		s, _ := generic.ConvertToString(val)
+		return s
+	}
+}
+
+// Filter unsafe urls in the same manner as html/template.
+func safeURL(s string) string {
+	if i := strings.IndexByte(s, ':'); i >= 0 && !strings.Contains(s[:i], "/") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "#ZgotmplZ"
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https", "mailto":
+		default:
+			return "#ZgotmplZ"
+		}
+	}
+	return s
+}
+
+func mapOf(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,307 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package widget
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTML(t *testing.T) {
+	type Test struct {
+		src interface{}
+		dst string
+	}
+
+	cities := echo.NewDataSet(
+		map[string]string{
+			"1": "London",
+			"2": "Paris",
+		},
+		true,
+	)
+
+	users := []string{"Bob", "Alice", "Tom", "Ann", "Sam"}
+	provider := &data.ArrayProvider{
+		Loader: func(ctx context.Context) (int, error) {
+			return len(users), nil
+		},
+	}
+	sortedProvider := &data.ArrayProvider{
+		Loader: func(ctx context.Context) (int, error) {
+			return len(users), nil
+		},
+	}
+
+	e := echo.New()
+	ctx := e.NewContext(httptest.NewRequest("GET", "/users?pg=2", nil), nil)
+
+	name := &FormText{
+		Id:          "name",
+		Name:        "Name",
+		Label:       "Name",
+		Placeholder: "Your name",
+		MaxLength:   32,
+		Required:    true,
+	}
+	_ = name.SetValue(ctx, []string{""})
+	_ = name.Validate(ctx)
+
+	city := &FormSelect{
+		Id:    "city",
+		Name:  "City",
+		Label: "City",
+		Items: cities,
+	}
+	_ = city.SetValue(ctx, []string{"2"})
+
+	tests := map[string]Test{
+		"Text": {
+			src: TEXT("<b>Bob</b>"),
+			dst: `&lt;b&gt;Bob&lt;/b&gt;`,
+		},
+		"Html": {
+			src: HTML("<b>Bob</b>"),
+			dst: `<b>Bob</b>`,
+		},
+		"Alert": {
+			src: &Alert{
+				Type:  AlertSuccess,
+				Label: "Saved",
+			},
+			dst: "\n<p class=\"alert alert-success\" role=\"alert\">Saved</p>",
+		},
+		"Action": {
+			src: &Action{
+				Label:   "Delete",
+				Action:  "/users/delete/1",
+				Confirm: "Are you sure?",
+				Post:    true,
+			},
+			dst: "\n<form method=\"post\" action=\"/users/delete/1\"><button type=\"submit\" onclick=\"return confirm(&#34;Are you sure?&#34;)\">Delete</button></form>",
+		},
+		"Action: unsafe url": {
+			src: &Action{
+				Label:  "Run",
+				Action: "javascript:alert(1)",
+			},
+			dst: "\n<a href=\"#ZgotmplZ\">Run</a>",
+		},
+		"Breadcrumbs": {
+			src: Breadcrumbs{
+				{Label: "Home", Action: "/"},
+				{Label: "Users", Action: "/users"},
+				{Label: "Bob"},
+			},
+			dst: "\n<nav class=\"breadcrumbs\">\n<a href=\"/\">Home</a> / <a href=\"/users\">Users</a> / <span aria-current=\"page\">Bob</span>\n</nav>",
+		},
+		"DetailView": {
+			src: &DetailView{
+				Items: Details{
+					"1": {Label: "Name", Value: "Bob"},
+					"2": {Label: "Email", Value: "bob@example.com", Type: "email"},
+				},
+			},
+			dst: "\n<table class=\"details\">\n<thead>\n<tr><th>Key</th><th>Value</th></tr>\n</thead>\n<tbody>\n<tr><th>Name</th><td>Bob</td></tr>\n<tr class=\"email\"><th>Email</th><td>bob@example.com</td></tr>\n</tbody>\n</table>",
+		},
+		"FormText": {
+			src: name,
+			dst: "\n<div class=\"field invalid\">\n<label for=\"name\">Name <abbr class=\"required\" title=\"required\">*</abbr></label>\n<input type=\"text\" id=\"name\" name=\"Name\" required placeholder=\"Your name\" maxlength=\"32\">\n<ul class=\"errors\">\n<li>Required value</li>\n</ul>\n</div>",
+		},
+		"FormSelect": {
+			src: city,
+			dst: "\n<div class=\"field\">\n<label for=\"city\">City</label>\n<select id=\"city\" name=\"City\">\n<option value=\"\">(Empty)</option>\n<option value=\"1\">London</option>\n<option value=\"2\" selected>Paris</option>\n</select>\n</div>",
+		},
+		"FormFlags": {
+			src: &FormFlags{
+				Name:    "Cities",
+				Label:   "Cities",
+				Items:   cities,
+				Default: []string{"1"},
+			},
+			dst: "\n<fieldset class=\"field\">\n<legend>Cities</legend>\n<label><input type=\"checkbox\" name=\"Cities\" value=\"1\" checked> London</label>\n<label><input type=\"checkbox\" name=\"Cities\" value=\"2\"> Paris</label>\n</fieldset>",
+		},
+		"Form": {
+			src: &Form{
+				Action: "/login",
+				Model: echo.Model{
+					"Login": &FormText{Name: "Login"},
+					"Token": &FormHidden{Name: "Token", Default: "abc"},
+				},
+			},
+			dst: "\n<form method=\"POST\" action=\"/login\">\n<div class=\"field\">\n<input type=\"text\" name=\"Login\">\n</div>\n<input type=\"hidden\" name=\"Token\" value=\"abc\">\n</form>",
+		},
+		"NavBar": {
+			src: &NavBar{
+				Brand: "Echo",
+				Items: Map{
+					"1": &NavBarItem{Label: "Home", Action: "/", Active: true},
+					"2": &NavBarDropDown{
+						Label: "Users",
+						Items: List{
+							&NavBarItem{Label: "List", Action: "/users"},
+							&NavBarItem{},
+							&NavBarItem{Label: "Create", Action: "/users/create"},
+						},
+					},
+					"3": &NavBarLink{Label: "Logout", Action: "/logout", Post: true},
+				},
+			},
+			dst: "\n<nav class=\"nav-bar\">\n<a class=\"brand\" href=\"/\">Echo</a>\n<ul>\n<li class=\"active\"><a href=\"/\">Home</a></li>\n<li>\n<details>\n<summary>Users</summary>\n<ul>\n<li><a href=\"/users\">List</a></li>\n<li role=\"separator\"></li>\n<li><a href=\"/users/create\">Create</a></li>\n</ul>\n</details>\n</li>\n<li>\n<form method=\"post\" action=\"/logout\"><button type=\"submit\">Logout</button></form>\n</li>\n</ul>\n</nav>",
+		},
+		"Table": {
+			src: &Table{
+				Pager: Pager{
+					Capacity: 2,
+					BtnCount: 3,
+					Url:      &url.URL{Path: "/users", RawQuery: "pg=2"},
+					Provider: provider,
+				},
+				Columns: TableColumns{
+					"1_name": {
+						Label: "Name",
+						Data: func() (interface{}, error) {
+							return users[provider.Index-1], nil
+						},
+					},
+					"2_actions": {
+						Data: func() (interface{}, error) {
+							return &TableActions{
+								RowId: provider.Index,
+								Path:  "/users",
+								Items: Map{
+									"View": DefaultTableActionView,
+								},
+							}, nil
+						},
+					},
+				},
+			},
+			dst: "\n<table>\n<thead>\n<tr>\n<th>Name</th>\n<th></th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>Bob</td>\n<td>\n<a href=\"/users/view/1\" title=\"View\">View</a></td>\n</tr>\n<tr>\n<td>Alice</td>\n<td>\n<a href=\"/users/view/2\" title=\"View\">View</a></td>\n</tr>\n</tbody>\n</table>\n<nav class=\"pager\">\n<span>Shows rows from 3 to 4 of 5</span>\n<a href=\"/users?pg=1\">Prev</a>\n<a href=\"/users?pg=1\">1</a>\n<strong aria-current=\"page\">2</strong>\n<a href=\"/users?pg=3\">3</a>\n<a href=\"/users?pg=3\">Next</a>\n</nav>",
+		},
+		"Table: sortable": {
+			src: &Table{
+				Pager: Pager{
+					Id:       "users",
+					Capacity: 2,
+					BtnCount: 3,
+					Url:      &url.URL{Path: "/users", RawQuery: "pg=2&sort=name&f_name=o&f_joined_from=2019-01-01"},
+					Provider: sortedProvider,
+				},
+				Columns: TableColumns{
+					"1_name": {
+						Label: "Name",
+						Sort:  "name",
+						Filter: &TableFilter{
+							Kind:        data.FilterText,
+							Key:         "name",
+							Placeholder: "Search",
+						},
+						Data: func() (interface{}, error) {
+							return users[sortedProvider.Index-1], nil
+						},
+					},
+					"2_city": {
+						Label: "City",
+						Filter: &TableFilter{
+							Kind:  data.FilterSelect,
+							Key:   "city",
+							Items: cities,
+						},
+						Data: func() (interface{}, error) {
+							return "London", nil
+						},
+					},
+					"3_joined": {
+						Label: "Joined",
+						Sort:  "joined",
+						Filter: &TableFilter{
+							Kind: data.FilterDateRange,
+							Key:  "joined",
+						},
+						Data: func() (interface{}, error) {
+							return "2019-03-01", nil
+						},
+					},
+					"4_actions": {
+						Data: func() (interface{}, error) {
+							return &TableActions{
+								RowId: sortedProvider.Index,
+								Path:  "/users",
+								Items: Map{
+									"View": DefaultTableActionView,
+								},
+							}, nil
+						},
+					},
+				},
+			},
+			dst: "\n<form id=\"users-filter\" class=\"filters\" method=\"get\" action=\"/users\">\n<input type=\"hidden\" name=\"sort\" value=\"name\">\n<button type=\"submit\">Filter</button>\n</form>\n<table>\n<thead>\n<tr>\n<th><a href=\"/users?f_joined_from=2019-01-01&amp;f_name=o&amp;sort=-name\" class=\"sorted-asc\">Name</a></th>\n<th>City</th>\n<th><a href=\"/users?f_joined_from=2019-01-01&amp;f_name=o&amp;sort=joined\">Joined</a></th>\n<th></th>\n</tr>\n<tr class=\"filters\">\n<td>\n<input type=\"text\" name=\"f_name\" value=\"o\" placeholder=\"Search\" form=\"users-filter\"></td>\n<td>\n<select name=\"f_city\" form=\"users-filter\">\n<option value=\"\" selected>(Empty)</option>\n<option value=\"1\">London</option>\n<option value=\"2\">Paris</option>\n</select></td>\n<td>\n<input type=\"date\" name=\"f_joined_from\" value=\"2019-01-01\" form=\"users-filter\">\n<input type=\"date\" name=\"f_joined_to\" value=\"\" form=\"users-filter\"></td>\n<td></td>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>Bob</td>\n<td>London</td>\n<td>2019-03-01</td>\n<td>\n<a href=\"/users/view/1\" title=\"View\">View</a></td>\n</tr>\n<tr>\n<td>Alice</td>\n<td>London</td>\n<td>2019-03-01</td>\n<td>\n<a href=\"/users/view/2\" title=\"View\">View</a></td>\n</tr>\n</tbody>\n</table>\n<nav class=\"pager\">\n<span>Shows rows from 3 to 4 of 5</span>\n<a href=\"/users?f_joined_from=2019-01-01&amp;f_name=o&amp;pg=1&amp;sort=name\">Prev</a>\n<a href=\"/users?f_joined_from=2019-01-01&amp;f_name=o&amp;pg=1&amp;sort=name\">1</a>\n<strong aria-current=\"page\">2</strong>\n<a href=\"/users?f_joined_from=2019-01-01&amp;f_name=o&amp;pg=3&amp;sort=name\">3</a>\n<a href=\"/users?f_joined_from=2019-01-01&amp;f_name=o&amp;pg=3&amp;sort=name\">Next</a>\n</nav>",
+		},
+		"Map": {
+			src: Map{
+				"2": TEXT("Alice"),
+				"1": HTML("<hr>"),
+			},
+			dst: "<hr>Alice",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteHTML(ctx, &buf, test.src)
+			require.NoError(t, err)
+			assert.Equal(t, test.dst, buf.String())
+		})
+	}
+}
+
+func TestRegisterHTMLRenderer(t *testing.T) {
+	RegisterHTMLRenderer(
+		(*Alert)(nil),
+		func(ctx echo.Context, w io.Writer, widget interface{}) error {
+			_, err := io.WriteString(w, "<blink>"+widget.(*Alert).Label.(string)+"</blink>")
+			return err
+		},
+	)
+	defer RegisterHTMLRenderer((*Alert)(nil), nil)
+
+	ctx := echo.New().NewContext(nil, nil)
+	res, err := echo.RenderWidget(
+		ctx,
+		&Fragment{
+			Widget: List{
+				&Alert{Label: "Saved"},
+				TEXT("&"),
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, template.HTML("<blink>Saved</blink>&amp;"), res)
+}
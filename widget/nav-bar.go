@@ -18,6 +18,8 @@
 package widget
 
 import (
+	"io"
+
 	"github.com/adverax/echo"
 )
 
@@ -248,3 +250,110 @@ func (w *NavBarForm) Render(ctx echo.Context) (interface{}, error) {
 
 	return result, nil
 }
+
+func (w *NavBar) RenderHTML(ctx echo.Context, out io.Writer) error {
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<nav class=\"nav-bar\">")
+	if w.Brand != nil {
+		hw.raw("\n<a class=\"brand\" href=\"/\">")
+		hw.widget(ctx, w.Brand)
+		hw.raw("</a>")
+	}
+	hw.raw("\n<ul>")
+	hw.widget(ctx, w.Items)
+	hw.raw("\n</ul>\n</nav>")
+	return hw.err
+}
+
+func (w *NavBarItem) RenderHTML(ctx echo.Context, out io.Writer) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	if m["Type"] == "separator" {
+		hw.raw("\n<li role=\"separator\"></li>")
+		return hw.err
+	}
+
+	hw.raw("\n<li")
+	if w.Active {
+		hw.raw(` class="active"`)
+	}
+	hw.raw("><a")
+	hw.href("href", m["Action"])
+	hw.raw(">")
+	hw.value(m["Label"])
+	hw.raw("</a></li>")
+	return hw.err
+}
+
+func (w *NavBarDropDown) RenderHTML(ctx echo.Context, out io.Writer) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<li>\n<details>\n<summary>")
+	hw.value(mapOf(res)["Label"])
+	hw.raw("</summary>\n<ul>")
+	hw.widget(ctx, w.Items)
+	hw.raw("\n</ul>\n</details>\n</li>")
+	return hw.err
+}
+
+func (w *NavBarText) RenderHTML(ctx echo.Context, out io.Writer) error {
+	if w.Hidden || w.Body == nil {
+		return nil
+	}
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<li>")
+	hw.widget(ctx, w.Body)
+	hw.raw("</li>")
+	return hw.err
+}
+
+func (w *NavBarLink) RenderHTML(ctx echo.Context, out io.Writer) error {
+	res, err := w.Render(ctx)
+	if err != nil || res == nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<li>")
+	if w.Post {
+		hw.raw("\n<form method=\"post\" action=\"")
+		hw.link(m["Action"])
+		hw.raw(`"><button type="submit"`)
+		hw.attr("title", m["Tooltip"])
+		hw.raw(">")
+		hw.value(m["Label"])
+		hw.raw("</button></form>")
+	} else {
+		hw.raw("\n<a")
+		hw.href("href", m["Action"])
+		hw.attr("title", m["Tooltip"])
+		hw.raw(">")
+		hw.value(m["Label"])
+		hw.raw("</a>")
+	}
+	hw.raw("\n</li>")
+	return hw.err
+}
+
+func (w *NavBarForm) RenderHTML(ctx echo.Context, out io.Writer) error {
+	if w.Hidden {
+		return nil
+	}
+
+	hw := &htmlWriter{w: out}
+	hw.raw("\n<li>")
+	hw.widget(ctx, &w.Form)
+	hw.raw("\n</li>")
+	return hw.err
+}
@@ -110,16 +110,8 @@ func (w *Pager) execute(
 		btnCount = 10
 	}
 
-	param := w.Param
-	if param == "" {
-		param = "pg"
-	}
-
-	uu := w.Url
-	if uu == nil {
-		uu = new(url.URL)
-		*uu = *ctx.Request().URL
-	}
+	param := w.param()
+	uu := w.url(ctx)
 
 	prev := w.Prev
 	if prev == nil {
@@ -297,6 +289,25 @@ func (w *Pager) execute(
 	}, nil
 }
 
+// Get name of parameter for page number.
+func (w *Pager) param() string {
+	if w.Param == "" {
+		return "pg"
+	}
+	return w.Param
+}
+
+// Get copy of base url.
+func (w *Pager) url(ctx echo.Context) *url.URL {
+	uu := new(url.URL)
+	if w.Url == nil {
+		*uu = *ctx.Request().URL
+	} else {
+		*uu = *w.Url
+	}
+	return uu
+}
+
 func (w *Pager) newBtn(
 	label interface{},
 	action interface{},
@@ -310,3 +321,40 @@ func (w *Pager) newBtn(
 	}
 	return btn
 }
+
+// Write html of rendered pager.
+func writePager(hw *htmlWriter, m map[string]interface{}) {
+	hw.raw("\n<nav class=\"pager\">\n<span>")
+	hw.value(m["Message"])
+	hw.raw("</span>")
+	if buttons := mapOf(m["Buttons"]); buttons != nil {
+		writePagerButton(hw, mapOf(buttons["Prev"]))
+		if band, ok := buttons["Band"].([]map[string]interface{}); ok {
+			for _, btn := range band {
+				writePagerButton(hw, btn)
+			}
+		}
+		writePagerButton(hw, mapOf(buttons["Next"]))
+	}
+	hw.raw("\n</nav>")
+}
+
+func writePagerButton(hw *htmlWriter, btn map[string]interface{}) {
+	switch {
+	case btn == nil:
+	case btn["Disabled"] == true:
+		hw.raw("\n<span class=\"disabled\">")
+		hw.value(btn["Label"])
+		hw.raw("</span>")
+	case btn["Active"] == true:
+		hw.raw("\n<strong aria-current=\"page\">")
+		hw.value(btn["Label"])
+		hw.raw("</strong>")
+	default:
+		hw.raw("\n<a")
+		hw.href("href", btn["Action"])
+		hw.raw(">")
+		hw.value(btn["Label"])
+		hw.raw("</a>")
+	}
+}
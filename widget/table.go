@@ -18,7 +18,14 @@
 package widget
 
 import (
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/adverax/echo"
+	"github.com/adverax/echo/data"
 	"github.com/adverax/echo/generic"
 )
 
@@ -28,6 +35,16 @@ type TableColumn struct {
 	Hidden   bool         // Column is hidden and can't be render
 	Data     DataFunc     // Column data provider
 	Expander ExpanderFunc // Column expander for generate extra info
+	Sort     string       // Key of sorting (optional). Column is sortable, if key is defined
+	Filter   *TableFilter // Filter of column (optional)
+}
+
+// TableFilter is filter of table column.
+type TableFilter struct {
+	Kind        data.FilterKind // Kind of filter (text, select or date range)
+	Key         string          // Key of filter (default key of column)
+	Items       echo.DataSet    // Items of select filter
+	Placeholder interface{}     // Placeholder of text filter (optional)
 }
 
 // Single table action
@@ -194,21 +211,52 @@ type TableColumns map[string]*TableColumn
 //           return nil
 //       },
 //   }
+//
+// Sorting and filters are read from parameters of URI and passed to
+// the data provider, that implements data.Sorter or data.Filterer.
+// Links of pager preserve them.
 type Table struct {
 	Pager
 	Columns     TableColumns // Columns declaration
 	RowExpander ExpanderFunc // Row expander
+	SortParam   string       // Name of parameter of URI for sorting (default "sort"). Value "-key" means descending order
+	FilterParam string       // Prefix of parameters of URI for filters (default "f_")
+}
+
+// State of sorting and filters, that is read from URI.
+type tableState struct {
+	url    *url.URL
+	params url.Values
+	sort   string // Key of active sorting
+	desc   bool   // Active sorting is descending
+	filter data.Filter
 }
 
 func (w *Table) Render(
 	ctx echo.Context,
 ) (interface{}, error) {
+	state := w.state(ctx)
+	if state.sort != "" {
+		if sorter, ok := w.Provider.(data.Sorter); ok {
+			sorting := data.SortAsc
+			if state.desc {
+				sorting = data.SortDesc
+			}
+			sorter.SetSort(data.Sort{state.sort: sorting})
+		}
+	}
+	if len(state.filter) != 0 {
+		if filterer, ok := w.Provider.(data.Filterer); ok {
+			filterer.SetFilter(state.filter)
+		}
+	}
+
 	pager, err := w.Pager.execute(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	head, err := w.renderHead(ctx)
+	head, err := w.renderHead(ctx, state)
 	if err != nil {
 		return nil, err
 	}
@@ -224,11 +272,126 @@ func (w *Table) Render(
 		"Pager": pager.render(ctx),
 	}
 
+	filters, err := w.renderFilters(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if filters != nil {
+		res["Filters"] = filters
+	}
+
 	return res, nil
 }
 
+func (w *Table) sortParam() string {
+	if w.SortParam == "" {
+		return "sort"
+	}
+	return w.SortParam
+}
+
+func (w *Table) filterParam() string {
+	if w.FilterParam == "" {
+		return "f_"
+	}
+	return w.FilterParam
+}
+
+func (w *Table) filterForm() string {
+	if w.Id == "" {
+		return "table-filter"
+	}
+	return w.Id + "-filter"
+}
+
+// Read state of sorting and filters from URI.
+func (w *Table) state(
+	ctx echo.Context,
+) *tableState {
+	uu := w.url(ctx)
+	state := &tableState{
+		url:    uu,
+		params: uu.Query(),
+		filter: make(data.Filter),
+	}
+
+	sort := state.params.Get(w.sortParam())
+	if strings.HasPrefix(sort, "-") {
+		sort = sort[1:]
+		state.desc = true
+	}
+	for _, col := range w.Columns {
+		if col != nil && !col.Hidden && col.Sort != "" && col.Sort == sort {
+			state.sort = sort
+		}
+	}
+
+	loc := time.UTC
+	if locale := ctx.Locale(); locale != nil && locale.Location() != nil {
+		loc = locale.Location()
+	}
+
+	for key, col := range w.Columns {
+		if col == nil || col.Hidden || col.Filter == nil {
+			continue
+		}
+
+		key, name := w.filterName(key, col.Filter)
+		switch col.Filter.Kind {
+		case data.FilterDateRange:
+			criterion := data.Criterion{Kind: data.FilterDateRange}
+			from, err := time.ParseInLocation(tableDateLayout, state.params.Get(name+"_from"), loc)
+			if err == nil {
+				criterion.From = from
+			}
+			to, err := time.ParseInLocation(tableDateLayout, state.params.Get(name+"_to"), loc)
+			if err == nil {
+				criterion.To = to.AddDate(0, 0, 1)
+			}
+			if !criterion.From.IsZero() || !criterion.To.IsZero() {
+				state.filter[key] = criterion
+			}
+		default:
+			if value := state.params.Get(name); value != "" {
+				state.filter[key] = data.Criterion{
+					Kind:  col.Filter.Kind,
+					Value: value,
+				}
+			}
+		}
+	}
+
+	return state
+}
+
+// Get key of filter and name of parameter.
+func (w *Table) filterName(
+	column string,
+	filter *TableFilter,
+) (key, name string) {
+	key = filter.Key
+	if key == "" {
+		key = column
+	}
+	return key, w.filterParam() + key
+}
+
+// Get link with parameters. The first page is used after change of
+// sorting or filters.
+func (w *Table) link(
+	ctx echo.Context,
+	state *tableState,
+	params url.Values,
+) (string, error) {
+	params.Del(w.param())
+	uu := *state.url
+	uu.RawQuery = params.Encode()
+	return RenderLink(ctx, &uu)
+}
+
 func (w *Table) renderHead(
 	ctx echo.Context,
+	state *tableState,
 ) (interface{}, error) {
 	cells := make(map[string]interface{}, len(w.Columns))
 
@@ -248,6 +411,33 @@ func (w *Table) renderHead(
 			cell["Label"] = label
 		}
 
+		if col.Sort != "" {
+			sort := col.Sort
+			if col.Sort == state.sort {
+				if state.desc {
+					cell["Sorted"] = "desc"
+				} else {
+					cell["Sorted"] = "asc"
+					sort = "-" + sort
+				}
+			}
+			params := cloneValues(state.params)
+			params.Set(w.sortParam(), sort)
+			action, err := w.link(ctx, state, params)
+			if err != nil {
+				return nil, err
+			}
+			cell["Action"] = action
+		}
+
+		if col.Filter != nil {
+			filter, err := w.renderFilter(ctx, state, key, col.Filter)
+			if err != nil {
+				return nil, err
+			}
+			cell["Filter"] = filter
+		}
+
 		if col.Expander != nil {
 			err := col.Expander(cell)
 			if err != nil {
@@ -318,3 +508,303 @@ func (w *Table) renderRow(
 
 	return res, nil
 }
+
+func (w *Table) RenderHTML(
+	ctx echo.Context,
+	out io.Writer,
+) error {
+	res, err := w.Render(ctx)
+	if err != nil {
+		return err
+	}
+	m := mapOf(res)
+
+	hw := &htmlWriter{w: out}
+	filters := mapOf(m["Filters"])
+	if filters != nil {
+		hw.raw("\n<form")
+		hw.attr("id", filters["Id"])
+		hw.raw(` class="filters" method="get"`)
+		hw.href("action", filters["Action"])
+		hw.raw(">")
+		params, _ := filters["Params"].([]interface{})
+		for _, v := range params {
+			param := mapOf(v)
+			hw.raw("\n<input type=\"hidden\"")
+			hw.attr("name", param["Name"])
+			hw.raw(` value="`)
+			hw.value(param["Value"])
+			hw.raw(`">`)
+		}
+		hw.raw("\n<button type=\"submit\">")
+		hw.value(filters["Submit"])
+		hw.raw("</button>\n</form>")
+	}
+	hw.raw("\n<table>\n<thead>\n<tr>")
+	head := mapOf(m["Head"])
+	for _, key := range sortedKeys(head) {
+		cell := mapOf(head[key])
+		hw.raw("\n<th")
+		hw.attr("class", cell["Class"])
+		hw.raw(">")
+		if cell["Action"] != nil {
+			hw.raw("<a")
+			hw.href("href", cell["Action"])
+			if sorted := htmlString(cell["Sorted"]); sorted != "" {
+				hw.attr("class", "sorted-"+sorted)
+			}
+			hw.raw(">")
+			hw.value(cell["Label"])
+			hw.raw("</a>")
+		} else {
+			hw.value(cell["Label"])
+		}
+		hw.raw("</th>")
+	}
+	hw.raw("\n</tr>")
+	if filters != nil {
+		hw.raw("\n<tr class=\"filters\">")
+		for _, key := range sortedKeys(head) {
+			hw.raw("\n<td>")
+			writeTableFilter(hw, mapOf(mapOf(head[key])["Filter"]))
+			hw.raw("</td>")
+		}
+		hw.raw("\n</tr>")
+	}
+	hw.raw("\n</thead>\n<tbody>")
+	for _, r := range m["Body"].([]interface{}) {
+		row := mapOf(r)
+		hw.raw("\n<tr")
+		hw.attr("class", row["Class"])
+		hw.raw(">")
+		cols := mapOf(row["Cols"])
+		for _, key := range sortedKeys(cols) {
+			hw.raw("\n<td>")
+			writeTableCell(hw, cols[key])
+			hw.raw("</td>")
+		}
+		hw.raw("\n</tr>")
+	}
+	hw.raw("\n</tbody>\n</table>")
+	writePager(hw, mapOf(m["Pager"]))
+	return hw.err
+}
+
+// Write cell of table. Map is treated as rendered TableActions.
+func writeTableCell(hw *htmlWriter, cell interface{}) {
+	actions, ok := cell.(map[string]interface{})
+	if !ok {
+		hw.value(cell)
+		return
+	}
+
+	for _, name := range sortedKeys(actions) {
+		action := mapOf(actions[name])
+		if action["Post"] == true {
+			hw.raw("\n<form method=\"post\" action=\"")
+			hw.link(action["Action"])
+			hw.raw(`"><button type="submit"`)
+			hw.attr("title", action["Tooltip"])
+			hw.confirm(action["Confirm"])
+			hw.raw(">")
+			hw.value(name)
+			hw.raw("</button></form>")
+		} else {
+			hw.raw("\n<a")
+			hw.href("href", action["Action"])
+			hw.attr("title", action["Tooltip"])
+			hw.confirm(action["Confirm"])
+			hw.raw(">")
+			hw.value(name)
+			hw.raw("</a>")
+		}
+	}
+}
+
+// Write input of column filter.
+func writeTableFilter(hw *htmlWriter, filter map[string]interface{}) {
+	if filter == nil {
+		return
+	}
+
+	switch filter["Kind"] {
+	case "select":
+		hw.raw("\n<select")
+		hw.attr("name", filter["Name"])
+		hw.attr("form", filter["Form"])
+		hw.raw(">")
+		if empty := mapOf(filter["Empty"]); empty != nil {
+			hw.raw("\n<option value=\"\"")
+			hw.flag("selected", empty["Selected"])
+			hw.raw(">")
+			hw.value(empty["Label"])
+			hw.raw("</option>")
+		}
+		items, _ := filter["Items"].([]interface{})
+		for _, v := range items {
+			item := mapOf(v)
+			hw.raw("\n<option value=\"")
+			hw.value(item["Value"])
+			hw.raw(`"`)
+			hw.flag("selected", item["Selected"])
+			hw.raw(">")
+			hw.value(item["Label"])
+			hw.raw("</option>")
+		}
+		hw.raw("\n</select>")
+	case "date-range":
+		for _, bound := range []string{"From", "To"} {
+			input := mapOf(filter[bound])
+			hw.raw("\n<input type=\"date\"")
+			hw.attr("name", input["Name"])
+			hw.raw(` value="`)
+			hw.value(input["Value"])
+			hw.raw(`"`)
+			hw.attr("form", filter["Form"])
+			hw.raw(">")
+		}
+	default:
+		hw.raw("\n<input type=\"text\"")
+		hw.attr("name", filter["Name"])
+		hw.attr("value", filter["Value"])
+		hw.attr("placeholder", filter["Placeholder"])
+		hw.attr("form", filter["Form"])
+		hw.raw(">")
+	}
+}
+
+const tableDateLayout = "2006-01-02"
+
+// Render input of column filter.
+func (w *Table) renderFilter(
+	ctx echo.Context,
+	state *tableState,
+	column string,
+	filter *TableFilter,
+) (map[string]interface{}, error) {
+	_, name := w.filterName(column, filter)
+
+	res := make(map[string]interface{}, 8)
+	res["Form"] = w.filterForm()
+
+	switch filter.Kind {
+	case data.FilterDateRange:
+		res["Kind"] = "date-range"
+		res["From"] = map[string]interface{}{
+			"Name":  name + "_from",
+			"Value": state.params.Get(name + "_from"),
+		}
+		res["To"] = map[string]interface{}{
+			"Name":  name + "_to",
+			"Value": state.params.Get(name + "_to"),
+		}
+	case data.FilterSelect:
+		value := state.params.Get(name)
+		res["Kind"] = "select"
+		res["Name"] = name
+		var selected map[string]bool
+		if value != "" {
+			selected = map[string]bool{value: true}
+		}
+		items, err := RenderDataSet(ctx, filter.Items, selected)
+		if err != nil {
+			return nil, err
+		}
+		if items != nil {
+			res["Items"] = items
+		}
+		label, err := ctx.Echo().Locale.Message(ctx, uint32(MessageSelectorEmpty))
+		if err != nil {
+			return nil, err
+		}
+		empty := map[string]interface{}{"Label": label}
+		if value == "" {
+			empty["Selected"] = true
+		}
+		res["Empty"] = empty
+	default:
+		res["Kind"] = "text"
+		res["Name"] = name
+		if value := state.params.Get(name); value != "" {
+			res["Value"] = value
+		}
+		if filter.Placeholder != nil {
+			placeholder, err := echo.RenderWidget(ctx, filter.Placeholder)
+			if err != nil {
+				return nil, err
+			}
+			res["Placeholder"] = placeholder
+		}
+	}
+
+	return res, nil
+}
+
+// Render form of filters. Form keeps other parameters of URI
+// (except page number) in hidden fields.
+func (w *Table) renderFilters(
+	ctx echo.Context,
+	state *tableState,
+) (map[string]interface{}, error) {
+	filters := make(map[string]bool, len(w.Columns))
+	for key, col := range w.Columns {
+		if col == nil || col.Hidden || col.Filter == nil {
+			continue
+		}
+		_, name := w.filterName(key, col.Filter)
+		if col.Filter.Kind == data.FilterDateRange {
+			filters[name+"_from"] = true
+			filters[name+"_to"] = true
+		} else {
+			filters[name] = true
+		}
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(state.params))
+	for name := range state.params {
+		if !filters[name] && name != w.param() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	params := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		for _, value := range state.params[name] {
+			params = append(params, map[string]interface{}{
+				"Name":  name,
+				"Value": value,
+			})
+		}
+	}
+
+	uu := *state.url
+	uu.RawQuery = ""
+	action, err := RenderLink(ctx, &uu)
+	if err != nil {
+		return nil, err
+	}
+
+	submit, err := ctx.Echo().Locale.Message(ctx, uint32(MessageTableFilterSubmit))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"Id":     w.filterForm(),
+		"Action": action,
+		"Params": params,
+		"Submit": submit,
+	}, nil
+}
+
+func cloneValues(values url.Values) url.Values {
+	res := make(url.Values, len(values))
+	for key, vs := range values {
+		res[key] = append([]string(nil), vs...)
+	}
+	return res
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 Adverax. All Rights Reserved.
+// This file is part of project
+//
+//      http://github.com/adverax/echo
+//
+// Licensed under the MIT (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      https://github.com/adverax/echo/blob/master/LICENSE
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package widget
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/adverax/echo"
+	"github.com/adverax/echo/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sortedProviderMock struct {
+	dataProviderMock
+	sort   data.Sort
+	filter data.Filter
+}
+
+func (provider *sortedProviderMock) SetSort(sort data.Sort) {
+	provider.sort = sort
+}
+
+func (provider *sortedProviderMock) SetFilter(filter data.Filter) {
+	provider.filter = filter
+}
+
+func TestTable_Render(t *testing.T) {
+	type Test struct {
+		query  string
+		sort   data.Sort
+		filter data.Filter
+		head   string
+		filt   string
+		pager  string
+	}
+
+	cities := echo.NewDataSet(
+		map[string]string{
+			"1": "London",
+			"2": "Paris",
+		},
+		true,
+	)
+
+	tests := map[string]Test{
+		"Default state": {
+			head:  `{"1_name":{"Action":"//google.com/users?sort=name","Filter":{"Form":"users-filter","Kind":"text","Name":"f_name"},"Label":"Name"},"2_city":{"Filter":{"Empty":{"Label":"(Empty)","Selected":true},"Form":"users-filter","Items":[{"Label":"London","Value":"1"},{"Label":"Paris","Value":"2"}],"Kind":"select","Name":"f_city"},"Label":"City"},"3_joined":{"Action":"//google.com/users?sort=joined","Filter":{"Form":"users-filter","From":{"Name":"f_joined_from","Value":""},"Kind":"date-range","To":{"Name":"f_joined_to","Value":""}},"Label":"Joined"}}`,
+			filt:  `{"Action":"//google.com/users","Id":"users-filter","Params":[],"Submit":"Filter"}`,
+			pager: `//google.com/users?pg=2`,
+		},
+		"Ascending sort": {
+			query: "sort=name&pg=2",
+			sort:  data.Sort{"name": data.SortAsc},
+			head:  `{"1_name":{"Action":"//google.com/users?sort=-name","Filter":{"Form":"users-filter","Kind":"text","Name":"f_name"},"Label":"Name","Sorted":"asc"},"2_city":{"Filter":{"Empty":{"Label":"(Empty)","Selected":true},"Form":"users-filter","Items":[{"Label":"London","Value":"1"},{"Label":"Paris","Value":"2"}],"Kind":"select","Name":"f_city"},"Label":"City"},"3_joined":{"Action":"//google.com/users?sort=joined","Filter":{"Form":"users-filter","From":{"Name":"f_joined_from","Value":""},"Kind":"date-range","To":{"Name":"f_joined_to","Value":""}},"Label":"Joined"}}`,
+			filt:  `{"Action":"//google.com/users","Id":"users-filter","Params":[{"Name":"sort","Value":"name"}],"Submit":"Filter"}`,
+			pager: `//google.com/users?pg=3&sort=name`,
+		},
+		"Descending sort with filters": {
+			query: "sort=-joined&f_name=bo&f_city=2&f_joined_from=2019-01-01&f_joined_to=2019-01-31&lang=en",
+			sort:  data.Sort{"joined": data.SortDesc},
+			filter: data.Filter{
+				"name": {Kind: data.FilterText, Value: "bo"},
+				"city": {Kind: data.FilterSelect, Value: "2"},
+				"joined": {
+					Kind: data.FilterDateRange,
+					From: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:   time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			head:  `{"1_name":{"Action":"//google.com/users?f_city=2\u0026f_joined_from=2019-01-01\u0026f_joined_to=2019-01-31\u0026f_name=bo\u0026lang=en\u0026sort=name","Filter":{"Form":"users-filter","Kind":"text","Name":"f_name","Value":"bo"},"Label":"Name"},"2_city":{"Filter":{"Empty":{"Label":"(Empty)"},"Form":"users-filter","Items":[{"Label":"London","Value":"1"},{"Label":"Paris","Selected":true,"Value":"2"}],"Kind":"select","Name":"f_city"},"Label":"City"},"3_joined":{"Action":"//google.com/users?f_city=2\u0026f_joined_from=2019-01-01\u0026f_joined_to=2019-01-31\u0026f_name=bo\u0026lang=en\u0026sort=joined","Filter":{"Form":"users-filter","From":{"Name":"f_joined_from","Value":"2019-01-01"},"Kind":"date-range","To":{"Name":"f_joined_to","Value":"2019-01-31"}},"Label":"Joined","Sorted":"desc"}}`,
+			filt:  `{"Action":"//google.com/users","Id":"users-filter","Params":[{"Name":"lang","Value":"en"},{"Name":"sort","Value":"-joined"}],"Submit":"Filter"}`,
+			pager: `//google.com/users?f_city=2&f_joined_from=2019-01-01&f_joined_to=2019-01-31&f_name=bo&lang=en&pg=2&sort=-joined`,
+		},
+		"Unknown sort and invalid date must be ignored": {
+			query: "sort=password&f_joined_from=yesterday",
+			head:  `{"1_name":{"Action":"//google.com/users?f_joined_from=yesterday\u0026sort=name","Filter":{"Form":"users-filter","Kind":"text","Name":"f_name"},"Label":"Name"},"2_city":{"Filter":{"Empty":{"Label":"(Empty)","Selected":true},"Form":"users-filter","Items":[{"Label":"London","Value":"1"},{"Label":"Paris","Value":"2"}],"Kind":"select","Name":"f_city"},"Label":"City"},"3_joined":{"Action":"//google.com/users?f_joined_from=yesterday\u0026sort=joined","Filter":{"Form":"users-filter","From":{"Name":"f_joined_from","Value":"yesterday"},"Kind":"date-range","To":{"Name":"f_joined_to","Value":""}},"Label":"Joined"}}`,
+			filt:  `{"Action":"//google.com/users","Id":"users-filter","Params":[{"Name":"sort","Value":"password"}],"Submit":"Filter"}`,
+			pager: `//google.com/users?f_joined_from=yesterday&pg=2&sort=password`,
+		},
+	}
+
+	e := echo.New()
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := &sortedProviderMock{
+				dataProviderMock: dataProviderMock{
+					all: []*dataMock{
+						{Key: "Key1"},
+						{Key: "Key2"},
+						{Key: "Key3"},
+					},
+				},
+			}
+			table := &Table{
+				Pager: Pager{
+					Id:       "users",
+					Capacity: 2,
+					BtnCount: 2,
+					Url: &url.URL{
+						Host:     "google.com",
+						Path:     "/users",
+						RawQuery: test.query,
+					},
+					Provider: provider,
+				},
+				Columns: TableColumns{
+					"1_name": {
+						Label:  "Name",
+						Sort:   "name",
+						Filter: &TableFilter{Kind: data.FilterText, Key: "name"},
+						Data: func() (interface{}, error) {
+							return provider.row.Key, nil
+						},
+					},
+					"2_city": {
+						Label:  "City",
+						Filter: &TableFilter{Kind: data.FilterSelect, Key: "city", Items: cities},
+						Data: func() (interface{}, error) {
+							return "London", nil
+						},
+					},
+					"3_joined": {
+						Label:  "Joined",
+						Sort:   "joined",
+						Filter: &TableFilter{Kind: data.FilterDateRange, Key: "joined"},
+						Data: func() (interface{}, error) {
+							return "2019-01-15", nil
+						},
+					},
+				},
+			}
+
+			c := e.NewContext(nil, nil)
+			tree, err := table.Render(c)
+			require.NoError(t, err)
+			assert.Equal(t, test.sort, provider.sort)
+			assert.Equal(t, test.filter, provider.filter)
+
+			res := tree.(map[string]interface{})
+			head, err := json.Marshal(res["Head"])
+			require.NoError(t, err)
+			assert.Equal(t, test.head, string(head))
+			filters, err := json.Marshal(res["Filters"])
+			require.NoError(t, err)
+			assert.Equal(t, test.filt, string(filters))
+			buttons := mapOf(mapOf(res["Pager"])["Buttons"])
+			assert.Equal(t, test.pager, mapOf(buttons["Next"])["Action"])
+		})
+	}
+}
@@ -75,6 +75,7 @@ var (
 	MessageSelectorEmpty            = DeclareDefaultMsg(1014, "(Empty)")
 	MessageMultistepFormPrev        = DeclareDefaultMsg(1015, "Prev")
 	MessageMultistepFormNext        = DeclareDefaultMsg(1016, "Next")
+	MessageTableFilterSubmit        = DeclareDefaultMsg(1017, "Filter")
 )
 
 func DeclareDefaultMsg(msg MESSAGE, message string) MESSAGE {